@@ -1,5 +1,6 @@
 // Command mermaid-lint is a linter for Mermaid diagram files (.mmd)
-// and Mermaid code blocks embedded in Markdown files (.md).
+// and Mermaid diagrams embedded in Markdown, MDX, HTML, AsciiDoc,
+// reStructuredText and Org files and Jupyter notebooks.
 package main
 
 import (
@@ -27,7 +28,7 @@ func run() int {
 	outputFormat := flag.String("format", "text", "output format: text or json")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: mermaid-lint [flags] <files or directories...>\n\n")
-		fmt.Fprintf(os.Stderr, "A linter for Mermaid diagram files (.mmd) and Mermaid blocks in Markdown (.md).\n\n")
+		fmt.Fprintf(os.Stderr, "A linter for Mermaid diagram files (.mmd) and Mermaid blocks in Markdown (.md), MDX, HTML, AsciiDoc, reStructuredText, Org and Jupyter notebooks.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
@@ -62,7 +63,7 @@ func run() int {
 
 	l := linter.New(cfg)
 
-	files, err := collectFiles(args)
+	files, err := collectFiles(args, l.Supports)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return 1
@@ -138,8 +139,13 @@ func printFindingsJSON(findings []linter.Finding) {
 		if i == len(findings)-1 {
 			comma = ""
 		}
-		fmt.Printf("  {\"rule\": %q, \"severity\": %q, \"message\": %q, \"file\": %q, \"line\": %d}%s\n",
-			f.Rule, f.Severity, f.Message, f.File, f.Line, comma)
+		// Findings in a notebook also give the cell their lines count from.
+		cell := ""
+		if f.Cell > 0 {
+			cell = fmt.Sprintf(", \"cell\": %d", f.Cell)
+		}
+		fmt.Printf("  {\"rule\": %q, \"severity\": %q, \"message\": %q, \"file\": %q%s, \"line\": %d, \"column\": %d, \"endLine\": %d, \"endColumn\": %d}%s\n",
+			f.Rule, f.Severity, f.Message, f.File, cell, f.Line, f.Column, f.EndLine, f.EndColumn, comma)
 	}
 	fmt.Println("]")
 }
@@ -163,7 +169,7 @@ func filterBySeverity(findings []linter.Finding, minSeverity config.Severity) []
 	return filtered
 }
 
-func collectFiles(args []string) ([]string, error) {
+func collectFiles(args []string, supported func(path string) bool) ([]string, error) {
 	var files []string
 	for _, arg := range args {
 		info, err := os.Stat(arg)
@@ -171,13 +177,13 @@ func collectFiles(args []string) ([]string, error) {
 			return nil, fmt.Errorf("cannot access %s: %w", arg, err)
 		}
 		if info.IsDir() {
-			dirFiles, err := walkDir(arg)
+			dirFiles, err := walkDir(arg, supported)
 			if err != nil {
 				return nil, err
 			}
 			files = append(files, dirFiles...)
 		} else {
-			if isSupportedFile(arg) {
+			if supported(arg) {
 				files = append(files, arg)
 			}
 		}
@@ -185,7 +191,7 @@ func collectFiles(args []string) ([]string, error) {
 	return files, nil
 }
 
-func walkDir(dir string) ([]string, error) {
+func walkDir(dir string, supported func(path string) bool) ([]string, error) {
 	var files []string
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -199,15 +205,10 @@ func walkDir(dir string) ([]string, error) {
 			}
 			return nil
 		}
-		if isSupportedFile(path) {
+		if supported(path) {
 			files = append(files, path)
 		}
 		return nil
 	})
 	return files, err
 }
-
-func isSupportedFile(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".mmd" || ext == ".mermaid" || ext == ".md" || ext == ".markdown"
-}
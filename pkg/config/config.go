@@ -30,6 +30,7 @@ type Config struct {
 func DefaultConfig() *Config {
 	return &Config{
 		Rules: map[string]RuleConfig{
+			"syntax-error":            {Enabled: true, Severity: SeverityError},
 			"no-unknown-diagram-type": {Enabled: true, Severity: SeverityError},
 			"no-empty-diagram":        {Enabled: true, Severity: SeverityWarning},
 			"valid-direction":          {Enabled: true, Severity: SeverityError},
@@ -0,0 +1,95 @@
+package linter
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// FileType is a kind of file the linter reads, recognized by its
+// extension.
+type FileType struct {
+	Name       string
+	Extensions []string           // Lowercase and with the dot, e.g. ".md"
+	Extractors []parser.Extractor // Find the diagrams in the file; none if the file is a diagram
+}
+
+// DefaultFileTypes returns the file types a new Linter reads.
+func DefaultFileTypes() []FileType {
+	return []FileType{
+		{
+			Name:       "mermaid",
+			Extensions: []string{".mmd", ".mermaid"},
+		},
+		{
+			Name:       "markdown",
+			Extensions: []string{".md", ".markdown"},
+			Extractors: []parser.Extractor{
+				&parser.MarkdownExtractor{},
+				&parser.ColonFenceExtractor{},
+				&parser.HugoExtractor{},
+				&parser.HTMLExtractor{Markdown: true},
+			},
+		},
+		{
+			Name:       "mdx",
+			Extensions: []string{".mdx"},
+			Extractors: []parser.Extractor{
+				&parser.MarkdownExtractor{},
+				&parser.MDXExtractor{},
+				&parser.HTMLExtractor{Markdown: true},
+			},
+		},
+		{
+			Name:       "html",
+			Extensions: []string{".html", ".htm"},
+			Extractors: []parser.Extractor{&parser.HTMLExtractor{}},
+		},
+		{
+			Name:       "asciidoc",
+			Extensions: []string{".adoc", ".asciidoc", ".asc"},
+			Extractors: []parser.Extractor{&parser.AsciiDocExtractor{}},
+		},
+		{
+			Name:       "rst",
+			Extensions: []string{".rst"},
+			Extractors: []parser.Extractor{&parser.RSTExtractor{}},
+		},
+		{
+			Name:       "org",
+			Extensions: []string{".org"},
+			Extractors: []parser.Extractor{&parser.OrgExtractor{}},
+		},
+		{
+			Name:       "notebook",
+			Extensions: []string{".ipynb"},
+			Extractors: []parser.Extractor{&parser.NotebookExtractor{}},
+		},
+	}
+}
+
+// RegisterFileType adds a file type to the ones the linter reads. It takes
+// precedence over earlier registrations of the same extensions.
+func (l *Linter) RegisterFileType(ft FileType) {
+	l.FileTypes = append(l.FileTypes, ft)
+}
+
+// FileType returns the file type of path, by its extension.
+func (l *Linter) FileType(path string) (FileType, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := len(l.FileTypes) - 1; i >= 0; i-- {
+		for _, e := range l.FileTypes[i].Extensions {
+			if e == ext {
+				return l.FileTypes[i], true
+			}
+		}
+	}
+	return FileType{}, false
+}
+
+// Supports reports whether path is of a file type the linter reads.
+func (l *Linter) Supports(path string) bool {
+	_, ok := l.FileType(path)
+	return ok
+}
@@ -0,0 +1,100 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skjutare/mermaid-lint/pkg/config"
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+func TestFileType(t *testing.T) {
+	l := New(config.DefaultConfig())
+	tests := map[string]string{
+		"a.mmd":           "mermaid",
+		"docs/README.MD":  "markdown",
+		"page.mdx":        "mdx",
+		"site/index.html": "html",
+		"guide.adoc":      "asciidoc",
+		"index.rst":       "rst",
+		"notes.org":       "org",
+		"notes.txt":       "",
+	}
+	for path, want := range tests {
+		ft, ok := l.FileType(path)
+		if ft.Name != want || ok != (want != "") || l.Supports(path) != ok {
+			t.Errorf("FileType(%q) = %q, %v; want %q", path, ft.Name, ok, want)
+		}
+	}
+
+	l.RegisterFileType(FileType{Name: "wiki", Extensions: []string{".txt", ".md"}, Extractors: []parser.Extractor{&parser.ColonFenceExtractor{}}})
+	if ft, _ := l.FileType("notes.txt"); ft.Name != "wiki" {
+		t.Errorf("registered type not found for .txt, got %q", ft.Name)
+	}
+	if ft, _ := l.FileType("a.md"); ft.Name != "wiki" {
+		t.Errorf("registered type does not replace markdown, got %q", ft.Name)
+	}
+}
+
+func TestLintFile_EmbeddedDiagrams(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"page.html": "<p>Hi</p>\n<pre class=\"mermaid\">\nflowchart XX\n  A --&gt; B\n</pre>\n",
+		"page.mdx":  "# Title\n\n<Mermaid chart={`flowchart XX\n  A --> B`} />\n",
+		"wiki.md":   "Intro\n\n::: mermaid\nflowchart XX\n  A --> B\n:::\n",
+		"hugo.md":   "{{< mermaid >}}\nflowchart XX\n  A --> B\n{{< /mermaid >}}\n",
+		"doc.adoc":  "= Doc\n\n[mermaid]\n----\nflowchart XX\n  A --> B\n----\n",
+		"doc.rst":   "Doc\n===\n\n.. mermaid::\n\n   flowchart XX\n     A --> B\n",
+		"doc.org":   "* Doc\n#+begin_src mermaid\nflowchart XX\n  A --> B\n#+end_src\n",
+	}
+	wantLine := map[string]int{"page.html": 3, "page.mdx": 3, "wiki.md": 4, "hugo.md": 2, "doc.adoc": 5, "doc.rst": 6, "doc.org": 3}
+
+	l := New(config.DefaultConfig())
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		findings, err := l.LintFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		found := findByRule(findings, "valid-direction")
+		if len(found) != 1 || found[0].Line != wantLine[name] || found[0].File != path {
+			t.Errorf("%s: valid-direction findings = %v, want one on line %d", name, found, wantLine[name])
+		}
+		if errs := findByRule(findings, "syntax-error"); len(errs) != 0 {
+			t.Errorf("%s: unexpected syntax errors %v", name, errs)
+		}
+	}
+
+	if _, err := l.LintFile(filepath.Join(dir, "notes.txt")); err == nil {
+		t.Error("expected an error for an unsupported file type")
+	}
+}
+
+func TestLintFile_Notebook(t *testing.T) {
+	nb := `{"cells": [
+  {"cell_type": "code", "source": ["x = 1\n"]},
+  {"cell_type": "markdown", "source": ["Intro\n", "` + "```mermaid" + `\n", "flowchart XX\n", "  A --> B\n", "` + "```" + `\n"]}
+ ], "nbformat": 4, "nbformat_minor": 5}`
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	if err := os.WriteFile(path, []byte(nb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := New(config.DefaultConfig()).LintFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := findByRule(findings, "valid-direction")
+	if len(found) != 1 || found[0].Cell != 2 || found[0].Line != 3 {
+		t.Fatalf("valid-direction findings = %v, want one in cell 2 on line 3", found)
+	}
+	for _, f := range findings {
+		if f.Cell != 2 {
+			t.Errorf("finding without its cell: %v", f)
+		}
+	}
+}
@@ -12,94 +12,144 @@ import (
 	"github.com/skjutare/mermaid-lint/pkg/parser"
 )
 
-// Finding represents a single lint finding.
+// Finding represents a single lint finding. Columns are 1-based and
+// count characters; they are 0 when only the line is known. The span ends
+// before EndColumn on EndLine. In a Jupyter notebook, Cell is the 1-based
+// index of the cell holding the finding and lines count from the start of
+// that cell; elsewhere Cell is 0.
 type Finding struct {
-	Rule     string
-	Severity config.Severity
-	Message  string
-	File     string
-	Line     int
+	Rule      string
+	Severity  config.Severity
+	Message   string
+	File      string
+	Cell      int
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
 }
 
-// String returns a human-readable representation of the finding.
+// findingAt returns a finding for rule located at r.
+func findingAt(rule, message string, r parser.Range) Finding {
+	return Finding{
+		Rule:      rule,
+		Message:   message,
+		Line:      r.Start.Line,
+		Column:    r.Start.Column,
+		EndLine:   r.End.Line,
+		EndColumn: r.End.Column,
+	}
+}
+
+// String returns a human-readable representation of the finding. Findings
+// in a notebook are located as "file[cell N]:line:column".
 func (f Finding) String() string {
 	loc := f.File
-	if f.Line > 0 {
-		loc = fmt.Sprintf("%s:%d", f.File, f.Line)
+	if f.Cell > 0 {
+		loc = fmt.Sprintf("%s[cell %d]", f.File, f.Cell)
+	}
+	switch {
+	case f.Line > 0 && f.Column > 0:
+		loc = fmt.Sprintf("%s:%d:%d", loc, f.Line, f.Column)
+	case f.Line > 0:
+		loc = fmt.Sprintf("%s:%d", loc, f.Line)
 	}
 	return fmt.Sprintf("%s [%s] %s (%s)", loc, f.Severity, f.Message, f.Rule)
 }
 
 // Linter runs lint rules against Mermaid diagrams.
 type Linter struct {
-	Config *config.Config
-	Rules  []Rule
+	Config    *config.Config
+	Rules     []Rule
+	FileTypes []FileType
 }
 
 // New creates a new Linter with the given configuration.
 func New(cfg *config.Config) *Linter {
 	l := &Linter{Config: cfg}
 	l.Rules = AllRules()
+	l.FileTypes = DefaultFileTypes()
 	return l
 }
 
-// LintFile lints a single file (either .mmd or .md).
+// LintFile lints a single file of one of the linter's file types: a
+// Mermaid file, or a file with embedded diagrams such as Markdown.
 func (l *Linter) LintFile(path string) ([]Finding, error) {
-	ext := strings.ToLower(filepath.Ext(path))
-
-	switch ext {
-	case ".mmd", ".mermaid":
+	ft, ok := l.FileType(path)
+	if !ok {
+		return nil, fmt.Errorf("unsupported file type: %s", strings.ToLower(filepath.Ext(path)))
+	}
+	if len(ft.Extractors) == 0 {
 		return l.lintMermaidFile(path)
-	case ".md", ".markdown":
-		return l.lintMarkdownFile(path)
-	default:
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
 	}
-}
 
-func (l *Linter) lintMermaidFile(path string) ([]Finding, error) {
-	data, err := os.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	source := string(data)
-	diagram := parser.Parse(source, 1)
-	return l.lintDiagram(diagram, path), nil
+	return l.LintReader(f, path, ft.Extractors...)
 }
 
-func (l *Linter) lintMarkdownFile(path string) ([]Finding, error) {
-	f, err := os.Open(path)
+func (l *Linter) lintMermaidFile(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
-	return l.LintMarkdownReader(f, path)
+	source := string(data)
+	diagram, _ := parser.Parse(source, 1)
+	return l.lintDiagram(diagram, path), nil
 }
 
 // LintMarkdownReader lints mermaid blocks extracted from a markdown reader.
+// Unclosed and empty mermaid blocks are reported as syntax errors at their
+// opening fence.
 func (l *Linter) LintMarkdownReader(r io.Reader, filename string) ([]Finding, error) {
-	blocks, err := parser.ExtractMermaidBlocks(r)
+	return l.LintReader(r, filename, &parser.MarkdownExtractor{})
+}
+
+// LintReader lints the diagrams the extractors find in r. Problems they
+// report, such as unclosed blocks, are syntax errors.
+func (l *Linter) LintReader(r io.Reader, filename string, extractors ...parser.Extractor) ([]Finding, error) {
+	blocks, diags, err := parser.ExtractAll(r, extractors...)
 	if err != nil {
 		return nil, err
 	}
 
-	var findings []Finding
+	findings := l.lintDiagnostics(diags, filename)
 	for _, block := range blocks {
-		// StartLine+1 because the mermaid source starts on the line after the fence
-		diagram := parser.Parse(block.Source, block.StartLine+1)
-		findings = append(findings, l.lintDiagram(diagram, filename)...)
+		diagram, _ := parser.ParseBlock(block)
+		for _, f := range l.lintDiagram(diagram, filename) {
+			f.Cell = block.Cell
+			findings = append(findings, f)
+		}
 	}
 	return findings, nil
 }
 
 // LintSource lints raw mermaid source code.
 func (l *Linter) LintSource(source string, filename string) []Finding {
-	diagram := parser.Parse(source, 1)
+	diagram, _ := parser.Parse(source, 1)
 	return l.lintDiagram(diagram, filename)
 }
 
+// lintDiagnostics reports problems found outside of any diagram, such as
+// an unclosed fence, under the syntax-error rule.
+func (l *Linter) lintDiagnostics(diags []parser.Diagnostic, filename string) []Finding {
+	rule := &SyntaxError{}
+	if !l.Config.IsRuleEnabled(rule.Name()) {
+		return nil
+	}
+	findings := rule.findings(diags)
+	for i := range findings {
+		findings[i].File = filename
+		findings[i].Severity = l.Config.RuleSeverity(rule.Name())
+	}
+	return findings
+}
+
 func (l *Linter) lintDiagram(d *parser.Diagram, filename string) []Finding {
 	var findings []Finding
 	for _, rule := range l.Rules {
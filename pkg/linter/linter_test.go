@@ -29,6 +29,17 @@ func TestLintSource_ValidFlowchart(t *testing.T) {
 	}
 }
 
+func TestLintSource_Frontmatter(t *testing.T) {
+	cfg := config.DefaultConfig()
+	l := New(cfg)
+
+	source := "---\ntitle: Flow\nconfig:\n  theme: dark\n---\n%%{init: {'flowchart': {'curve': 'basis'}}}%%\nflowchart LR\n  A[Start] --> B[End]"
+	findings := l.LintSource(source, "test.mmd")
+	if len(findings) > 0 {
+		t.Errorf("expected no findings for a diagram with frontmatter, got: %v", findings)
+	}
+}
+
 func TestLintSource_InvalidDirection(t *testing.T) {
 	cfg := config.DefaultConfig()
 	l := New(cfg)
@@ -36,7 +47,19 @@ func TestLintSource_InvalidDirection(t *testing.T) {
 	findings := l.LintSource("flowchart XX\n  A --> B", "test.mmd")
 	found := findByRule(findings, "valid-direction")
 	if len(found) == 0 {
-		t.Error("expected finding for invalid direction")
+		t.Fatal("expected finding for invalid direction")
+	}
+	if f := found[0]; f.Line != 1 || f.Column != 11 || f.EndLine != 1 || f.EndColumn != 13 {
+		t.Errorf("finding at %d:%d-%d:%d, want 1:11-1:13", f.Line, f.Column, f.EndLine, f.EndColumn)
+	}
+}
+
+func TestLintSource_DirectionWithSemicolon(t *testing.T) {
+	l := New(config.DefaultConfig())
+
+	findings := l.LintSource("graph TD;\n  A --> B", "test.mmd")
+	if found := findByRule(findings, "valid-direction"); len(found) != 0 {
+		t.Errorf("unexpected findings for \"graph TD;\": %v", found)
 	}
 }
 
@@ -47,7 +70,23 @@ func TestLintSource_EmptyDiagram(t *testing.T) {
 	findings := l.LintSource("flowchart LR\n", "test.mmd")
 	found := findByRule(findings, "no-empty-diagram")
 	if len(found) == 0 {
-		t.Error("expected finding for empty diagram")
+		t.Fatal("expected finding for empty diagram")
+	}
+	if f := found[0]; f.Line != 1 || f.Column != 1 || f.EndLine != 1 || f.EndColumn != 10 {
+		t.Errorf("finding at %d:%d-%d:%d, want 1:1-1:10", f.Line, f.Column, f.EndLine, f.EndColumn)
+	}
+}
+
+func TestLintSource_MissingDiagramType(t *testing.T) {
+	l := New(config.DefaultConfig())
+
+	findings := l.LintSource("---\ntitle: Flow\n---\n", "test.mmd")
+	found := findByRule(findings, "no-unknown-diagram-type")
+	if len(found) != 1 {
+		t.Fatalf("expected 1 finding for a missing diagram type, got %v", findings)
+	}
+	if f := found[0]; f.Line != 4 || f.Column != 1 {
+		t.Errorf("finding at %d:%d, want 4:1", f.Line, f.Column)
 	}
 }
 
@@ -86,6 +125,20 @@ func TestLintSource_DisabledRule(t *testing.T) {
 	}
 }
 
+func TestLintSource_SyntaxError(t *testing.T) {
+	cfg := config.DefaultConfig()
+	l := New(cfg)
+
+	findings := l.LintSource("flowchart LR\n  A[Start --> B\n  B -->", "test.mmd")
+	found := findByRule(findings, "syntax-error")
+	if len(found) != 2 {
+		t.Fatalf("expected 2 syntax errors, got %v", found)
+	}
+	if found[0].Line != 2 || found[1].Line != 3 || found[0].Severity != config.SeverityError {
+		t.Errorf("unexpected findings: %v", found)
+	}
+}
+
 func TestLintMarkdownReader(t *testing.T) {
 	cfg := config.DefaultConfig()
 	l := New(cfg)
@@ -104,6 +157,38 @@ func TestLintMarkdownReader(t *testing.T) {
 	}
 }
 
+func TestLintMarkdownReader_Columns(t *testing.T) {
+	cfg := config.DefaultConfig()
+	l := New(cfg)
+
+	md := "> ```mermaid\n> flowchart LR\n>   A --> B[oops\n> ```\n"
+	findings, err := l.LintMarkdownReader(strings.NewReader(md), "test.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := findByRule(findings, "syntax-error")
+	if len(found) != 1 {
+		t.Fatalf("expected 1 syntax error, got %v", findings)
+	}
+	if f := found[0]; f.Line != 3 || f.Column != 12 || f.EndLine != 3 || f.EndColumn != 17 {
+		t.Errorf("finding at %d:%d-%d:%d, want 3:12-3:17", f.Line, f.Column, f.EndLine, f.EndColumn)
+	}
+
+	labels := findByRule(findings, "node-has-label")
+	if len(labels) == 0 || labels[0].Column != 5 {
+		t.Errorf("expected node-has-label for A at column 5, got %v", labels)
+	}
+
+	md = "> ```mermaid\n> sankey-beta\n>   A,B,many\n> ```\n"
+	findings, err = l.LintMarkdownReader(strings.NewReader(md), "test.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found := findByRule(findings, "syntax-error"); len(found) != 1 || found[0].Column != 5 || found[0].EndColumn != 13 {
+		t.Errorf("expected a sankey syntax error at 3:5-3:13, got %v", findings)
+	}
+}
+
 func TestFindingString(t *testing.T) {
 	f := Finding{
 		Rule:     "test-rule",
@@ -121,6 +206,20 @@ func TestFindingString(t *testing.T) {
 	}
 }
 
+func TestFindingString_Column(t *testing.T) {
+	f := Finding{Rule: "test-rule", Severity: config.SeverityInfo, File: "test.md", Line: 5, Column: 3}
+	if s := f.String(); !strings.HasPrefix(s, "test.md:5:3 ") {
+		t.Errorf("expected file:line:column in output, got %q", s)
+	}
+}
+
+func TestFindingString_Cell(t *testing.T) {
+	f := Finding{Rule: "test-rule", Severity: config.SeverityError, File: "nb.ipynb", Cell: 3, Line: 5, Column: 2}
+	if s := f.String(); !strings.HasPrefix(s, "nb.ipynb[cell 3]:5:2 ") {
+		t.Errorf("expected file[cell N]:line:column in output, got %q", s)
+	}
+}
+
 func findByRule(findings []Finding, rule string) []Finding {
 	var result []Finding
 	for _, f := range findings {
@@ -140,3 +239,29 @@ func findBySeverity(findings []Finding, sev config.Severity) []Finding {
 	}
 	return result
 }
+
+func TestLintMarkdownReader_FenceProblems(t *testing.T) {
+	cfg := config.DefaultConfig()
+	l := New(cfg)
+
+	md := "# Doc\n\n```mermaid\n\n```\n\nText\n\n  ~~~mermaid\n  flowchart LR\n"
+	findings, err := l.LintMarkdownReader(strings.NewReader(md), "test.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", findings)
+	}
+	empty, unclosed := findings[0], findings[1]
+	if empty.Rule != "syntax-error" || empty.Message != "mermaid code block is empty" || empty.Line != 3 || empty.File != "test.md" {
+		t.Errorf("empty block finding = %v", empty)
+	}
+	if unclosed.Line != 9 || unclosed.Column != 3 || unclosed.Severity != config.SeverityError {
+		t.Errorf("unclosed block finding = %v", unclosed)
+	}
+
+	cfg.Rules["syntax-error"] = config.RuleConfig{Enabled: false}
+	if findings, _ := New(cfg).LintMarkdownReader(strings.NewReader(md), "test.md"); len(findings) != 0 {
+		t.Errorf("expected no findings with syntax-error disabled, got %v", findings)
+	}
+}
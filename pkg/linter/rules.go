@@ -17,6 +17,7 @@ type Rule interface {
 // AllRules returns all available lint rules.
 func AllRules() []Rule {
 	return []Rule{
+		&SyntaxError{},
 		&NoUnknownDiagramType{},
 		&NoEmptyDiagram{},
 		&ValidDirection{},
@@ -26,6 +27,35 @@ func AllRules() []Rule {
 	}
 }
 
+// --- Rule: syntax-error ---
+
+// SyntaxError reports the problems the parser found in the diagram source.
+type SyntaxError struct{}
+
+func (r *SyntaxError) Name() string        { return "syntax-error" }
+func (r *SyntaxError) Description() string { return "Diagram source must be well-formed" }
+
+func (r *SyntaxError) Check(d *parser.Diagram) []Finding {
+	return r.findings(d.Diagnostics)
+}
+
+// findings converts parser diagnostics into findings of this rule.
+func (r *SyntaxError) findings(diags []parser.Diagnostic) []Finding {
+	var findings []Finding
+	for _, diag := range diags {
+		findings = append(findings, Finding{
+			Rule:      r.Name(),
+			Message:   diag.Message,
+			Cell:      diag.Cell,
+			Line:      diag.Line,
+			Column:    diag.Column,
+			EndLine:   diag.EndLine,
+			EndColumn: diag.EndColumn,
+		})
+	}
+	return findings
+}
+
 // --- Rule: no-unknown-diagram-type ---
 
 // NoUnknownDiagramType checks that the diagram type is recognized.
@@ -36,18 +66,10 @@ func (r *NoUnknownDiagramType) Description() string  { return "Diagram type must
 
 func (r *NoUnknownDiagramType) Check(d *parser.Diagram) []Finding {
 	if d.Type == parser.DiagramUnknown && d.TypeRaw != "" {
-		return []Finding{{
-			Rule:    r.Name(),
-			Message: fmt.Sprintf("unknown diagram type %q", d.TypeRaw),
-			Line:    d.StartLine,
-		}}
+		return []Finding{findingAt(r.Name(), fmt.Sprintf("unknown diagram type %q", d.TypeRaw), d.TypeRange)}
 	}
 	if d.TypeRaw == "" {
-		return []Finding{{
-			Rule:    r.Name(),
-			Message: "no diagram type declaration found",
-			Line:    d.StartLine,
-		}}
+		return []Finding{findingAt(r.Name(), "no diagram type declaration found", d.TypeRange)}
 	}
 	return nil
 }
@@ -67,11 +89,7 @@ func (r *NoEmptyDiagram) Check(d *parser.Diagram) []Finding {
 	}
 
 	if len(d.Nodes) == 0 && len(d.Edges) == 0 {
-		return []Finding{{
-			Rule:    r.Name(),
-			Message: "diagram has no nodes or edges",
-			Line:    d.StartLine,
-		}}
+		return []Finding{findingAt(r.Name(), "diagram has no nodes or edges", d.TypeRange)}
 	}
 	return nil
 }
@@ -94,11 +112,8 @@ func (r *ValidDirection) Check(d *parser.Diagram) []Finding {
 	}
 	dir := strings.ToUpper(d.Direction)
 	if !parser.ValidFlowchartDirections[dir] {
-		return []Finding{{
-			Rule:    r.Name(),
-			Message: fmt.Sprintf("invalid flowchart direction %q; must be one of TB, TD, BT, LR, RL", d.Direction),
-			Line:    d.StartLine,
-		}}
+		return []Finding{findingAt(r.Name(),
+			fmt.Sprintf("invalid flowchart direction %q; must be one of TB, TD, BT, LR, RL", d.Direction), d.DirectionRange)}
 	}
 	return nil
 }
@@ -121,11 +136,8 @@ func (r *NoDuplicateNodeIDs) Check(d *parser.Diagram) []Finding {
 
 	for _, node := range d.Nodes {
 		if firstLine, exists := seen[node.ID]; exists {
-			findings = append(findings, Finding{
-				Rule:    r.Name(),
-				Message: fmt.Sprintf("duplicate node ID %q (first defined at line %d)", node.ID, firstLine),
-				Line:    node.Line,
-			})
+			findings = append(findings, findingAt(r.Name(),
+				fmt.Sprintf("duplicate node ID %q (first defined at line %d)", node.ID, firstLine), node.Range))
 		} else {
 			seen[node.ID] = node.Line
 		}
@@ -149,11 +161,7 @@ func (r *NodeHasLabel) Check(d *parser.Diagram) []Finding {
 	var findings []Finding
 	for _, node := range d.Nodes {
 		if node.Label == "" {
-			findings = append(findings, Finding{
-				Rule:    r.Name(),
-				Message: fmt.Sprintf("node %q has no label", node.ID),
-				Line:    node.Line,
-			})
+			findings = append(findings, findingAt(r.Name(), fmt.Sprintf("node %q has no label", node.ID), node.Range))
 		}
 	}
 	return findings
@@ -185,11 +193,8 @@ func (r *NoOrphanNodes) Check(d *parser.Diagram) []Finding {
 	var findings []Finding
 	for _, node := range d.Nodes {
 		if !connected[node.ID] {
-			findings = append(findings, Finding{
-				Rule:    r.Name(),
-				Message: fmt.Sprintf("node %q is not connected to any edge", node.ID),
-				Line:    node.Line,
-			})
+			findings = append(findings, findingAt(r.Name(),
+				fmt.Sprintf("node %q is not connected to any edge", node.ID), node.Range))
 		}
 	}
 	return findings
@@ -0,0 +1,281 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// BlockDiagram is the model of a block-beta diagram.
+type BlockDiagram struct {
+	Columns int      // Columns of the top level, 0 when unset or "auto"
+	Blocks  []*Block // Top-level blocks, in document order
+	Edges   []BlockEdge
+}
+
+// Block is a block, a composite "block ... end", a space or a block arrow.
+type Block struct {
+	ID        string // Empty for spaces; generated for composites without one
+	Label     string
+	Kind      string // "block", "composite", "space" or "arrow"
+	Shape     string // Shape as for flowchart nodes, empty for a plain block
+	Direction string // For arrows: "right", "left", "up", "down", "x" or "y"
+	Span      int    // Columns the block spans, at least 1
+	Columns   int    // For composites: columns inside, 0 when unset or "auto"
+	Parent    string // ID of the enclosing composite, if any
+	Children  []*Block
+	Line      int
+	EndLine   int   // For composites: line of "end", 0 if unclosed
+	Range     Range // Span of the definition; for composites, up to "end" if closed
+}
+
+// BlockEdge is an arrow between two blocks, such as A -- "text" --> B.
+type BlockEdge struct {
+	From  string
+	To    string
+	Link  string // Link operator, e.g. "-->"
+	Label string
+	Line  int
+	Range Range // From the start of the source block to the end of the target
+}
+
+// blockParser builds a BlockDiagram line by line, keeping a stack of the
+// composite blocks that are still open.
+type blockParser struct {
+	d          *Diagram
+	bd         *BlockDiagram
+	open       []*Block
+	ids        map[string]*Block
+	composites int
+}
+
+func (d *Diagram) parseBlock() {
+	p := &blockParser{d: d, bd: &BlockDiagram{}, ids: make(map[string]*Block)}
+	d.forEachBodyLine(p.parseLine)
+	for _, b := range p.open {
+		d.addDiagnostic(b.Line, "block %q is not closed", b.ID)
+	}
+	d.Block = p.bd
+}
+
+func (p *blockParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+	keyword, rest := splitKeyword(line)
+
+	switch {
+	case line == "end":
+		if len(p.open) == 0 {
+			p.d.addDiagnostic(lineNum, "\"end\" without an open block")
+			return
+		}
+		b := p.open[len(p.open)-1]
+		b.EndLine, b.Range.End = lineNum, p.d.lineRange(lineNum).End
+		p.open = p.open[:len(p.open)-1]
+	case keyword == "columns":
+		n := 0
+		if rest != "auto" {
+			var err error
+			if n, err = strconv.Atoi(rest); err != nil || n < 1 {
+				p.d.addDiagnostic(lineNum, "invalid column count %q", rest)
+				return
+			}
+		}
+		if len(p.open) > 0 {
+			p.open[len(p.open)-1].Columns = n
+		} else {
+			p.bd.Columns = n
+		}
+	case keyword == "block" || strings.HasPrefix(keyword, "block:"):
+		p.openComposite(keyword, lineNum)
+	case keyword == "classDef" || keyword == "class" || keyword == "style":
+		// Styling is not part of the block model.
+	default:
+		p.parseItems(line, lineNum)
+	}
+}
+
+// openComposite starts a "block:id:span" composite.
+func (p *blockParser) openComposite(header string, lineNum int) {
+	p.composites++
+	b := &Block{Kind: "composite", Span: 1, Line: lineNum, Range: p.d.lineRange(lineNum)}
+	parts := strings.Split(header, ":")
+	if len(parts) > 1 {
+		b.ID = parts[1]
+	}
+	if len(parts) > 2 {
+		b.Span = p.parseSpan(parts[2], lineNum)
+	}
+	if b.ID == "" {
+		b.ID = fmt.Sprintf("block%d", p.composites)
+	}
+	p.add(b)
+	p.open = append(p.open, b)
+}
+
+// parseItems reads the blocks and edges of a line, e.g.
+// `a["A"]:2 b --> c space:2`.
+func (p *blockParser) parseItems(line string, lineNum int) {
+	var prev *Block
+	var link *BlockEdge
+	var prevRange, linkRange Range // Spans of prev and link as written on this line
+	start := p.d.lineRange(lineNum).Start.Offset
+	s := line
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+		offset := start + len(line) - len(s)
+		if op, label, n := scanBlockLink(s); n > 0 {
+			link = &BlockEdge{Link: op, Label: label, Line: lineNum}
+			linkRange = p.d.span(offset, offset+n)
+			s = s[n:]
+			continue
+		}
+
+		b, n := p.scanItem(s, lineNum)
+		if n == 0 {
+			p.d.addSpanDiagnostic(offset, start+len(line), "unexpected %q in block diagram", s)
+			return
+		}
+		b.Range = p.d.span(offset, offset+n)
+		s = s[n:]
+		b, mention := p.add(b), b.Range
+
+		if link != nil {
+			if prev != nil && b.Kind != "space" {
+				link.From, link.To = prev.ID, b.ID
+				link.Range = Range{Start: prevRange.Start, End: mention.End}
+				p.bd.Edges = append(p.bd.Edges, *link)
+			}
+			link = nil
+		}
+		prev, prevRange = b, mention
+	}
+	if link != nil {
+		p.d.addRangeDiagnostic(linkRange, "edge %q has no target block", link.Link)
+	}
+}
+
+// scanItem reads "id", "id[label]", "id<[label]>(dir)" or "space", each
+// with an optional ":span". It returns the number of bytes consumed, 0 if
+// s does not start with a block.
+func (p *blockParser) scanItem(s string, lineNum int) (*Block, int) {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if r == '-' && n > 0 {
+			next, _ := utf8.DecodeRuneInString(s[n+size:])
+			if !isIDChar(next) {
+				break
+			}
+		} else if !isIDChar(r) {
+			break
+		}
+		n += size
+	}
+	if n == 0 {
+		return nil, 0
+	}
+
+	b := &Block{ID: s[:n], Kind: "block", Span: 1, Line: lineNum}
+	if b.ID == "space" {
+		b.ID, b.Kind = "", "space"
+	}
+
+	rest := s[n:]
+	switch {
+	case strings.HasPrefix(rest, "<["):
+		label, m, ok := scanLabel(rest[2:], "]>")
+		if !ok {
+			return nil, 0
+		}
+		b.Kind, b.Label = "arrow", unquote(label)
+		n += 2 + m
+		if dir, ok := strings.CutPrefix(s[n:], "("); ok {
+			if end := strings.IndexByte(dir, ')'); end >= 0 {
+				b.Direction = strings.TrimSpace(dir[:end])
+				n += 1 + end + 1
+			}
+		}
+	case rest != "" && strings.ContainsRune("[({>", rune(rest[0])):
+		matched := false
+		for _, delim := range shapeDelimiters {
+			if !strings.HasPrefix(rest, delim.open) {
+				continue
+			}
+			label, m, ok := scanLabel(rest[len(delim.open):], delim.close)
+			if !ok {
+				continue
+			}
+			b.Label, b.Shape = unquote(label), delim.shape
+			n += len(delim.open) + m
+			matched = true
+			break
+		}
+		if !matched {
+			return nil, 0
+		}
+	}
+
+	if span, ok := strings.CutPrefix(s[n:], ":"); ok {
+		digits := len(span) - len(strings.TrimLeft(span, "0123456789"))
+		b.Span = p.parseSpan(span[:digits], lineNum)
+		n += 1 + digits
+	}
+	return b, n
+}
+
+// parseSpan reads a column span, reporting and defaulting invalid ones
+// to 1.
+func (p *blockParser) parseSpan(s string, lineNum int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		p.d.addDiagnostic(lineNum, "invalid block span %q", s)
+		return 1
+	}
+	return n
+}
+
+// add places b in the innermost open composite. A block that is already
+// defined is not added again; the existing block is returned instead.
+func (p *blockParser) add(b *Block) *Block {
+	if b.Kind != "space" {
+		if existing, ok := p.ids[b.ID]; ok {
+			return existing
+		}
+		p.ids[b.ID] = b
+	}
+	if len(p.open) == 0 {
+		p.bd.Blocks = append(p.bd.Blocks, b)
+		return b
+	}
+	parent := p.open[len(p.open)-1]
+	b.Parent = parent.ID
+	parent.Children = append(parent.Children, b)
+	return b
+}
+
+// scanBlockLink reads a link operator, optionally with a label written as
+// "-- text -->" or "-->|text|". It returns the number of bytes consumed,
+// 0 if s does not start with a link.
+func scanBlockLink(s string) (op, label string, n int) {
+	link, label, n := matchLink(s, false)
+	if n <= 0 {
+		return "", "", 0
+	}
+	op = link.String()
+	if label != "" {
+		return op, unquote(label), n
+	}
+
+	rest := strings.TrimLeft(s[n:], " \t")
+	if text, ok := strings.CutPrefix(rest, "|"); ok {
+		if end := strings.IndexByte(text, '|'); end >= 0 {
+			label = unquote(strings.TrimSpace(text[:end]))
+			n = len(s) - len(text) + end + 1
+		}
+	}
+	return op, label, n
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_BlockDiagram(t *testing.T) {
+	source := "block-beta\n" +
+		"  columns 3\n" +
+		"  a[\"A label\"]:2 b\n" +
+		"  block:group1:2\n" +
+		"    columns auto\n" +
+		"    c((\"C\")) d\n" +
+		"  end\n" +
+		"  space:2\n" +
+		"  e<[\"go\"]>(right)\n" +
+		"  a --> b\n" +
+		"  b -- \"uses\" --> c\n" +
+		"  classDef blue fill:#66f\n"
+	d, _ := Parse(source, 1)
+
+	bd := d.Block
+	if bd == nil {
+		t.Fatal("expected a block model")
+	}
+	if len(d.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d.Diagnostics)
+	}
+	if bd.Columns != 3 {
+		t.Errorf("columns = %d", bd.Columns)
+	}
+	if len(bd.Blocks) != 5 {
+		t.Fatalf("expected 5 top-level blocks, got %d", len(bd.Blocks))
+	}
+	if a := bd.Blocks[0]; a.ID != "a" || a.Label != "A label" || a.Shape != "rect" || a.Span != 2 {
+		t.Errorf("a = %+v", a)
+	}
+	if b := bd.Blocks[1]; b.ID != "b" || b.Shape != "" || b.Span != 1 {
+		t.Errorf("b = %+v", b)
+	}
+
+	group := bd.Blocks[2]
+	if group.ID != "group1" || group.Kind != "composite" || group.Span != 2 || group.Line != 4 || group.EndLine != 7 {
+		t.Errorf("group = %+v", group)
+	}
+	if len(group.Children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(group.Children))
+	}
+	if c := group.Children[0]; c.ID != "c" || c.Shape != "circle" || c.Parent != "group1" {
+		t.Errorf("c = %+v", c)
+	}
+
+	if s := bd.Blocks[3]; s.Kind != "space" || s.Span != 2 {
+		t.Errorf("space = %+v", s)
+	}
+	if e := bd.Blocks[4]; e.Kind != "arrow" || e.Label != "go" || e.Direction != "right" {
+		t.Errorf("arrow = %+v", e)
+	}
+
+	want := []BlockEdge{
+		{From: "a", To: "b", Link: "-->", Line: 10},
+		{From: "b", To: "c", Link: "-->", Label: "uses", Line: 11},
+	}
+	if len(bd.Edges) != len(want) {
+		t.Fatalf("expected %d edges, got %+v", len(want), bd.Edges)
+	}
+	for i, w := range want {
+		got := bd.Edges[i]
+		got.Range = Range{}
+		if got != w {
+			t.Errorf("edge %d = %+v, want %+v", i, bd.Edges[i], w)
+		}
+	}
+	if r := bd.Edges[1].Range; r.Start.Line != 11 || r.Start.Column != 3 || r.End.Column != 20 {
+		t.Errorf("edge 1 range = %+v, want 11:3-11:20", r)
+	}
+	if r := bd.Blocks[0].Range; r.Start.Line != 3 || r.Start.Column != 3 || r.End.Column != 17 {
+		t.Errorf("a range = %+v, want 3:3-3:17", r)
+	}
+	if r := group.Range; r.Start.Line != 4 || r.End.Line != 7 || r.End.Column != 6 {
+		t.Errorf("group range = %+v, want 4:3-7:6", r)
+	}
+}
+
+func TestParse_BlockDiagnostics(t *testing.T) {
+	d, _ := Parse("block-beta\n  columns x\n  end\n  block:g\n    a -->", 1)
+
+	want := []struct {
+		line int
+		text string
+	}{
+		{2, "invalid column count"},
+		{3, "without an open block"},
+		{5, "no target"},
+		{4, "not closed"},
+	}
+	if len(d.Diagnostics) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %+v", len(want), d.Diagnostics)
+	}
+	for i, w := range want {
+		diag := d.Diagnostics[i]
+		if diag.Line != w.line || !strings.Contains(diag.Message, w.text) {
+			t.Errorf("diagnostic %d = %+v, want line %d containing %q", i, diag, w.line, w.text)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// C4Diagram is the model of a C4Context, C4Container, C4Component,
+// C4Dynamic or C4Deployment diagram.
+type C4Diagram struct {
+	Title         string
+	Elements      []C4Element
+	Boundaries    []C4Boundary
+	Relationships []C4Relationship
+	Styles        []C4StyleUpdate
+}
+
+// C4Args holds the arguments of a C4 macro call. Positional arguments are
+// unquoted; named arguments such as $tags="x" are keyed without the $.
+type C4Args struct {
+	Positional []string
+	Named      map[string]string
+}
+
+// C4Element is a person, system, container or component, such as
+// Container_Ext(api, "API", "Go", "Serves requests").
+type C4Element struct {
+	Macro       string // Macro as written, e.g. "SystemDb_Ext"
+	Kind        string // "person", "system", "container" or "component"
+	Storage     string // "db", "queue" or empty
+	External    bool   // An _Ext variant
+	Alias       string
+	Label       string
+	Technology  string
+	Description string
+	Boundary    string // Alias of the enclosing boundary, if any
+	Args        C4Args
+	Line        int
+	Range       Range
+}
+
+// C4Boundary is a boundary block, including deployment nodes.
+type C4Boundary struct {
+	Macro       string // e.g. "System_Boundary", "Deployment_Node"
+	Alias       string
+	Label       string
+	Type        string // Type argument of Boundary and deployment nodes
+	Description string
+	Parent      string // Alias of the enclosing boundary, if any
+	Args        C4Args
+	Line        int
+	EndLine     int   // Line of the closing brace, 0 if unclosed
+	Range       Range // Up to the closing brace or, if unclosed, the opening line
+}
+
+// C4Relationship is a Rel, BiRel, directional Rel_* or RelIndex call.
+type C4Relationship struct {
+	Macro         string
+	From          string
+	To            string
+	Label         string
+	Technology    string
+	Description   string
+	Direction     string // "up", "down", "left", "right", "back" or empty
+	Bidirectional bool
+	Index         string // Index of a RelIndex, if any
+	Args          C4Args
+	Line          int
+	Range         Range
+}
+
+// C4StyleUpdate is an UpdateElementStyle, UpdateRelStyle or other Update*
+// call. Targets holds the element alias, or the two ends of a
+// relationship.
+type C4StyleUpdate struct {
+	Macro   string
+	Targets []string
+	Args    C4Args
+	Line    int
+	Range   Range
+}
+
+// c4ElementKinds maps element macro stems to their kind and storage.
+var c4ElementKinds = map[string][2]string{
+	"Person":         {"person", ""},
+	"System":         {"system", ""},
+	"SystemDb":       {"system", "db"},
+	"SystemQueue":    {"system", "queue"},
+	"Container":      {"container", ""},
+	"ContainerDb":    {"container", "db"},
+	"ContainerQueue": {"container", "queue"},
+	"Component":      {"component", ""},
+	"ComponentDb":    {"component", "db"},
+	"ComponentQueue": {"component", "queue"},
+}
+
+// c4BoundaryMacros are the macros that open a { } block.
+var c4BoundaryMacros = map[string]bool{
+	"Boundary":            true,
+	"Enterprise_Boundary": true,
+	"System_Boundary":     true,
+	"Container_Boundary":  true,
+	"Deployment_Node":     true,
+	"Node":                true,
+	"Node_L":              true,
+	"Node_R":              true,
+}
+
+// c4RelDirections maps directional relationship macros to a direction.
+var c4RelDirections = map[string]string{
+	"Rel": "", "BiRel": "",
+	"Rel_U": "up", "Rel_Up": "up",
+	"Rel_D": "down", "Rel_Down": "down",
+	"Rel_L": "left", "Rel_Left": "left",
+	"Rel_R": "right", "Rel_Right": "right",
+	"Rel_Back": "back",
+	"RelIndex": "",
+}
+
+// c4CallPattern matches a macro call, optionally opening a block:
+// Name(args) {
+var c4CallPattern = regexp.MustCompile(`^(\w+)\s*\((.*)\)\s*(\{)?$`)
+
+// c4Parser builds a C4Diagram line by line, keeping a stack of the
+// boundaries that are still open.
+type c4Parser struct {
+	d    *Diagram
+	c4   *C4Diagram
+	open []int // indexes into Boundaries
+}
+
+func (d *Diagram) parseC4() {
+	p := &c4Parser{d: d, c4: &C4Diagram{}}
+	d.forEachBodyLine(p.parseLine)
+	for _, i := range p.open {
+		b := p.c4.Boundaries[i]
+		d.addDiagnostic(b.Line, "%s %q is not closed with \"}\"", b.Macro, b.Alias)
+	}
+	d.C4 = p.c4
+}
+
+// boundary returns the alias of the innermost open boundary.
+func (p *c4Parser) boundary() string {
+	if len(p.open) == 0 {
+		return ""
+	}
+	return p.c4.Boundaries[p.open[len(p.open)-1]].Alias
+}
+
+func (p *c4Parser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+	r := p.d.lineRange(lineNum)
+
+	if line == "}" {
+		if len(p.open) == 0 {
+			p.d.addDiagnostic(lineNum, "\"}\" without an open boundary")
+			return
+		}
+		b := &p.c4.Boundaries[p.open[len(p.open)-1]]
+		b.EndLine, b.Range.End = lineNum, r.End
+		p.open = p.open[:len(p.open)-1]
+		return
+	}
+	if keyword, rest := splitKeyword(line); keyword == "title" {
+		p.c4.Title = rest
+		return
+	}
+
+	m := c4CallPattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	macro := m[1]
+	args := parseC4Args(m[2])
+	pos := args.Positional
+
+	switch {
+	case c4BoundaryMacros[macro]:
+		b := C4Boundary{
+			Macro:  macro,
+			Alias:  argAt(pos, 0),
+			Label:  argAt(pos, 1),
+			Parent: p.boundary(),
+			Args:   args,
+			Line:   lineNum,
+			Range:  r,
+		}
+		if macro != "System_Boundary" && macro != "Container_Boundary" && macro != "Enterprise_Boundary" {
+			b.Type = namedOr(args, "type", argAt(pos, 2))
+			b.Description = namedOr(args, "descr", argAt(pos, 3))
+		}
+		p.c4.Boundaries = append(p.c4.Boundaries, b)
+		if m[3] != "" {
+			p.open = append(p.open, len(p.c4.Boundaries)-1)
+		}
+	case isC4Element(macro):
+		stem, external := strings.CutSuffix(macro, "_Ext")
+		kind := c4ElementKinds[stem]
+		e := C4Element{
+			Macro:    macro,
+			Kind:     kind[0],
+			Storage:  kind[1],
+			External: external,
+			Alias:    argAt(pos, 0),
+			Label:    argAt(pos, 1),
+			Boundary: p.boundary(),
+			Args:     args,
+			Line:     lineNum,
+			Range:    r,
+		}
+		if e.Kind == "container" || e.Kind == "component" {
+			e.Technology = namedOr(args, "techn", argAt(pos, 2))
+			e.Description = namedOr(args, "descr", argAt(pos, 3))
+		} else {
+			e.Description = namedOr(args, "descr", argAt(pos, 2))
+		}
+		p.c4.Elements = append(p.c4.Elements, e)
+	case isC4Relationship(macro):
+		index := ""
+		if macro == "RelIndex" && len(pos) > 0 {
+			// RelIndex(index, from, to, ...) takes the index first.
+			index, pos = pos[0], pos[1:]
+		}
+		p.c4.Relationships = append(p.c4.Relationships, C4Relationship{
+			Macro:         macro,
+			From:          argAt(pos, 0),
+			To:            argAt(pos, 1),
+			Label:         argAt(pos, 2),
+			Technology:    namedOr(args, "techn", argAt(pos, 3)),
+			Description:   namedOr(args, "descr", argAt(pos, 4)),
+			Direction:     c4RelDirections[macro],
+			Bidirectional: macro == "BiRel",
+			Index:         index,
+			Args:          args,
+			Line:          lineNum,
+			Range:         r,
+		})
+	case strings.HasPrefix(macro, "Update"):
+		targets := pos
+		if len(targets) > 2 {
+			targets = targets[:2]
+		}
+		p.c4.Styles = append(p.c4.Styles, C4StyleUpdate{
+			Macro:   macro,
+			Targets: targets,
+			Args:    args,
+			Line:    lineNum,
+			Range:   r,
+		})
+	}
+}
+
+func isC4Element(macro string) bool {
+	stem, _ := strings.CutSuffix(macro, "_Ext")
+	_, ok := c4ElementKinds[stem]
+	return ok
+}
+
+func isC4Relationship(macro string) bool {
+	_, ok := c4RelDirections[macro]
+	return ok
+}
+
+// parseC4Args splits macro arguments on commas outside double quotes.
+func parseC4Args(text string) C4Args {
+	var args C4Args
+	for _, arg := range splitQuotedList(text) {
+		if name, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "$") {
+			if args.Named == nil {
+				args.Named = make(map[string]string)
+			}
+			args.Named[strings.TrimSpace(name[1:])] = unquote(strings.TrimSpace(value))
+			continue
+		}
+		args.Positional = append(args.Positional, arg)
+	}
+	return args
+}
+
+// argAt returns the positional argument at i, or "" if there is none.
+func argAt(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// namedOr returns the named argument, falling back to a positional value.
+func namedOr(args C4Args, name, positional string) string {
+	if v, ok := args.Named[name]; ok {
+		return v
+	}
+	return positional
+}
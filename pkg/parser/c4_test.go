@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_C4Context(t *testing.T) {
+	source := "C4Context\n" +
+		"  title System Context diagram for Internet Banking System\n" +
+		"  Enterprise_Boundary(b0, \"BankBoundary0\") {\n" +
+		"    Person(customerA, \"Banking Customer A\", \"A customer of the bank\")\n" +
+		"    System_Boundary(b1, \"BankBoundary\") {\n" +
+		"      SystemDb_Ext(SystemE, \"Mainframe\", \"Stores, core data\", $tags=\"legacy\")\n" +
+		"    }\n" +
+		"  }\n" +
+		"  Container(api, \"API\", \"Go\", \"Serves requests\")\n" +
+		"  Rel(customerA, api, \"Uses\", \"HTTPS\")\n" +
+		"  BiRel(api, SystemE, \"Syncs\")\n" +
+		"  Rel_U(SystemE, api, \"Notifies\", $techn=\"AMQP\")\n" +
+		"  UpdateElementStyle(customerA, $fontColor=\"red\", $bgColor=\"grey\")\n" +
+		"  UpdateRelStyle(customerA, api, $textColor=\"blue\", $offsetY=\"-10\")"
+	d, _ := Parse(source, 1)
+
+	c4 := d.C4
+	if c4 == nil {
+		t.Fatal("expected a C4 model")
+	}
+	if c4.Title != "System Context diagram for Internet Banking System" {
+		t.Errorf("title = %q", c4.Title)
+	}
+
+	if len(c4.Boundaries) != 2 {
+		t.Fatalf("expected 2 boundaries, got %d", len(c4.Boundaries))
+	}
+	if b := c4.Boundaries[0]; b.Alias != "b0" || b.Label != "BankBoundary0" || b.Parent != "" || b.Line != 3 || b.EndLine != 8 {
+		t.Errorf("boundary 0 = %+v", b)
+	}
+	if b := c4.Boundaries[1]; b.Alias != "b1" || b.Parent != "b0" || b.EndLine != 7 {
+		t.Errorf("boundary 1 = %+v", b)
+	}
+
+	if len(c4.Elements) != 3 {
+		t.Fatalf("expected 3 elements, got %d", len(c4.Elements))
+	}
+	if e := c4.Elements[0]; e.Kind != "person" || e.Alias != "customerA" || e.Description != "A customer of the bank" || e.Boundary != "b0" {
+		t.Errorf("element 0 = %+v", e)
+	}
+	e := c4.Elements[1]
+	if e.Kind != "system" || e.Storage != "db" || !e.External || e.Boundary != "b1" {
+		t.Errorf("element 1 = %+v", e)
+	}
+	if e.Description != "Stores, core data" || e.Args.Named["tags"] != "legacy" {
+		t.Errorf("element 1 args = %q %+v", e.Description, e.Args)
+	}
+	if e := c4.Elements[2]; e.Kind != "container" || e.Technology != "Go" || e.Description != "Serves requests" || e.Boundary != "" {
+		t.Errorf("element 2 = %+v", e)
+	}
+
+	if len(c4.Relationships) != 3 {
+		t.Fatalf("expected 3 relationships, got %d", len(c4.Relationships))
+	}
+	if r := c4.Relationships[0]; r.From != "customerA" || r.To != "api" || r.Label != "Uses" || r.Technology != "HTTPS" {
+		t.Errorf("relationship 0 = %+v", r)
+	}
+	if r := c4.Relationships[1]; !r.Bidirectional {
+		t.Errorf("relationship 1 = %+v", r)
+	}
+	if r := c4.Relationships[2]; r.Direction != "up" || r.Technology != "AMQP" {
+		t.Errorf("relationship 2 = %+v", r)
+	}
+
+	if len(c4.Styles) != 2 {
+		t.Fatalf("expected 2 style updates, got %d", len(c4.Styles))
+	}
+	if s := c4.Styles[0]; len(s.Targets) != 1 || s.Args.Named["bgColor"] != "grey" {
+		t.Errorf("style 0 = %+v", s)
+	}
+	if s := c4.Styles[1]; len(s.Targets) != 2 || s.Targets[1] != "api" || s.Args.Named["offsetY"] != "-10" {
+		t.Errorf("style 1 = %+v", s)
+	}
+}
+
+func TestParse_C4DynamicAndDeployment(t *testing.T) {
+	d, _ := Parse("C4Dynamic\n  RelIndex(1, a, b, \"Submits\")", 1)
+	if r := d.C4.Relationships[0]; r.Index != "1" || r.From != "a" || r.To != "b" || r.Label != "Submits" {
+		t.Errorf("RelIndex = %+v", r)
+	}
+
+	d, diags := Parse("C4Deployment\n  Deployment_Node(dc, \"Data centre\", \"Ubuntu\") {\n    Node(web, \"Web\") {\n", 1)
+	if len(d.C4.Boundaries) != 2 {
+		t.Fatalf("expected 2 deployment nodes, got %d", len(d.C4.Boundaries))
+	}
+	if b := d.C4.Boundaries[0]; b.Type != "Ubuntu" || b.EndLine != 0 {
+		t.Errorf("node 0 = %+v", b)
+	}
+	if b := d.C4.Boundaries[1]; b.Parent != "dc" {
+		t.Errorf("node 1 = %+v", b)
+	}
+	if len(diags) != 2 || diags[0].Message != `Deployment_Node "dc" is not closed with "}"` || diags[1].Line != 3 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+
+	_, diags = Parse("C4Context\n  Person(u, \"User\")\n  }", 1)
+	want := Diagnostic{Message: `"}" without an open boundary`, Line: 3, Column: 3, EndLine: 3, EndColumn: 4}
+	if len(diags) != 1 || diags[0] != want {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
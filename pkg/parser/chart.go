@@ -0,0 +1,288 @@
+package parser
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PieChart is the model of a pie chart.
+type PieChart struct {
+	Title    string
+	ShowData bool
+	Slices   []PieSlice
+}
+
+// PieSlice is a labelled value of a pie chart.
+type PieSlice struct {
+	Label string
+	Value float64 // NaN if the value is not a number
+	Line  int
+	Range Range
+}
+
+// QuadrantChart is the model of a quadrantChart.
+type QuadrantChart struct {
+	Title     string
+	XAxis     QuadrantAxis
+	YAxis     QuadrantAxis
+	Quadrants [4]string // Labels of quadrant-1 to quadrant-4
+	Points    []QuadrantPoint
+}
+
+// QuadrantAxis is an axis of a quadrant chart, labelled at both ends.
+type QuadrantAxis struct {
+	Low   string
+	High  string
+	Line  int
+	Range Range
+}
+
+// QuadrantPoint is a labelled point of a quadrant chart.
+type QuadrantPoint struct {
+	Label  string
+	X      float64           // NaN if the coordinate is not a number
+	Y      float64           // NaN if the coordinate is not a number
+	Class  string            // Class from Label:::class, if any
+	Styles map[string]string // e.g., radius, color, stroke-width
+	Line   int
+	Range  Range
+}
+
+// XYChart is the model of an xychart-beta chart.
+type XYChart struct {
+	Title      string
+	Horizontal bool
+	XAxis      XYAxis
+	YAxis      XYAxis
+	Series     []XYSeries
+}
+
+// XYAxis is an axis of an xychart, with either categories or a numeric
+// range.
+type XYAxis struct {
+	Title      string
+	Categories []string
+	Min        float64 // NaN if the bound is not a number
+	Max        float64 // NaN if the bound is not a number
+	HasRange   bool    // Min and Max were given as "min --> max"
+	Line       int     // 0 if the axis is not declared
+	Range      Range
+}
+
+// XYSeries is a bar or line series of an xychart.
+type XYSeries struct {
+	Kind   string // "bar" or "line"
+	Title  string
+	Values []float64 // NaN for values that are not numbers
+	Line   int
+	Range  Range
+}
+
+var (
+	// Match pie slices: "Label" : 42.5
+	pieSlicePattern = regexp.MustCompile(`^"([^"]*)"\s*:\s*(.*)$`)
+
+	// Match quadrant points: Label:::class: [0.3, 0.6] radius: 10
+	quadrantPointPattern = regexp.MustCompile(`^(.+?)(?::::(\w+))?\s*:\s*\[([^\]]*)\]\s*(.*)$`)
+
+	// Match xychart axes: "title" [a, b] or "title" 0 --> 100
+	xyAxisPattern = regexp.MustCompile(`^(?:("[^"]*"|[^"\[\s]+)\s*)?(?:\[(.*)\]|(\S+)\s*-->\s*(\S+))?$`)
+
+	// Match xychart series: "title" [1, 2, 3]
+	xySeriesPattern = regexp.MustCompile(`^(?:("[^"]*"|\S+)\s*)?\[(.*)\]$`)
+)
+
+func (d *Diagram) parsePie() {
+	pie := &PieChart{}
+
+	// showData and the title may follow the keyword on the first line.
+	_, header := splitKeyword(strings.TrimSpace(d.Lines[d.header]))
+	if rest, ok := strings.CutPrefix(header, "showData"); ok {
+		pie.ShowData = true
+		header = strings.TrimSpace(rest)
+	}
+	if title, ok := strings.CutPrefix(header, "title"); ok {
+		pie.Title = strings.TrimSpace(title)
+	}
+
+	d.forEachBodyLine(func(i int, line string) {
+		keyword, rest := splitKeyword(line)
+		switch {
+		case keyword == "title":
+			pie.Title = rest
+		case keyword == "showData":
+			pie.ShowData = true
+		default:
+			if m := pieSlicePattern.FindStringSubmatch(line); m != nil {
+				pie.Slices = append(pie.Slices, PieSlice{
+					Label: m[1],
+					Value: parseNumber(m[2]),
+					Line:  d.StartLine + i,
+					Range: d.lineRange(d.StartLine + i),
+				})
+			}
+		}
+	})
+	d.Pie = pie
+}
+
+func (d *Diagram) parseQuadrant() {
+	q := &QuadrantChart{}
+	d.forEachBodyLine(func(i int, line string) {
+		lineNum := d.StartLine + i
+		keyword, rest := splitKeyword(line)
+		switch keyword {
+		case "title":
+			q.Title = rest
+		case "x-axis", "y-axis":
+			axis := parseQuadrantAxis(rest, lineNum)
+			axis.Range = d.lineRange(lineNum)
+			if keyword == "x-axis" {
+				q.XAxis = axis
+			} else {
+				q.YAxis = axis
+			}
+		case "quadrant-1", "quadrant-2", "quadrant-3", "quadrant-4":
+			q.Quadrants[keyword[len(keyword)-1]-'1'] = rest
+		case "classDef":
+			// Styling is not part of the chart model.
+		default:
+			if point, ok := parseQuadrantPoint(line, lineNum); ok {
+				point.Range = d.lineRange(lineNum)
+				q.Points = append(q.Points, point)
+			}
+		}
+	})
+	d.Quadrant = q
+}
+
+// parseQuadrantAxis parses "Low label --> High label".
+func parseQuadrantAxis(text string, lineNum int) QuadrantAxis {
+	low, high, _ := strings.Cut(text, "-->")
+	return QuadrantAxis{
+		Low:  unquote(strings.TrimSpace(low)),
+		High: unquote(strings.TrimSpace(high)),
+		Line: lineNum,
+	}
+}
+
+// parseQuadrantPoint parses "Label:::class: [x, y] key: value, ...".
+func parseQuadrantPoint(line string, lineNum int) (QuadrantPoint, bool) {
+	m := quadrantPointPattern.FindStringSubmatch(line)
+	if m == nil {
+		return QuadrantPoint{}, false
+	}
+	point := QuadrantPoint{
+		Label: unquote(strings.TrimSpace(m[1])),
+		Class: m[2],
+		X:     math.NaN(),
+		Y:     math.NaN(),
+		Line:  lineNum,
+	}
+	if coords := splitList(m[3]); len(coords) == 2 {
+		point.X = parseNumber(coords[0])
+		point.Y = parseNumber(coords[1])
+	}
+	for _, style := range splitList(m[4]) {
+		key, value, _ := strings.Cut(style, ":")
+		if point.Styles == nil {
+			point.Styles = make(map[string]string)
+		}
+		point.Styles[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return point, true
+}
+
+func (d *Diagram) parseXYChart() {
+	xy := &XYChart{}
+	_, header := splitKeyword(strings.TrimSpace(d.Lines[d.header]))
+	xy.Horizontal = header == "horizontal"
+
+	d.forEachBodyLine(func(i int, line string) {
+		lineNum := d.StartLine + i
+		keyword, rest := splitKeyword(line)
+		switch keyword {
+		case "title":
+			xy.Title = unquote(rest)
+		case "x-axis", "y-axis":
+			axis := parseXYAxis(rest, lineNum)
+			axis.Range = d.lineRange(lineNum)
+			if keyword == "x-axis" {
+				xy.XAxis = axis
+			} else {
+				xy.YAxis = axis
+			}
+		case "bar", "line":
+			m := xySeriesPattern.FindStringSubmatch(rest)
+			if m == nil {
+				return
+			}
+			series := XYSeries{Kind: keyword, Title: unquote(m[1]), Line: lineNum, Range: d.lineRange(lineNum)}
+			for _, v := range splitQuotedList(m[2]) {
+				series.Values = append(series.Values, parseNumber(v))
+			}
+			xy.Series = append(xy.Series, series)
+		}
+	})
+	d.XYChart = xy
+}
+
+// parseXYAxis parses an axis with an optional title followed by either
+// "[categories]" or "min --> max".
+func parseXYAxis(text string, lineNum int) XYAxis {
+	axis := XYAxis{Line: lineNum}
+	m := xyAxisPattern.FindStringSubmatch(text)
+	if m == nil {
+		axis.Title = unquote(text)
+		return axis
+	}
+	axis.Title = unquote(m[1])
+	switch {
+	case m[2] != "" || strings.Contains(text, "["):
+		axis.Categories = splitQuotedList(m[2])
+	case m[3] != "":
+		axis.HasRange = true
+		axis.Min = parseNumber(m[3])
+		axis.Max = parseNumber(m[4])
+	}
+	return axis
+}
+
+// splitQuotedList splits a comma-separated list whose items may be
+// double-quoted strings containing commas.
+func splitQuotedList(s string) []string {
+	var items []string
+	var b strings.Builder
+	inQuote := false
+	flush := func() {
+		if item := strings.TrimSpace(b.String()); item != "" {
+			items = append(items, unquote(item))
+		}
+		b.Reset()
+	}
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case r == ',' && !inQuote:
+			flush()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	flush()
+	return items
+}
+
+// parseNumber reads a decimal number, returning NaN if s is not one so
+// that rules can tell invalid data apart from zero.
+func parseNumber(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse_Pie(t *testing.T) {
+	source := "pie showData title Pets\n" +
+		"  \"Dogs\" : 386\n" +
+		"  \"Cats\" : 85.5\n" +
+		"  \"Rats\" : lots"
+	d, _ := Parse(source, 1)
+
+	if d.Pie == nil {
+		t.Fatal("expected a pie model")
+	}
+	if !d.Pie.ShowData || d.Pie.Title != "Pets" {
+		t.Errorf("pie = %+v", d.Pie)
+	}
+	if len(d.Pie.Slices) != 3 {
+		t.Fatalf("expected 3 slices, got %d", len(d.Pie.Slices))
+	}
+	if s := d.Pie.Slices[1]; s.Label != "Cats" || s.Value != 85.5 || s.Line != 3 {
+		t.Errorf("slice 1 = %+v", s)
+	}
+	if v := d.Pie.Slices[2].Value; !math.IsNaN(v) {
+		t.Errorf("expected NaN for a non-numeric value, got %v", v)
+	}
+}
+
+func TestParse_PieTitleLine(t *testing.T) {
+	d, _ := Parse("pie\n  title Key elements\n  \"A\" : 1", 1)
+	if d.Pie.Title != "Key elements" || d.Pie.ShowData {
+		t.Errorf("pie = %+v", d.Pie)
+	}
+}
+
+func TestParse_Quadrant(t *testing.T) {
+	source := "quadrantChart\n" +
+		"  title Reach and engagement\n" +
+		"  x-axis Low Reach --> High Reach\n" +
+		"  y-axis Low Engagement --> High Engagement\n" +
+		"  quadrant-1 We should expand\n" +
+		"  quadrant-3 Re-evaluate\n" +
+		"  Campaign A: [0.3, 0.6]\n" +
+		"  Campaign B:::hot: [0.45, 0.23] radius: 10, color: #ff3300"
+	d, _ := Parse(source, 1)
+
+	q := d.Quadrant
+	if q == nil {
+		t.Fatal("expected a quadrant model")
+	}
+	if q.XAxis.Low != "Low Reach" || q.XAxis.High != "High Reach" || q.YAxis.Line != 4 {
+		t.Errorf("axes = %+v / %+v", q.XAxis, q.YAxis)
+	}
+	if q.Quadrants[0] != "We should expand" || q.Quadrants[2] != "Re-evaluate" || q.Quadrants[1] != "" {
+		t.Errorf("quadrants = %q", q.Quadrants)
+	}
+	if len(q.Points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(q.Points))
+	}
+	if p := q.Points[0]; p.Label != "Campaign A" || p.X != 0.3 || p.Y != 0.6 {
+		t.Errorf("point 0 = %+v", p)
+	}
+	if p := q.Points[1]; p.Class != "hot" || p.Styles["radius"] != "10" || p.Styles["color"] != "#ff3300" {
+		t.Errorf("point 1 = %+v", p)
+	}
+}
+
+func TestParse_XYChart(t *testing.T) {
+	source := "xychart-beta horizontal\n" +
+		"  title \"Sales Revenue\"\n" +
+		"  x-axis [jan, feb, \"mar, apr\"]\n" +
+		"  y-axis \"Revenue (in $)\" 4000 --> 11000\n" +
+		"  bar [5000, 6000, 7500]\n" +
+		"  line \"Trend\" [5000, x, 7500]"
+	d, _ := Parse(source, 1)
+
+	xy := d.XYChart
+	if xy == nil {
+		t.Fatal("expected an xychart model")
+	}
+	if !xy.Horizontal || xy.Title != "Sales Revenue" {
+		t.Errorf("chart = %+v", xy)
+	}
+	if cats := xy.XAxis.Categories; len(cats) != 3 || cats[2] != "mar, apr" {
+		t.Errorf("categories = %q", cats)
+	}
+	if y := xy.YAxis; y.Title != "Revenue (in $)" || !y.HasRange || y.Min != 4000 || y.Max != 11000 {
+		t.Errorf("y axis = %+v", y)
+	}
+	if len(xy.Series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(xy.Series))
+	}
+	if s := xy.Series[0]; s.Kind != "bar" || len(s.Values) != 3 || s.Values[2] != 7500 {
+		t.Errorf("series 0 = %+v", s)
+	}
+	if s := xy.Series[1]; s.Kind != "line" || s.Title != "Trend" || !math.IsNaN(s.Values[1]) {
+		t.Errorf("series 1 = %+v", s)
+	}
+}
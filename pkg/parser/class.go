@@ -0,0 +1,334 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ClassDiagram is the model of a classDiagram.
+type ClassDiagram struct {
+	Direction     string
+	Classes       []Class // Each class once, in order of first appearance
+	Relationships []ClassRelationship
+	Namespaces    []Namespace
+}
+
+// Class is a class of a class diagram. A class declared several times has
+// the members of all its declarations.
+type Class struct {
+	ID          string
+	Label       string   // Display label from Class["label"], if any
+	Generic     string   // Type parameter from Class~T~, if any
+	Annotations []string // e.g., "interface" for <<interface>>
+	Members     []ClassMember
+	Namespace   string // Enclosing namespace, if any
+	Implicit    bool   // Never declared, only used in relationships
+	Line        int
+	Range       Range // Span of the declaration and its body, or of the first mention
+}
+
+// ClassMember is an attribute or method of a class.
+type ClassMember struct {
+	Visibility string // "+", "-", "#", "~" or empty
+	Name       string
+	Type       string // Attribute type or method return type
+	Parameters string // Method parameters as written
+	Method     bool
+	Static     bool // Marked with $
+	Abstract   bool // Marked with *
+	Line       int
+	Range      Range
+}
+
+// ClassRelationship is a relationship between two classes, such as
+// "Customer "1" --> "*" Ticket : buys".
+type ClassRelationship struct {
+	From            string
+	To              string
+	FromCardinality string
+	ToCardinality   string
+	FromHead        string // "<|", "*", "o", "<", "()" or empty
+	ToHead          string // "|>", "*", "o", ">", "()" or empty
+	Link            string // "--" (solid) or ".." (dashed)
+	Kind            string // e.g., "inheritance", "composition", "dependency"
+	Label           string
+	Line            int
+	Range           Range
+}
+
+// Namespace groups classes of a class diagram.
+type Namespace struct {
+	Name    string
+	Classes []string
+	Line    int
+	EndLine int   // Line of the closing brace, 0 if unclosed
+	Range   Range // Up to the closing brace or, if unclosed, the opening line
+}
+
+var (
+	// Match relationships: A "1" <|-- "*" B : label
+	classRelationPattern = regexp.MustCompile(
+		`^(\w[\w~]*|` + "`[^`]+`" + `)\s*` +
+			`(?:"([^"]*)"\s*)?` +
+			`(<\||\*|o|<|\(\))?(--|\.\.)(\|>|\*|o|>|\(\))?` +
+			`\s*(?:"([^"]*)"\s*)?` +
+			`(\w[\w~]*|` + "`[^`]+`" + `)` +
+			`\s*(?::\s*(.*))?$`,
+	)
+
+	// Match class declarations: class Name~T~["label"]:::css {
+	classDeclPattern = regexp.MustCompile(
+		`^class\s+(\w+|` + "`[^`]+`" + `)(?:~([^~]+)~)?` +
+			`(?:\["([^"]*)"\])?(?::::\w+)?\s*(\{)?\s*(\})?$`,
+	)
+
+	// Match member lines outside a class body: Name : member
+	classMemberPattern = regexp.MustCompile(`^(\w+|` + "`[^`]+`" + `)\s*:\s*(.+)$`)
+
+	// Match annotation lines: <<interface>> Name
+	classAnnotationPattern = regexp.MustCompile(`^<<([^>]+)>>\s*(\S*)$`)
+)
+
+// classParser builds a ClassDiagram line by line.
+type classParser struct {
+	d         *Diagram
+	cd        *ClassDiagram
+	index     map[string]int // class ID -> index in Classes
+	body      string         // class whose { } body is open, if any
+	namespace int            // index of the open namespace, -1 if none
+}
+
+func (d *Diagram) parseClass() {
+	p := &classParser{
+		d:         d,
+		cd:        &ClassDiagram{},
+		index:     make(map[string]int),
+		namespace: -1,
+	}
+	d.forEachBodyLine(p.parseLine)
+	if p.body != "" {
+		c := p.cd.Classes[p.index[p.body]]
+		d.addDiagnostic(c.Line, "class %q is not closed with \"}\"", c.ID)
+	}
+	if p.namespace >= 0 {
+		ns := p.cd.Namespaces[p.namespace]
+		d.addDiagnostic(ns.Line, "namespace %q is not closed with \"}\"", ns.Name)
+	}
+	d.Class = p.cd
+}
+
+// class returns the class with the given ID, creating it if needed.
+func (p *classParser) class(id string, line int, implicit bool) *Class {
+	if i, ok := p.index[id]; ok {
+		c := &p.cd.Classes[i]
+		if !implicit && c.Implicit {
+			c.Implicit = false
+			c.Line, c.Range = line, p.d.lineRange(line)
+		}
+		return c
+	}
+	c := Class{ID: id, Implicit: implicit, Line: line, Range: p.d.lineRange(line)}
+	if p.namespace >= 0 {
+		ns := &p.cd.Namespaces[p.namespace]
+		c.Namespace = ns.Name
+		ns.Classes = append(ns.Classes, id)
+	}
+	p.index[id] = len(p.cd.Classes)
+	p.cd.Classes = append(p.cd.Classes, c)
+	return &p.cd.Classes[len(p.cd.Classes)-1]
+}
+
+func (p *classParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+
+	if p.body != "" {
+		c := p.class(p.body, lineNum, false)
+		if line == "}" {
+			c.Range.End = p.d.lineRange(lineNum).End
+			p.body = ""
+			return
+		}
+		if m := classAnnotationPattern.FindStringSubmatch(line); m != nil && m[2] == "" {
+			c.Annotations = append(c.Annotations, m[1])
+			return
+		}
+		c.Members = append(c.Members, p.member(line, lineNum))
+		return
+	}
+
+	keyword, rest := splitKeyword(line)
+	switch {
+	case keyword == "direction":
+		p.cd.Direction = rest
+	case keyword == "namespace":
+		name := strings.TrimSpace(strings.TrimSuffix(rest, "{"))
+		p.cd.Namespaces = append(p.cd.Namespaces, Namespace{Name: name, Line: lineNum, Range: p.d.lineRange(lineNum)})
+		p.namespace = len(p.cd.Namespaces) - 1
+	case line == "}":
+		if p.namespace < 0 {
+			p.d.addDiagnostic(lineNum, "\"}\" without an open class or namespace")
+			return
+		}
+		ns := &p.cd.Namespaces[p.namespace]
+		ns.EndLine, ns.Range.End = lineNum, p.d.lineRange(lineNum).End
+		p.namespace = -1
+	case keyword == "class":
+		p.parseClassDecl(line, lineNum)
+	default:
+		p.parseStatement(line, lineNum)
+	}
+}
+
+func (p *classParser) parseClassDecl(line string, lineNum int) {
+	m := classDeclPattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	id, _ := className(m[1])
+	c := p.class(id, lineNum, false)
+	if m[2] != "" {
+		c.Generic = m[2]
+	}
+	if m[3] != "" {
+		c.Label = m[3]
+	}
+	if m[4] != "" && m[5] == "" {
+		p.body = c.ID
+	}
+}
+
+// parseStatement handles relationships, annotations and "Class : member"
+// lines.
+func (p *classParser) parseStatement(line string, lineNum int) {
+	if m := classRelationPattern.FindStringSubmatch(line); m != nil {
+		rel := ClassRelationship{
+			From:            p.reference(m[1], lineNum),
+			FromCardinality: m[2],
+			FromHead:        m[3],
+			Link:            m[4],
+			ToHead:          m[5],
+			ToCardinality:   m[6],
+			To:              p.reference(m[7], lineNum),
+			Label:           strings.TrimSpace(m[8]),
+			Line:            lineNum,
+			Range:           p.d.lineRange(lineNum),
+		}
+		rel.Kind = relationshipKind(rel)
+		p.cd.Relationships = append(p.cd.Relationships, rel)
+		return
+	}
+	if m := classAnnotationPattern.FindStringSubmatch(line); m != nil && m[2] != "" {
+		id, _ := className(m[2])
+		c := p.class(id, lineNum, false)
+		c.Annotations = append(c.Annotations, m[1])
+		return
+	}
+	if m := classMemberPattern.FindStringSubmatch(line); m != nil {
+		id, _ := className(m[1])
+		c := p.class(id, lineNum, false)
+		c.Members = append(c.Members, p.member(strings.TrimSpace(m[2]), lineNum))
+	}
+}
+
+// member parses a member written on line lineNum.
+func (p *classParser) member(text string, lineNum int) ClassMember {
+	m := parseClassMember(text, lineNum)
+	m.Range = p.d.lineRange(lineNum)
+	return m
+}
+
+// parseClassMember parses an attribute such as "+List~int~ items" or a
+// method such as "#area(w, h) double$".
+func parseClassMember(text string, lineNum int) ClassMember {
+	m := ClassMember{Line: lineNum}
+	if text != "" && strings.ContainsRune("+-#~", rune(text[0])) {
+		m.Visibility = text[:1]
+		text = strings.TrimSpace(text[1:])
+	}
+
+	if open := strings.Index(text, "("); open >= 0 {
+		if close := strings.LastIndex(text, ")"); close > open {
+			m.Method = true
+			m.Name = strings.TrimSpace(text[:open])
+			m.Parameters = strings.TrimSpace(text[open+1 : close])
+			m.Type, m.Static, m.Abstract = cutClassifier(strings.TrimSpace(text[close+1:]))
+			return m
+		}
+	}
+
+	text, m.Static, m.Abstract = cutClassifier(text)
+	if name, typ, ok := strings.Cut(text, ":"); ok {
+		m.Name = strings.TrimSpace(name)
+		m.Type = strings.TrimSpace(typ)
+		return m
+	}
+	if i := strings.LastIndexAny(text, " \t"); i >= 0 {
+		m.Type = strings.TrimSpace(text[:i])
+		m.Name = strings.TrimSpace(text[i+1:])
+		return m
+	}
+	m.Name = text
+	return m
+}
+
+// cutClassifier removes a trailing $ (static) or * (abstract) marker.
+func cutClassifier(text string) (rest string, static, abstract bool) {
+	switch {
+	case strings.HasSuffix(text, "$"):
+		return strings.TrimSpace(strings.TrimSuffix(text, "$")), true, false
+	case strings.HasSuffix(text, "*"):
+		return strings.TrimSpace(strings.TrimSuffix(text, "*")), false, true
+	}
+	return text, false, false
+}
+
+// relationshipKind names the relationship expressed by its arrow heads
+// and line style.
+func relationshipKind(r ClassRelationship) string {
+	head := r.FromHead + r.ToHead
+	dashed := r.Link == ".."
+	switch {
+	case strings.Contains(head, "|"):
+		if dashed {
+			return "realization"
+		}
+		return "inheritance"
+	case strings.Contains(head, "*"):
+		return "composition"
+	case strings.Contains(head, "o"):
+		return "aggregation"
+	case strings.Contains(head, "()"):
+		return "lollipop"
+	case strings.ContainsAny(head, "<>"):
+		if dashed {
+			return "dependency"
+		}
+		return "association"
+	case dashed:
+		return "link-dashed"
+	}
+	return "link"
+}
+
+// reference returns the ID of a class named by a relationship, creating
+// the class if needed and recording its type parameter.
+func (p *classParser) reference(name string, line int) string {
+	id, generic := className(name)
+	c := p.class(id, line, true)
+	if generic != "" && c.Generic == "" {
+		c.Generic = generic
+	}
+	return id
+}
+
+// className strips the backticks around an escaped class name and splits
+// off its type parameter, as in "Foo~T~".
+func className(s string) (id, generic string) {
+	if strings.HasPrefix(s, "`") {
+		return strings.Trim(s, "`"), ""
+	}
+	if i := strings.IndexByte(s, '~'); i > 0 && strings.HasSuffix(s, "~") && len(s) > i+1 {
+		return s[:i], s[i+1 : len(s)-1]
+	}
+	return s, ""
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_ClassDeclarations(t *testing.T) {
+	source := "classDiagram\n" +
+		"  class Animal~T~ {\n" +
+		"    <<interface>>\n" +
+		"    +String name\n" +
+		"    -List~int~ ids\n" +
+		"    +eat(food, amount) bool\n" +
+		"    #count() int$\n" +
+		"    +move()*\n" +
+		"  }\n" +
+		"  class Dog[\"Good dog\"]\n" +
+		"  <<abstract>> Dog\n" +
+		"  Dog : +bark() void"
+	d, _ := Parse(source, 1)
+
+	if d.Class == nil || len(d.Class.Classes) != 2 {
+		t.Fatalf("expected 2 classes, got %+v", d.Class)
+	}
+	animal := d.Class.Classes[0]
+	if animal.ID != "Animal" || animal.Generic != "T" || animal.Line != 2 {
+		t.Errorf("animal = %+v", animal)
+	}
+	if len(animal.Annotations) != 1 || animal.Annotations[0] != "interface" {
+		t.Errorf("annotations = %v", animal.Annotations)
+	}
+	if len(animal.Members) != 5 {
+		t.Fatalf("expected 5 members, got %d: %+v", len(animal.Members), animal.Members)
+	}
+
+	want := []ClassMember{
+		{Visibility: "+", Name: "name", Type: "String", Line: 4},
+		{Visibility: "-", Name: "ids", Type: "List~int~", Line: 5},
+		{Visibility: "+", Name: "eat", Type: "bool", Parameters: "food, amount", Method: true, Line: 6},
+		{Visibility: "#", Name: "count", Type: "int", Method: true, Static: true, Line: 7},
+		{Visibility: "+", Name: "move", Method: true, Abstract: true, Line: 8},
+	}
+	for i, w := range want {
+		got := animal.Members[i]
+		got.Range = Range{}
+		if got != w {
+			t.Errorf("member %d = %+v, want %+v", i, animal.Members[i], w)
+		}
+	}
+
+	if r := animal.Range; r.Start.Line != 2 || r.End.Line != 9 || r.End.Column != 4 {
+		t.Errorf("animal range = %+v, want 2:3-9:4", r)
+	}
+
+	dog := d.Class.Classes[1]
+	if dog.Label != "Good dog" || len(dog.Annotations) != 1 || dog.Annotations[0] != "abstract" {
+		t.Errorf("dog = %+v", dog)
+	}
+	if len(dog.Members) != 1 || dog.Members[0].Name != "bark" || !dog.Members[0].Method {
+		t.Errorf("dog members = %+v", dog.Members)
+	}
+}
+
+func TestParse_ClassRelationships(t *testing.T) {
+	source := "classDiagram\n" +
+		"  Animal <|-- Duck\n" +
+		"  Customer \"1\" --> \"*\" Ticket : buys\n" +
+		"  Car *-- Wheel\n" +
+		"  Pond o-- Duck\n" +
+		"  Service ..|> Api\n" +
+		"  Client ..> Service : uses\n" +
+		"  A -- B"
+	d, _ := Parse(source, 1)
+
+	want := []struct {
+		from, to, kind, label, fromCard, toCard string
+	}{
+		{"Animal", "Duck", "inheritance", "", "", ""},
+		{"Customer", "Ticket", "association", "buys", "1", "*"},
+		{"Car", "Wheel", "composition", "", "", ""},
+		{"Pond", "Duck", "aggregation", "", "", ""},
+		{"Service", "Api", "realization", "", "", ""},
+		{"Client", "Service", "dependency", "uses", "", ""},
+		{"A", "B", "link", "", "", ""},
+	}
+	rels := d.Class.Relationships
+	if len(rels) != len(want) {
+		t.Fatalf("expected %d relationships, got %d", len(want), len(rels))
+	}
+	for i, w := range want {
+		r := rels[i]
+		if r.From != w.from || r.To != w.to || r.Kind != w.kind || r.Label != w.label ||
+			r.FromCardinality != w.fromCard || r.ToCardinality != w.toCard {
+			t.Errorf("relationship %d = %+v, want %+v", i, r, w)
+		}
+	}
+
+	for _, c := range d.Class.Classes {
+		if !c.Implicit {
+			t.Errorf("class %s should be implicit", c.ID)
+		}
+	}
+}
+
+func TestParse_ClassNamespaces(t *testing.T) {
+	source := "classDiagram\n" +
+		"  namespace Shapes {\n" +
+		"    class Triangle\n" +
+		"    class Square\n" +
+		"  }\n" +
+		"  class Circle"
+	d, _ := Parse(source, 1)
+
+	if len(d.Class.Namespaces) != 1 {
+		t.Fatalf("expected 1 namespace, got %d", len(d.Class.Namespaces))
+	}
+	ns := d.Class.Namespaces[0]
+	if ns.Name != "Shapes" || ns.Line != 2 || ns.EndLine != 5 || len(ns.Classes) != 2 {
+		t.Errorf("namespace = %+v", ns)
+	}
+	if d.Class.Classes[2].Namespace != "" {
+		t.Errorf("Circle should be outside the namespace")
+	}
+}
+
+func TestParse_ClassUnclosed(t *testing.T) {
+	source := "classDiagram\n" +
+		"  }\n" +
+		"  namespace Shapes {\n" +
+		"    class Square {\n" +
+		"      +int side"
+	d, diags := Parse(source, 1)
+
+	want := []Diagnostic{
+		{Message: `"}" without an open class or namespace`, Line: 2, Column: 3, EndLine: 2, EndColumn: 4},
+		{Message: `class "Square" is not closed with "}"`, Line: 4, Column: 5, EndLine: 4, EndColumn: 19},
+		{Message: `namespace "Shapes" is not closed with "}"`, Line: 3, Column: 3, EndLine: 3, EndColumn: 21},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("diagnostics = %+v, want %+v", diags, want)
+	}
+	for i := range want {
+		if diags[i] != want[i] {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, diags[i], want[i])
+		}
+	}
+	if sq := d.Class.Classes[0]; len(sq.Members) != 1 || sq.Namespace != "Shapes" {
+		t.Errorf("Square = %+v", sq)
+	}
+}
+
+func TestParse_ClassDuplicateMembersKept(t *testing.T) {
+	source := "classDiagram\n" +
+		"  class A {\n" +
+		"    +int x\n" +
+		"  }\n" +
+		"  A : +int x"
+	d, _ := Parse(source, 1)
+
+	if len(d.Class.Classes) != 1 || len(d.Class.Classes[0].Members) != 2 {
+		t.Fatalf("expected one class with both members, got %+v", d.Class.Classes)
+	}
+}
+
+func TestParse_ClassGenericRelationship(t *testing.T) {
+	source := "classDiagram\n" +
+		"  class Foo~T~\n" +
+		"  Foo~T~ <|-- Bar\n" +
+		"  Bar --> List~int~"
+	d, _ := Parse(source, 1)
+
+	classes := d.Class.Classes
+	if len(classes) != 3 {
+		t.Fatalf("expected 3 classes, got %+v", classes)
+	}
+	if foo := classes[0]; foo.ID != "Foo" || foo.Generic != "T" || foo.Implicit {
+		t.Errorf("foo = %+v", foo)
+	}
+	if list := classes[2]; list.ID != "List" || list.Generic != "int" || !list.Implicit {
+		t.Errorf("list = %+v", list)
+	}
+	rels := d.Class.Relationships
+	if len(rels) != 2 || rels[0].From != "Foo" || rels[0].To != "Bar" || rels[1].To != "List" {
+		t.Errorf("relationships = %+v", rels)
+	}
+}
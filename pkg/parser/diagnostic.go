@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Diagnostic is a problem found while parsing a diagram, such as a
+// structure Mermaid would reject. Columns are 1-based and count
+// characters; they are 0 when only the line is known. The span ends
+// before EndColumn on EndLine. In a notebook, lines count from the start
+// of Cell, the 1-based index of the cell.
+type Diagnostic struct {
+	Message   string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	Cell      int
+}
+
+// addDiagnostic records a problem spanning the text of the given file
+// line.
+func (d *Diagram) addDiagnostic(line int, format string, args ...any) {
+	d.addRangeDiagnostic(d.lineRange(line), format, args...)
+}
+
+// addRangeDiagnostic records a problem spanning r.
+func (d *Diagram) addRangeDiagnostic(r Range, format string, args ...any) {
+	d.Diagnostics = append(d.Diagnostics, Diagnostic{
+		Message:   fmt.Sprintf(format, args...),
+		Line:      r.Start.Line,
+		Column:    r.Start.Column,
+		EndLine:   r.End.Line,
+		EndColumn: r.End.Column,
+	})
+}
+
+// addSpanDiagnostic records a problem spanning the byte offsets
+// [start, end) of the diagram source.
+func (d *Diagram) addSpanDiagnostic(start, end int, format string, args ...any) {
+	d.addRangeDiagnostic(d.span(start, end), format, args...)
+}
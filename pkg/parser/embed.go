@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HTMLExtractor finds diagrams in `<pre class="mermaid">` and
+// `<div class="mermaid">` elements, the markup Mermaid renders in a web
+// page. HTML entities such as "&gt;" are decoded.
+type HTMLExtractor struct {
+	Markdown bool // The HTML is embedded in Markdown: skip elements inside code blocks
+}
+
+func (x *HTMLExtractor) Name() string { return "html" }
+
+func (x *HTMLExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var blocks []MermaidBlock
+	var diags []Diagnostic
+	for _, elem := range htmlElements {
+		b, d, err := elem.extract(bytes.NewReader(data), x.Markdown)
+		if err != nil {
+			return nil, nil, err
+		}
+		blocks, diags = append(blocks, b...), append(diags, d...)
+	}
+	sortExtracted(blocks, diags)
+	return blocks, diags, nil
+}
+
+// htmlElements are the elements HTMLExtractor looks in.
+var htmlElements = []*delimited{htmlElement("pre"), htmlElement("div")}
+
+func htmlElement(tag string) *delimited {
+	return &delimited{
+		open:   regexp.MustCompile(`(?i)<` + tag + `\b[^>]*>`),
+		close:  regexp.MustCompile(`(?i)</` + tag + `\s*>`),
+		closer: "</" + tag + ">",
+		accept: hasMermaidClass,
+		decode: htmlEntities.Replace,
+	}
+}
+
+// htmlClassPattern matches the class attribute of an HTML start tag.
+var htmlClassPattern = regexp.MustCompile(`(?i)\sclass\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// hasMermaidClass reports whether an HTML start tag has the class
+// "mermaid".
+func hasMermaidClass(tag string) bool {
+	m := htmlClassPattern.FindStringSubmatch(tag)
+	return m != nil && slices.Contains(strings.Fields(m[1]+m[2]+m[3]), "mermaid")
+}
+
+// htmlEntities decodes the entities needed to write a diagram in HTML.
+var htmlEntities = strings.NewReplacer(
+	"&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'", "&#x27;", "'", "&nbsp;", " ", "&amp;", "&",
+)
+
+// MDXExtractor finds diagrams passed to a Mermaid component in MDX as a
+// template literal:
+//
+//	<Mermaid chart={`
+//	  graph TD
+//	    A --> B
+//	`} />
+//
+// Components shown in a fenced or indented code block are skipped.
+type MDXExtractor struct{}
+
+func (x *MDXExtractor) Name() string { return "mdx" }
+
+func (x *MDXExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	return mdxComponent.extract(r, true)
+}
+
+var mdxComponent = &delimited{
+	open:    regexp.MustCompile("<Mermaid\\b[^>]*?\\bchart\\s*=\\s*\\{\\s*`"),
+	close:   regexp.MustCompile("`"),
+	closer:  "`",
+	escapes: true,
+	decode:  strings.NewReplacer("\\`", "`", "\\$", "$", `\\`, `\`).Replace,
+}
+
+// HugoExtractor finds diagrams in Hugo "{{< mermaid >}}" shortcodes, also
+// written "{{% mermaid %}}" and with parameters such as
+// `{{< mermaid align="left" >}}`. Shortcodes quoted in a Markdown code
+// block are not diagrams, and are skipped.
+type HugoExtractor struct{}
+
+func (x *HugoExtractor) Name() string { return "hugo" }
+
+func (x *HugoExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	return hugoShortcode.extract(r, true)
+}
+
+var hugoShortcode = &delimited{
+	open:   regexp.MustCompile(`\{\{[<%]\s*mermaid\b[^}]*?[>%]\}\}`),
+	close:  regexp.MustCompile(`\{\{[<%]\s*/\s*mermaid\s*[>%]\}\}`),
+	closer: "{{< /mermaid >}}",
+}
+
+// ColonFenceExtractor finds diagrams in "::: mermaid" blocks, as used by
+// Azure DevOps wikis and some Markdown extensions:
+//
+//	::: mermaid
+//	graph TD
+//	:::
+//
+// A "::: mermaid" line inside a Markdown code block is skipped.
+type ColonFenceExtractor struct{}
+
+func (x *ColonFenceExtractor) Name() string { return "colon-fence" }
+
+func (x *ColonFenceExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	return colonFence.extract(r, true)
+}
+
+var colonFence = &delimited{
+	open:   regexp.MustCompile(`(?im)^[ \t]*:::[ \t]*mermaid[ \t\r]*$`),
+	close:  regexp.MustCompile(`(?m)^[ \t]*:::[ \t\r]*$`),
+	closer: ":::",
+}
@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHTMLExtractor(t *testing.T) {
+	html := "<html>\n" +
+		"<body>\n" +
+		"  <pre class=\"mermaid\">\n" +
+		"    graph TD\n" +
+		"      A --&gt; B\n" +
+		"  </pre>\n" +
+		"  <div class='diagram mermaid' id=\"x\">sequenceDiagram\n" +
+		"    Alice->>Bob: Hi</DIV>\n" +
+		"  <pre class=\"mermaid-like\">not a diagram</pre>\n" +
+		"  <pre>graph TD</pre>\n" +
+		"</body>\n"
+	blocks, diags, err := (&HTMLExtractor{}).Extract(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", diags)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+
+	pre := blocks[0]
+	if pre.Source != "    graph TD\n      A --> B" || pre.StartLine != 3 || pre.EndLine != 6 || pre.SourceLine != 4 {
+		t.Errorf("pre block = %+v", pre)
+	}
+	div := blocks[1]
+	if div.Source != "sequenceDiagram\n    Alice->>Bob: Hi" || div.StartLine != 7 || div.EndLine != 8 || div.SourceLine != 7 {
+		t.Errorf("div block = %+v", div)
+	}
+	if !reflect.DeepEqual(div.Indents, []int{38}) {
+		t.Errorf("div indents = %v, want [38]", div.Indents)
+	}
+
+	d, _ := ParseBlock(div)
+	if d.TypeRange.Start.Line != 7 || d.TypeRange.Start.Column != 39 {
+		t.Errorf("type range = %+v", d.TypeRange)
+	}
+}
+
+func TestMDXExtractor(t *testing.T) {
+	mdx := "import { Mermaid } from 'mdx-mermaid/Mermaid'\n" +
+		"\n" +
+		"<Mermaid chart={`\n" +
+		"  graph TD\n" +
+		"    A[\"\\`**bold**\\`\"] --> B\n" +
+		"`} />\n" +
+		"\n" +
+		"<Mermaid\n" +
+		"  chart={`pie\n" +
+		"    \"A\" : 1`}\n" +
+		"/>\n"
+	blocks, diags, err := (&MDXExtractor{}).Extract(strings.NewReader(mdx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 || len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v, %+v", blocks, diags)
+	}
+	if blocks[0].Source != "  graph TD\n    A[\"`**bold**`\"] --> B" || blocks[0].StartLine != 3 || blocks[0].SourceLine != 4 {
+		t.Errorf("first block = %+v", blocks[0])
+	}
+	if blocks[1].Source != "pie\n    \"A\" : 1" || blocks[1].StartLine != 8 || blocks[1].SourceLine != 9 || blocks[1].EndLine != 10 {
+		t.Errorf("second block = %+v", blocks[1])
+	}
+}
+
+func TestHugoExtractor(t *testing.T) {
+	md := "Text\n" +
+		"{{< mermaid align=\"left\" >}}\n" +
+		"graph LR\n" +
+		"  A --> B\n" +
+		"{{< /mermaid >}}\n" +
+		"{{% mermaid %}}pie{{% /mermaid %}}\n" +
+		"{{<mermaid>}}\n" +
+		"\n" +
+		"{{</mermaid>}}\n"
+	blocks, diags, err := (&HugoExtractor{}).Extract(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	if blocks[0].Source != "graph LR\n  A --> B" || blocks[0].StartLine != 2 || blocks[0].EndLine != 5 {
+		t.Errorf("first block = %+v", blocks[0])
+	}
+	if blocks[1].Source != "pie" || blocks[1].SourceLine != 6 || blocks[1].Indents[0] != 15 {
+		t.Errorf("inline block = %+v", blocks[1])
+	}
+	if len(diags) != 1 || diags[0].Message != "mermaid code block is empty" || diags[0].Line != 7 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+}
+
+func TestColonFenceExtractor(t *testing.T) {
+	md := "::: mermaid\n" +
+		"graph TD\n" +
+		"  A --> B\n" +
+		":::\n" +
+		"\n" +
+		":::note\n" +
+		"text\n" +
+		":::\n" +
+		"\n" +
+		"  :::mermaid\n" +
+		"  pie\n"
+	blocks, diags, err := (&ColonFenceExtractor{}).Extract(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].Source != "graph TD\n  A --> B" || blocks[0].EndLine != 4 {
+		t.Errorf("blocks = %+v", blocks)
+	}
+	want := []Diagnostic{{
+		Message:   "mermaid code block is not closed with \":::\"",
+		Line:      10,
+		Column:    1,
+		EndLine:   10,
+		EndColumn: 13,
+	}}
+	if !reflect.DeepEqual(diags, want) {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
+
+func TestEmbeddedInMarkdownCode(t *testing.T) {
+	md := "```html\n" +
+		"<div class=\"mermaid\">\n" +
+		"graph TD\n" +
+		"</div>\n" +
+		"```\n" +
+		"\n" +
+		"````markdown\n" +
+		"::: mermaid\n" +
+		"````\n" +
+		"\n" +
+		"    {{< mermaid >}}\n" +
+		"\n" +
+		"::: mermaid\n" +
+		"pie\n" +
+		":::\n"
+	blocks, diags, err := ExtractAll(strings.NewReader(md),
+		&ColonFenceExtractor{}, &HugoExtractor{}, &HTMLExtractor{Markdown: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", diags)
+	}
+	if len(blocks) != 1 || blocks[0].Source != "pie" || blocks[0].StartLine != 13 {
+		t.Errorf("blocks = %+v", blocks)
+	}
+
+	// Outside Markdown, the element is a diagram.
+	if blocks, _, _ := (&HTMLExtractor{}).Extract(strings.NewReader(md)); len(blocks) != 1 {
+		t.Errorf("expected the HTML element without Markdown, got %+v", blocks)
+	}
+}
@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ERDiagram is the model of an erDiagram.
+type ERDiagram struct {
+	Entities      []Entity // Each entity once, in order of first appearance
+	Relationships []ERRelationship
+}
+
+// Entity is an entity of an ER diagram. An entity with several attribute
+// blocks has the attributes of all of them.
+type Entity struct {
+	Name       string
+	Alias      string // Display name from NAME[alias], if any
+	Attributes []Attribute
+	Implicit   bool // Never declared, only used in relationships
+	Line       int
+	EndLine    int   // Line of the closing brace of the last block, if any
+	Range      Range // From the declaration, or first mention, to EndLine
+}
+
+// Attribute is an attribute of an entity, such as "string id PK "key"".
+type Attribute struct {
+	Type    string
+	Name    string
+	Keys    []string // "PK", "FK" and "UK"
+	Comment string
+	Line    int
+	Range   Range
+}
+
+// ERRelationship is a relationship between two entities, such as
+// "CUSTOMER ||--o{ ORDER : places".
+type ERRelationship struct {
+	From            string
+	To              string
+	FromMarker      string // Crow's foot marker as written, e.g. "||"
+	ToMarker        string // Crow's foot marker as written, e.g. "o{"
+	FromCardinality string // See erCardinalities
+	ToCardinality   string
+	Identifying     bool // Solid (--) rather than dashed (..) line
+	Label           string
+	Line            int
+	Range           Range
+}
+
+// erCardinalities maps crow's foot markers, on either side of the line,
+// to the cardinality they denote.
+var erCardinalities = map[string]string{
+	"|o": "zero-or-one", "o|": "zero-or-one",
+	"||": "exactly-one",
+	"}o": "zero-or-more", "o{": "zero-or-more",
+	"}|": "one-or-more", "|{": "one-or-more",
+}
+
+var (
+	// Match relationships: CUSTOMER ||--o{ ORDER : places
+	erRelationPattern = regexp.MustCompile(
+		`^([\w-]+|"[^"]+")\s*(\|o|\|\||\}o|\}\|)(--|\.\.)(o\||\|\||o\{|\|\{)\s*` +
+			`([\w-]+|"[^"]+")\s*(?::\s*(.*))?$`,
+	)
+
+	// Match the opening of an attribute block: NAME[alias] {
+	erEntityPattern = regexp.MustCompile(`^([\w-]+|"[^"]+")(?:\[([^\]]*)\])?\s*(?:\{\s*(\})?)?$`)
+
+	// Match attributes: type name PK, FK "comment"
+	erAttributePattern = regexp.MustCompile(
+		`^(\S+)\s+(\S+)((?:\s*,?\s*(?:PK|FK|UK)\b)*)(?:\s*"([^"]*)")?$`,
+	)
+)
+
+// erParser builds an ERDiagram line by line.
+type erParser struct {
+	d     *Diagram
+	ed    *ERDiagram
+	index map[string]int // entity name -> index in Entities
+	block string         // entity whose attribute block is open, if any
+}
+
+func (d *Diagram) parseER() {
+	p := &erParser{
+		d:     d,
+		ed:    &ERDiagram{},
+		index: make(map[string]int),
+	}
+	d.forEachBodyLine(p.parseLine)
+	if p.block != "" {
+		e := p.ed.Entities[p.index[p.block]]
+		d.addDiagnostic(e.Line, "entity %q is not closed with \"}\"", e.Name)
+	}
+	d.ER = p.ed
+}
+
+// entity returns the entity with the given name, creating it if needed.
+func (p *erParser) entity(name string, line int, implicit bool) *Entity {
+	if i, ok := p.index[name]; ok {
+		e := &p.ed.Entities[i]
+		if !implicit && e.Implicit {
+			e.Implicit = false
+			e.Line, e.Range = line, p.d.lineRange(line)
+		}
+		return e
+	}
+	p.index[name] = len(p.ed.Entities)
+	p.ed.Entities = append(p.ed.Entities, Entity{Name: name, Implicit: implicit, Line: line, Range: p.d.lineRange(line)})
+	return &p.ed.Entities[len(p.ed.Entities)-1]
+}
+
+func (p *erParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+
+	if p.block != "" {
+		if line == "}" {
+			e := p.entity(p.block, lineNum, false)
+			e.EndLine, e.Range.End = lineNum, p.d.lineRange(lineNum).End
+			p.block = ""
+			return
+		}
+		if attr, ok := parseAttribute(line, lineNum); ok {
+			attr.Range = p.d.lineRange(lineNum)
+			e := p.entity(p.block, lineNum, false)
+			e.Attributes = append(e.Attributes, attr)
+		}
+		return
+	}
+
+	if line == "}" {
+		p.d.addDiagnostic(lineNum, "\"}\" without an open entity")
+		return
+	}
+	if m := erRelationPattern.FindStringSubmatch(line); m != nil {
+		rel := ERRelationship{
+			From:            unquote(m[1]),
+			FromMarker:      m[2],
+			Identifying:     m[3] == "--",
+			ToMarker:        m[4],
+			To:              unquote(m[5]),
+			FromCardinality: erCardinalities[m[2]],
+			ToCardinality:   erCardinalities[m[4]],
+			Label:           unquote(strings.TrimSpace(m[6])),
+			Line:            lineNum,
+			Range:           p.d.lineRange(lineNum),
+		}
+		p.entity(rel.From, lineNum, true)
+		p.entity(rel.To, lineNum, true)
+		p.ed.Relationships = append(p.ed.Relationships, rel)
+		return
+	}
+
+	if m := erEntityPattern.FindStringSubmatch(line); m != nil {
+		e := p.entity(unquote(m[1]), lineNum, false)
+		if m[2] != "" {
+			e.Alias = unquote(m[2])
+		}
+		if strings.Contains(line, "{") {
+			if m[3] != "" {
+				e.EndLine, e.Range.End = lineNum, p.d.lineRange(lineNum).End
+			} else {
+				p.block = e.Name
+			}
+		}
+	}
+}
+
+// parseAttribute parses an attribute line inside an entity block.
+func parseAttribute(line string, lineNum int) (Attribute, bool) {
+	m := erAttributePattern.FindStringSubmatch(line)
+	if m == nil {
+		return Attribute{}, false
+	}
+	attr := Attribute{Type: m[1], Name: m[2], Comment: m[4], Line: lineNum}
+	for _, key := range strings.FieldsFunc(m[3], func(r rune) bool { return r == ',' || r == ' ' }) {
+		attr.Keys = append(attr.Keys, key)
+	}
+	return attr, true
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_ERRelationships(t *testing.T) {
+	source := "erDiagram\n" +
+		"  CUSTOMER ||--o{ ORDER : places\n" +
+		"  ORDER ||--|{ LINE-ITEM : \"contains\"\n" +
+		"  CUSTOMER }|..|| DELIVERY-ADDRESS : uses\n" +
+		"  PERSON |o--o| PASSPORT : holds"
+	d, _ := Parse(source, 1)
+
+	if d.ER == nil {
+		t.Fatal("expected an ER model")
+	}
+	want := []ERRelationship{
+		{From: "CUSTOMER", To: "ORDER", FromMarker: "||", ToMarker: "o{",
+			FromCardinality: "exactly-one", ToCardinality: "zero-or-more",
+			Identifying: true, Label: "places", Line: 2},
+		{From: "ORDER", To: "LINE-ITEM", FromMarker: "||", ToMarker: "|{",
+			FromCardinality: "exactly-one", ToCardinality: "one-or-more",
+			Identifying: true, Label: "contains", Line: 3},
+		{From: "CUSTOMER", To: "DELIVERY-ADDRESS", FromMarker: "}|", ToMarker: "||",
+			FromCardinality: "one-or-more", ToCardinality: "exactly-one",
+			Identifying: false, Label: "uses", Line: 4},
+		{From: "PERSON", To: "PASSPORT", FromMarker: "|o", ToMarker: "o|",
+			FromCardinality: "zero-or-one", ToCardinality: "zero-or-one",
+			Identifying: true, Label: "holds", Line: 5},
+	}
+	if len(d.ER.Relationships) != len(want) {
+		t.Fatalf("expected %d relationships, got %d", len(want), len(d.ER.Relationships))
+	}
+	for i, w := range want {
+		got := d.ER.Relationships[i]
+		got.Range = Range{}
+		if got != w {
+			t.Errorf("relationship %d = %+v, want %+v", i, d.ER.Relationships[i], w)
+		}
+	}
+	if len(d.ER.Entities) != 6 {
+		t.Errorf("expected 6 entities, got %d", len(d.ER.Entities))
+	}
+}
+
+func TestParse_EREntityAttributes(t *testing.T) {
+	source := "erDiagram\n" +
+		"  CUSTOMER ||--o{ ORDER : places\n" +
+		"  CUSTOMER {\n" +
+		"    string id PK \"the key\"\n" +
+		"    string name\n" +
+		"    int address_id FK, UK\n" +
+		"  }\n" +
+		"  p[Person] {\n" +
+		"  }"
+	d, _ := Parse(source, 1)
+
+	customer := d.ER.Entities[0]
+	if customer.Implicit || customer.Line != 3 || customer.EndLine != 7 {
+		t.Errorf("customer = %+v", customer)
+	}
+	if len(customer.Attributes) != 3 {
+		t.Fatalf("expected 3 attributes, got %d", len(customer.Attributes))
+	}
+	id := customer.Attributes[0]
+	if id.Type != "string" || id.Name != "id" || len(id.Keys) != 1 || id.Keys[0] != "PK" ||
+		id.Comment != "the key" || id.Line != 4 {
+		t.Errorf("id attribute = %+v", id)
+	}
+	if keys := customer.Attributes[2].Keys; len(keys) != 2 || keys[0] != "FK" || keys[1] != "UK" {
+		t.Errorf("address_id keys = %v", keys)
+	}
+
+	if !d.ER.Entities[1].Implicit {
+		t.Error("ORDER should be implicit")
+	}
+	person := d.ER.Entities[2]
+	if person.Name != "p" || person.Alias != "Person" || person.EndLine != 9 {
+		t.Errorf("person = %+v", person)
+	}
+}
+
+func TestParse_ERUnclosed(t *testing.T) {
+	source := "erDiagram\n" +
+		"  }\n" +
+		"  ORDER {\n" +
+		"    int id PK"
+	d, diags := Parse(source, 1)
+
+	want := []Diagnostic{
+		{Message: `"}" without an open entity`, Line: 2, Column: 3, EndLine: 2, EndColumn: 4},
+		{Message: `entity "ORDER" is not closed with "}"`, Line: 3, Column: 3, EndLine: 3, EndColumn: 10},
+	}
+	if len(diags) != len(want) || diags[0] != want[0] || diags[1] != want[1] {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+	if e := d.ER.Entities[0]; len(e.Attributes) != 1 || e.EndLine != 0 {
+		t.Errorf("ORDER = %+v", e)
+	}
+}
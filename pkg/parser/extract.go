@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Extractor finds the mermaid diagrams embedded in a host document, such as
+// a Markdown or HTML file. Problems with the embedding itself, such as a
+// block that is never closed, are returned as diagnostics.
+type Extractor interface {
+	Name() string
+	Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error)
+}
+
+// MarkdownExtractor finds fenced "```mermaid" code blocks, as
+// ExtractMermaidBlocks does.
+type MarkdownExtractor struct{}
+
+func (x *MarkdownExtractor) Name() string { return "markdown" }
+
+func (x *MarkdownExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	return ExtractMermaidBlocks(r)
+}
+
+// delimited finds diagrams written between an opening and a closing marker,
+// such as `<pre class="mermaid">` and "</pre>". The diagram may start on
+// the line of the opening marker and end on the line of the closing one.
+type delimited struct {
+	open    *regexp.Regexp
+	close   *regexp.Regexp
+	closer  string                     // The closing marker as shown in diagnostics
+	accept  func(open string) bool     // Filters matches of open, if set
+	escapes bool                       // A closing marker after a backslash is part of the diagram
+	decode  func(source string) string // Turns the text between the markers into mermaid source, if set
+}
+
+// extract finds the diagrams of the document read from r. In a markdown
+// document, markers inside code blocks are skipped.
+func (x *delimited) extract(r io.Reader, markdown bool) ([]MermaidBlock, []Diagnostic, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc := document{text: string(data), starts: lineStarts(string(data))}
+	var code []lineSpan
+	if markdown {
+		code = markdownCode(doc.text)
+	}
+
+	var blocks []MermaidBlock
+	var diags []Diagnostic
+	for pos := 0; pos < len(doc.text); {
+		loc := x.open.FindStringIndex(doc.text[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		pos = end
+		if x.accept != nil && !x.accept(doc.text[start:end]) {
+			continue
+		}
+		if line, _ := doc.position(start); inSpans(code, line) {
+			continue
+		}
+
+		closeStart, closeEnd := x.findClose(doc.text, end)
+		if closeStart < 0 {
+			diags = append(diags, doc.diagnostic(start, end, "mermaid code block is not closed with %q", x.closer))
+			break
+		}
+		pos = closeEnd
+
+		block := doc.block(start, end, closeStart)
+		if x.decode != nil {
+			block.Source = x.decode(block.Source)
+		}
+		if strings.TrimSpace(block.Source) == "" {
+			diags = append(diags, doc.diagnostic(start, end, "mermaid code block is empty"))
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, diags, nil
+}
+
+// findClose returns the span of the first closing marker at or after
+// offset from, or -1 if there is none.
+func (x *delimited) findClose(text string, from int) (int, int) {
+	for from <= len(text) {
+		loc := x.close.FindStringIndex(text[from:])
+		if loc == nil {
+			return -1, -1
+		}
+		start, end := from+loc[0], from+loc[1]
+		if !x.escapes || !escaped(text, start) {
+			return start, end
+		}
+		from = start + 1
+	}
+	return -1, -1
+}
+
+// escaped reports whether the byte at offset i of s follows an odd number
+// of backslashes.
+func escaped(s string, i int) bool {
+	n := 0
+	for i > 0 && s[i-1] == '\\' {
+		n++
+		i--
+	}
+	return n%2 == 1
+}
+
+// document is a host file being searched for diagrams.
+type document struct {
+	text   string
+	starts []int // Byte offset of each line
+}
+
+// position returns the 1-based line and character column of a byte offset.
+func (doc document) position(offset int) (line, col int) {
+	i := sort.SearchInts(doc.starts, offset+1) - 1
+	return i + 1, utf8.RuneCountInString(doc.text[doc.starts[i]:offset]) + 1
+}
+
+// diagnostic returns a problem located at the bytes [start, end).
+func (doc document) diagnostic(start, end int, format string, args ...any) Diagnostic {
+	line, col := doc.position(start)
+	endLine, endCol := doc.position(end)
+	return Diagnostic{
+		Message:   fmt.Sprintf(format, args...),
+		Line:      line,
+		Column:    col,
+		EndLine:   endLine,
+		EndColumn: endCol,
+	}
+}
+
+// block returns the diagram between an opening marker at [start, end) and
+// a closing marker at offset closeStart. The rest of the opening marker's
+// line is left out if it is blank, and so is the start of the closing
+// marker's line.
+func (doc document) block(start, end, closeStart int) MermaidBlock {
+	source := doc.text[end:closeStart]
+	first := end
+	if nl := strings.IndexByte(source, '\n'); nl >= 0 && strings.TrimSpace(source[:nl]) == "" {
+		source, first = source[nl+1:], end+nl+1
+	}
+	if nl := strings.LastIndexByte(source, '\n'); nl >= 0 && strings.TrimSpace(source[nl+1:]) == "" {
+		source = source[:nl]
+	} else if nl < 0 && strings.TrimSpace(source) == "" {
+		source = ""
+	}
+
+	b := MermaidBlock{Source: source}
+	b.StartLine, _ = doc.position(start)
+	b.EndLine, _ = doc.position(closeStart)
+	var col int
+	b.SourceLine, col = doc.position(first)
+	if col > 1 {
+		b.Indents = []int{col - 1}
+	}
+	return b
+}
+
+// ExtractAll runs each extractor over the document read from r and
+// combines their blocks and diagnostics in document order.
+func ExtractAll(r io.Reader, extractors ...Extractor) ([]MermaidBlock, []Diagnostic, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var blocks []MermaidBlock
+	var diags []Diagnostic
+	for _, x := range extractors {
+		b, d, err := x.Extract(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", x.Name(), err)
+		}
+		blocks, diags = append(blocks, b...), append(diags, d...)
+	}
+	sortExtracted(blocks, diags)
+	return blocks, diags, nil
+}
+
+// sortExtracted puts blocks and diagnostics found by several extractors in
+// document order.
+func sortExtracted(blocks []MermaidBlock, diags []Diagnostic) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].Cell != blocks[j].Cell {
+			return blocks[i].Cell < blocks[j].Cell
+		}
+		return blocks[i].StartLine < blocks[j].StartLine
+	})
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Cell != diags[j].Cell {
+			return diags[i].Cell < diags[j].Cell
+		}
+		return diags[i].Line < diags[j].Line
+	})
+}
@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractAll(t *testing.T) {
+	md := "::: mermaid\n" +
+		"pie\n" +
+		":::\n" +
+		"```mermaid\n" +
+		"graph TD\n" +
+		"```\n" +
+		"{{< mermaid >}}\n" +
+		"```mermaid\n"
+	blocks, diags, err := ExtractAll(strings.NewReader(md), &MarkdownExtractor{}, &HugoExtractor{}, &ColonFenceExtractor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || blocks[0].StartLine != 1 || blocks[1].StartLine != 4 {
+		t.Errorf("blocks = %+v", blocks)
+	}
+	if len(diags) != 2 || diags[0].Line != 7 || diags[1].Line != 8 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+}
+
+func TestParseBlock_SourceLine(t *testing.T) {
+	d, _ := ParseBlock(MermaidBlock{Source: "graph TD", StartLine: 2, SourceLine: 2, Indents: []int{5}})
+	if d.TypeRange.Start.Line != 2 || d.TypeRange.Start.Column != 6 {
+		t.Errorf("type range = %+v", d.TypeRange)
+	}
+}
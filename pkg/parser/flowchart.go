@@ -0,0 +1,591 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Flowchart is the syntax tree of a flowchart or graph diagram.
+type Flowchart struct {
+	Statements []FlowchartStatement
+}
+
+// FlowchartStatement is a single statement of a flowchart. It is one of
+// *ChainStatement, *SubgraphStatement or *KeywordStatement.
+type FlowchartStatement interface {
+	flowchartStatement()
+}
+
+// NodeRef is a mention of a node inside a statement, together with the
+// shape, label, "@{ ... }" attributes and ":::" classes it was written
+// with, if any.
+type NodeRef struct {
+	ID        string
+	Label     string // LabelText.Display
+	LabelText LabelText
+	Shape     string // empty when the node is referenced without brackets
+	Attrs     map[string]any
+	Classes   []string
+	Line      int
+	Range     Range
+}
+
+// Link is the edge operator joining two node groups of a chain.
+type Link struct {
+	ID         string // From "id@-->", if any
+	Style      string // The operator in canonical form, e.g. "-->", "<-.->", "==>"
+	Stroke     string // "normal", "thick", "dotted" or "invisible"
+	StartArrow string // Arrowhead at the source: "", "arrow", "cross" or "circle"
+	EndArrow   string // Arrowhead at the target, as for StartArrow
+	Length     int    // 1 for "-->", 2 for "--->" and so on
+	Label      string // From "|label|" or text inside the operator; LabelText.Display
+	LabelText  LabelText
+	Line       int
+	Range      Range // The edge ID, the operator and its |label|, if any
+}
+
+// ChainStatement is a sequence of node groups joined by links, such as
+// "A & B --> C -.-> D". Links[i] joins Groups[i] and Groups[i+1]; a plain
+// node definition is a chain with a single group and no links.
+type ChainStatement struct {
+	Groups [][]NodeRef
+	Links  []Link
+	Line   int
+	Range  Range
+}
+
+// SubgraphStatement is a subgraph block and the statements inside it.
+type SubgraphStatement struct {
+	ID        string
+	Title     string
+	Direction string // from a "direction" statement in the body, if any
+	Body      []FlowchartStatement
+	Line      int
+	EndLine   int   // line of the closing "end", 0 if the block is unclosed
+	Range     Range // up to the closing "end" or, if unclosed, the last statement
+}
+
+// KeywordStatement is a statement introduced by a keyword, such as the
+// diagram header or a style directive, with its arguments left unparsed.
+type KeywordStatement struct {
+	Keyword string
+	Text    string
+	Line    int
+	Range   Range
+}
+
+func (*ChainStatement) flowchartStatement()    {}
+func (*SubgraphStatement) flowchartStatement() {}
+func (*KeywordStatement) flowchartStatement()  {}
+
+// statementRange returns the Range of a flowchart statement.
+func statementRange(stmt FlowchartStatement) Range {
+	switch stmt := stmt.(type) {
+	case *ChainStatement:
+		return stmt.Range
+	case *SubgraphStatement:
+		return stmt.Range
+	case *KeywordStatement:
+		return stmt.Range
+	}
+	return Range{}
+}
+
+// flowchartParser is a recursive-descent parser over flowchart tokens.
+type flowchartParser struct {
+	d         *Diagram
+	tokens    []token
+	pos       int
+	subgraphs int // number of subgraphs seen, for generated IDs
+}
+
+func (d *Diagram) parseFlowchart() {
+	// Lexing starts at the type declaration, after any frontmatter.
+	p := &flowchartParser{
+		d:      d,
+		tokens: lexFlowchart(d.source, d.starts[d.header]),
+	}
+	d.Flowchart = p.parse()
+	d.collectFlowchart(d.Flowchart)
+
+	// The lexer ends the header at a ";", so "graph TD;" has direction "TD".
+	if stmts := d.Flowchart.Statements; len(stmts) > 0 {
+		if kw, ok := stmts[0].(*KeywordStatement); ok && (kw.Keyword == "flowchart" || kw.Keyword == "graph") {
+			if fields := strings.Fields(kw.Text); len(fields) > 0 {
+				d.Direction = fields[0]
+				off := kw.Range.Start.Offset + len(kw.Keyword)
+				off += strings.Index(d.source[off:kw.Range.End.Offset], d.Direction)
+				d.DirectionRange = d.span(off, off+len(d.Direction))
+			}
+		}
+	}
+}
+
+func (p *flowchartParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes a token, reporting it if it is a syntax error.
+func (p *flowchartParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	if tok.kind == tokIllegal && tok.value != "" {
+		p.errorAt(tok, "%s", tok.value)
+	}
+	return tok
+}
+
+// errorAt records a diagnostic spanning the text of tok.
+func (p *flowchartParser) errorAt(tok token, format string, args ...any) {
+	p.d.addSpanDiagnostic(tok.pos, tok.pos+len(tok.text), format, args...)
+}
+
+// line returns the file line number of a byte offset in the source.
+func (p *flowchartParser) line(pos int) int {
+	i := sort.SearchInts(p.d.starts, pos+1) - 1
+	return p.d.StartLine + i
+}
+
+// span returns the Range from the start of first to the end of last.
+func (p *flowchartParser) span(first, last token) Range {
+	return p.d.span(first.pos, last.pos+len(last.text))
+}
+
+func (p *flowchartParser) parse() *Flowchart {
+	stmts, _, _ := p.parseStatements(false)
+	return &Flowchart{Statements: stmts}
+}
+
+// parseStatements parses statements up to the end of input or, inside a
+// subgraph, up to the closing "end". It returns the end token and true if
+// one was found.
+func (p *flowchartParser) parseStatements(inSubgraph bool) ([]FlowchartStatement, token, bool) {
+	var stmts []FlowchartStatement
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case tokEOF:
+			return stmts, tok, false
+		case tokSeparator:
+			p.next()
+		case tokKeyword:
+			keyword := strings.ToLower(tok.text)
+			if keyword == "end" && inSubgraph {
+				p.next()
+				p.endStatement()
+				return stmts, tok, true
+			}
+			if keyword == "end" {
+				p.errorAt(tok, "\"end\" without an open subgraph")
+			}
+			if keyword == "subgraph" {
+				stmts = append(stmts, p.parseSubgraph())
+				continue
+			}
+			stmts = append(stmts, p.parseKeyword())
+			p.endStatement()
+		case tokID:
+			if stmt := p.parseChain(); stmt != nil {
+				stmts = append(stmts, stmt)
+			}
+			p.endStatement()
+		default:
+			p.endStatement()
+		}
+	}
+}
+
+// endStatement skips anything left before the next statement separator,
+// reporting each run of skipped tokens as unexpected. Tokens the lexer
+// rejected are reported with their own message instead.
+func (p *flowchartParser) endStatement() {
+	var first, last token
+	skipped := false
+	report := func() {
+		if skipped {
+			end := last.pos + len(last.text)
+			p.d.addSpanDiagnostic(first.pos, end, "unexpected %q", p.d.source[first.pos:end])
+			skipped = false
+		}
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokEOF:
+			report()
+			return
+		case tok.kind == tokSeparator:
+			report()
+			p.next()
+			return
+		case tok.kind == tokIllegal && tok.value != "":
+			report()
+		default:
+			if !skipped {
+				first, skipped = tok, true
+			}
+			last = tok
+		}
+		p.next()
+	}
+}
+
+func (p *flowchartParser) parseKeyword() *KeywordStatement {
+	kw := p.next()
+	stmt := &KeywordStatement{
+		Keyword: strings.ToLower(kw.text),
+		Line:    p.line(kw.pos),
+		Range:   p.span(kw, kw),
+	}
+	if p.peek().kind == tokText {
+		text := p.next()
+		stmt.Text = text.value
+		stmt.Range = p.span(kw, text)
+	}
+	return stmt
+}
+
+// parseSubgraph parses a subgraph header, its body and the closing "end".
+func (p *flowchartParser) parseSubgraph() *SubgraphStatement {
+	kw := p.peek()
+	header := p.parseKeyword()
+	p.endStatement()
+	p.subgraphs++
+
+	stmt := &SubgraphStatement{Line: header.Line, Range: header.Range}
+	stmt.ID, stmt.Title = parseSubgraphHeader(header.Text, p.subgraphs-1)
+
+	body, end, closed := p.parseStatements(true)
+	stmt.Body = body
+	if closed {
+		stmt.EndLine = p.line(end.pos)
+		stmt.Range.End = p.span(end, end).End
+	} else {
+		if len(body) > 0 {
+			stmt.Range.End = statementRange(body[len(body)-1]).End
+		}
+		p.errorAt(kw, "subgraph %q is not closed with \"end\"", stmt.ID)
+	}
+	for _, s := range body {
+		if kw, ok := s.(*KeywordStatement); ok && kw.Keyword == "direction" {
+			stmt.Direction = kw.Text
+		}
+	}
+	return stmt
+}
+
+// subgraphHeaderPattern matches "id [title]" subgraph headers.
+var subgraphHeaderPattern = regexp.MustCompile(`^([^\s\[]+)\s*\[(.*)\]$`)
+
+// parseSubgraphHeader splits the text after "subgraph" into an ID and a
+// title. As in Mermaid, a title containing whitespace with no explicit ID
+// gets a generated ID of the form "subGraphN".
+func parseSubgraphHeader(text string, n int) (id, title string) {
+	if m := subgraphHeaderPattern.FindStringSubmatch(text); m != nil {
+		return m[1], unquote(strings.TrimSpace(m[2]))
+	}
+	title = unquote(text)
+	if title == "" || strings.ContainsAny(title, " \t") {
+		return fmt.Sprintf("subGraph%d", n), title
+	}
+	return title, title
+}
+
+// unquote removes one pair of surrounding double quotes, if present.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseChain parses "group { link group }". A link without a target group
+// is reported and dropped, keeping the part of the chain that is complete.
+func (p *flowchartParser) parseChain() *ChainStatement {
+	first := p.peek()
+	group := p.parseGroup()
+	if group == nil {
+		return nil
+	}
+	stmt := &ChainStatement{
+		Groups: [][]NodeRef{group},
+		Line:   p.line(first.pos),
+		Range:  Range{Start: group[0].Range.Start, End: group[len(group)-1].Range.End},
+	}
+
+	for kind := p.peek().kind; kind == tokLink || kind == tokLinkID; kind = p.peek().kind {
+		first := p.peek()
+		var id string
+		if kind == tokLinkID {
+			id = p.next().value // The lexer only emits an edge ID before a link
+		}
+		linkTok := p.next()
+		op := linkTok.link
+		text := p.decodeLabel(linkTok)
+		link := Link{
+			ID:         id,
+			Style:      op.String(),
+			Stroke:     op.stroke,
+			StartArrow: op.start,
+			EndArrow:   op.end,
+			Length:     op.length,
+			Label:      text.Display,
+			LabelText:  text,
+			Line:       p.line(first.pos),
+			Range:      p.span(first, linkTok),
+		}
+		if p.peek().kind == tokLinkLabel {
+			label := p.next()
+			link.LabelText = p.decodeLabel(label)
+			link.Label = link.LabelText.Display
+			link.Range = p.span(first, label)
+		}
+		target := p.parseGroup()
+		if target == nil {
+			// The lexer reports its own errors, such as an unclosed label.
+			if tok := p.peek(); tok.kind != tokIllegal || tok.value == "" {
+				p.errorAt(linkTok, "edge %q has no target node", link.Style)
+			}
+			break
+		}
+		stmt.Links = append(stmt.Links, link)
+		stmt.Groups = append(stmt.Groups, target)
+		stmt.Range.End = target[len(target)-1].Range.End
+	}
+	return stmt
+}
+
+// parseGroup parses "node { & node }".
+func (p *flowchartParser) parseGroup() []NodeRef {
+	var group []NodeRef
+	for {
+		ref, ok := p.parseNodeRef()
+		if !ok {
+			return nil
+		}
+		group = append(group, ref)
+		if p.peek().kind != tokAmp {
+			return group
+		}
+		p.next()
+	}
+}
+
+// parseNodeRef parses an identifier with an optional shape or attribute
+// block and classes.
+func (p *flowchartParser) parseNodeRef() (NodeRef, bool) {
+	tok := p.peek()
+	if tok.kind != tokID {
+		return NodeRef{}, false
+	}
+	p.next()
+	ref := NodeRef{ID: tok.text, Line: p.line(tok.pos), Range: p.span(tok, tok)}
+	if p.peek().kind == tokShape {
+		shape := p.next()
+		ref.LabelText = p.decodeLabel(shape)
+		ref.Label = ref.LabelText.Display
+		ref.Shape = shape.shape
+		ref.Range = p.span(tok, shape)
+	} else if p.peek().kind == tokAttrs {
+		attrs := p.next()
+		ref.Attrs = p.parseAttrs(attrs)
+		ref.Range = p.span(tok, attrs)
+		p.applyAttrs(&ref, attrs)
+	}
+	for p.peek().kind == tokClass {
+		class := p.next()
+		ref.Classes = append(ref.Classes, class.value)
+		ref.Range.End = p.span(class, class).End
+	}
+	return ref, true
+}
+
+// decodeLabel decodes the label held in the value of tok, reporting
+// invalid entity codes where they appear in the source.
+func (p *flowchartParser) decodeLabel(tok token) LabelText {
+	label, errs := decodeLabel(tok.value)
+	base := tok.pos + strings.Index(tok.text, tok.value)
+	for _, e := range errs {
+		p.d.addSpanDiagnostic(base+e.offset, base+e.offset+len(e.code), "invalid entity code %q", e.code)
+	}
+	return label
+}
+
+// parseAttrs decodes an "@{ ... }" attribute block, which is written as a
+// YAML flow mapping.
+func (p *flowchartParser) parseAttrs(tok token) map[string]any {
+	v, err := parseYAMLFlow(tok.value, tok.pos+1)
+	if err != nil {
+		p.d.configError(err, "attributes")
+		return nil
+	}
+	attrs, _ := v.(map[string]any)
+	return attrs
+}
+
+// collectFlowchart fills Nodes, Edges and Subgraphs from the syntax tree.
+// Each node is recorded once, at its first mention; a label given later
+// still applies to it. A node belongs to the innermost subgraph it is first
+// mentioned in. A bare reference to a subgraph ID is an edge endpoint, not
+// a node, and "id@{ ... }" naming an earlier edge sets that edge's
+// attributes. Class and style statements apply to nodes wherever they
+// appear.
+func (d *Diagram) collectFlowchart(fc *Flowchart) {
+	subgraphIDs := make(map[string]bool)
+	d.collectSubgraphs(fc.Statements, "", subgraphIDs)
+
+	index := make(map[string]int)
+	nodeStyles := make(map[string][]StyleProperty)
+	addNode := func(ref NodeRef, subgraph string) {
+		if ref.Attrs != nil && ref.Shape == "" {
+			if d.setEdgeAttrs(ref.ID, ref.Attrs) {
+				return
+			}
+			ref.Shape = "rect" // A node declared with attributes but no shape
+		}
+		for _, class := range ref.Classes {
+			d.ClassAssignments = append(d.ClassAssignments, ClassAssignment{
+				Node:  ref.ID,
+				Class: class,
+				Line:  ref.Line,
+				Range: ref.Range,
+			})
+		}
+		i, seen := index[ref.ID]
+		if !seen {
+			if ref.Shape == "" && subgraphIDs[ref.ID] {
+				return
+			}
+			index[ref.ID] = len(d.Nodes)
+			d.Nodes = append(d.Nodes, Node{
+				ID:        ref.ID,
+				Label:     ref.Label,
+				LabelText: ref.LabelText,
+				Line:      ref.Line,
+				Shape:     ref.Shape,
+				Subgraph:  subgraph,
+				Range:     ref.Range,
+			})
+			i = len(d.Nodes) - 1
+		}
+		if ref.Attrs != nil {
+			if d.Nodes[i].Attrs == nil {
+				d.Nodes[i].Attrs = make(map[string]any)
+			}
+			mergeConfig(d.Nodes[i].Attrs, ref.Attrs)
+		}
+		if !seen {
+			return
+		}
+		if ref.Shape != "" && d.Nodes[i].Shape == "" {
+			d.Nodes[i].Label = ref.Label
+			d.Nodes[i].LabelText = ref.LabelText
+			d.Nodes[i].Shape = ref.Shape
+		}
+	}
+
+	var walk func(stmts []FlowchartStatement, subgraph string)
+	walk = func(stmts []FlowchartStatement, subgraph string) {
+		for _, stmt := range stmts {
+			switch stmt := stmt.(type) {
+			case *SubgraphStatement:
+				walk(stmt.Body, stmt.ID)
+			case *KeywordStatement:
+				switch stmt.Keyword {
+				case "classdef", "class", "style", "linkstyle":
+					d.collectStyle(stmt, nodeStyles)
+				}
+			case *ChainStatement:
+				for _, ref := range stmt.Groups[0] {
+					addNode(ref, subgraph)
+				}
+				for i, link := range stmt.Links {
+					for _, ref := range stmt.Groups[i+1] {
+						addNode(ref, subgraph)
+					}
+					for _, from := range stmt.Groups[i] {
+						for _, to := range stmt.Groups[i+1] {
+							d.Edges = append(d.Edges, Edge{
+								ID:         link.ID,
+								From:       from.ID,
+								To:         to.ID,
+								Label:      link.Label,
+								LabelText:  link.LabelText,
+								Line:       link.Line,
+								Style:      link.Style,
+								Stroke:     link.Stroke,
+								StartArrow: link.StartArrow,
+								EndArrow:   link.EndArrow,
+								Length:     link.Length,
+								Range:      Range{Start: from.Range.Start, End: to.Range.End},
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+	walk(fc.Statements, "")
+	d.applyStyles(nodeStyles)
+
+	for i := range d.Subgraphs {
+		for _, n := range d.Nodes {
+			if n.Subgraph == d.Subgraphs[i].ID {
+				d.Subgraphs[i].Nodes = append(d.Subgraphs[i].Nodes, n.ID)
+			}
+		}
+	}
+}
+
+// setEdgeAttrs merges attrs into the edges with the given ID and reports
+// whether there were any.
+func (d *Diagram) setEdgeAttrs(id string, attrs map[string]any) bool {
+	found := false
+	for i := range d.Edges {
+		if d.Edges[i].ID != id {
+			continue
+		}
+		if d.Edges[i].Attrs == nil {
+			d.Edges[i].Attrs = make(map[string]any)
+		}
+		mergeConfig(d.Edges[i].Attrs, attrs)
+		found = true
+	}
+	return found
+}
+
+// collectSubgraphs records every subgraph in document order.
+func (d *Diagram) collectSubgraphs(stmts []FlowchartStatement, parent string, ids map[string]bool) {
+	for _, stmt := range stmts {
+		sg, ok := stmt.(*SubgraphStatement)
+		if !ok {
+			continue
+		}
+		ids[sg.ID] = true
+		d.Subgraphs = append(d.Subgraphs, Subgraph{
+			ID:        sg.ID,
+			Title:     sg.Title,
+			Direction: sg.Direction,
+			Parent:    parent,
+			Line:      sg.Line,
+			EndLine:   sg.EndLine,
+			Range:     sg.Range,
+		})
+		d.collectSubgraphs(sg.Body, sg.ID, ids)
+	}
+}
+
+// lineStarts returns the byte offset at which each line of s begins.
+func lineStarts(s string) []int {
+	starts := []int{0}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
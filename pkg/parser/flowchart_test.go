@@ -0,0 +1,425 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_FlowchartChainedEdges(t *testing.T) {
+	d, _ := Parse("flowchart LR\n  A --> B --> C", 1)
+
+	if len(d.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(d.Edges))
+	}
+	if d.Edges[0].From != "A" || d.Edges[0].To != "B" {
+		t.Errorf("edge 0: got %s->%s, want A->B", d.Edges[0].From, d.Edges[0].To)
+	}
+	if d.Edges[1].From != "B" || d.Edges[1].To != "C" {
+		t.Errorf("edge 1: got %s->%s, want B->C", d.Edges[1].From, d.Edges[1].To)
+	}
+}
+
+func TestParse_FlowchartAmpersand(t *testing.T) {
+	d, _ := Parse("flowchart LR\n  A & B --> C & D", 1)
+
+	want := [][2]string{{"A", "C"}, {"A", "D"}, {"B", "C"}, {"B", "D"}}
+	if len(d.Edges) != len(want) {
+		t.Fatalf("expected %d edges, got %d", len(want), len(d.Edges))
+	}
+	for i, w := range want {
+		if d.Edges[i].From != w[0] || d.Edges[i].To != w[1] {
+			t.Errorf("edge %d: got %s->%s, want %s->%s",
+				i, d.Edges[i].From, d.Edges[i].To, w[0], w[1])
+		}
+	}
+	if len(d.Nodes) != 4 {
+		t.Errorf("expected 4 nodes, got %d", len(d.Nodes))
+	}
+}
+
+func TestParse_FlowchartQuotedLabels(t *testing.T) {
+	d, _ := Parse("flowchart LR\n  A[\"a ] b\"] -->|\"x | y\"| B(\"(round)\")", 1)
+
+	if len(d.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(d.Nodes))
+	}
+	if d.Nodes[0].Label != "a ] b" {
+		t.Errorf("node A label = %q, want %q", d.Nodes[0].Label, "a ] b")
+	}
+	if d.Nodes[1].Label != "(round)" || d.Nodes[1].Shape != "round" {
+		t.Errorf("node B = %q/%q, want %q/round", d.Nodes[1].Label, d.Nodes[1].Shape, "(round)")
+	}
+	if len(d.Edges) != 1 || d.Edges[0].Label != "x | y" {
+		t.Fatalf("expected one edge labelled %q, got %+v", "x | y", d.Edges)
+	}
+}
+
+func TestParse_FlowchartShapes(t *testing.T) {
+	source := "flowchart TD\n" +
+		"  a[rect]\n  b(round)\n  c{rhombus}\n  d[[sub]]\n  e[(db)]\n" +
+		"  f([stadium])\n  g((circle))\n  h>asym]\n  i[/para/]\n  j[\\alt\\]"
+	d, _ := Parse(source, 1)
+
+	want := map[string]string{
+		"a": "rect", "b": "round", "c": "rhombus", "d": "subroutine", "e": "cylinder",
+		"f": "stadium", "g": "circle", "h": "asymmetric", "i": "parallelogram", "j": "parallelogram-alt",
+	}
+	if len(d.Nodes) != len(want) {
+		t.Fatalf("expected %d nodes, got %d", len(want), len(d.Nodes))
+	}
+	for _, n := range d.Nodes {
+		if n.Shape != want[n.ID] {
+			t.Errorf("node %s shape = %q, want %q", n.ID, n.Shape, want[n.ID])
+		}
+	}
+}
+
+func TestParse_FlowchartDottedLinkIsExact(t *testing.T) {
+	// The dot in -.-> must not match any character.
+	d, _ := Parse("flowchart LR\n  A -x-> B", 1)
+	if len(d.Edges) != 0 {
+		t.Errorf("expected no edges, got %+v", d.Edges)
+	}
+}
+
+func TestParse_FlowchartKeywordStatements(t *testing.T) {
+	source := "flowchart LR\n  A --> B\n  style A fill:#f9f\n  class A,B important\n  classDef important stroke:#f00"
+	d, _ := Parse(source, 1)
+
+	if len(d.Nodes) != 2 {
+		t.Errorf("expected 2 nodes, got %d: %+v", len(d.Nodes), d.Nodes)
+	}
+
+	var keywords []string
+	for _, stmt := range d.Flowchart.Statements {
+		if kw, ok := stmt.(*KeywordStatement); ok {
+			keywords = append(keywords, kw.Keyword)
+		}
+	}
+	want := []string{"flowchart", "style", "class", "classdef"}
+	if len(keywords) != len(want) {
+		t.Fatalf("keywords = %v, want %v", keywords, want)
+	}
+	for i := range want {
+		if keywords[i] != want[i] {
+			t.Errorf("keyword %d = %q, want %q", i, keywords[i], want[i])
+		}
+	}
+}
+
+func TestParse_FlowchartStatements(t *testing.T) {
+	d, _ := Parse("graph TD; A[One] & B --> C; C -.-> D", 5)
+
+	if len(d.Flowchart.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(d.Flowchart.Statements))
+	}
+	chain, ok := d.Flowchart.Statements[1].(*ChainStatement)
+	if !ok {
+		t.Fatalf("statement 1 is %T, want *ChainStatement", d.Flowchart.Statements[1])
+	}
+	if len(chain.Groups) != 2 || len(chain.Groups[0]) != 2 || len(chain.Links) != 1 {
+		t.Fatalf("unexpected chain shape: %+v", chain)
+	}
+	if chain.Groups[0][0].Label != "One" {
+		t.Errorf("label = %q, want %q", chain.Groups[0][0].Label, "One")
+	}
+	if chain.Line != 5 {
+		t.Errorf("line = %d, want 5", chain.Line)
+	}
+}
+
+func TestParse_FlowchartEdgeLines(t *testing.T) {
+	d, _ := Parse("flowchart LR\n  A --> B\n\n  B --> C", 3)
+
+	if len(d.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(d.Edges))
+	}
+	if d.Edges[0].Line != 4 || d.Edges[1].Line != 6 {
+		t.Errorf("edge lines = %d, %d; want 4, 6", d.Edges[0].Line, d.Edges[1].Line)
+	}
+}
+
+func TestParse_FlowchartSubgraphs(t *testing.T) {
+	source := "flowchart LR\n" +
+		"  subgraph outer [Outer box]\n" +
+		"    direction TB\n" +
+		"    A --> B\n" +
+		"    subgraph inner\n" +
+		"      C\n" +
+		"    end\n" +
+		"  end\n" +
+		"  B --> C\n" +
+		"  D --> outer"
+	d, _ := Parse(source, 1)
+
+	if len(d.Subgraphs) != 2 {
+		t.Fatalf("expected 2 subgraphs, got %d", len(d.Subgraphs))
+	}
+	outer, inner := d.Subgraphs[0], d.Subgraphs[1]
+	if outer.ID != "outer" || outer.Title != "Outer box" || outer.Direction != "TB" {
+		t.Errorf("outer = %+v", outer)
+	}
+	if outer.Line != 2 || outer.EndLine != 8 {
+		t.Errorf("outer span = %d-%d, want 2-8", outer.Line, outer.EndLine)
+	}
+	if inner.Parent != "outer" || inner.Line != 5 || inner.EndLine != 7 {
+		t.Errorf("inner = %+v", inner)
+	}
+	if len(outer.Nodes) != 2 || outer.Nodes[0] != "A" || outer.Nodes[1] != "B" {
+		t.Errorf("outer nodes = %v, want [A B]", outer.Nodes)
+	}
+	if len(inner.Nodes) != 1 || inner.Nodes[0] != "C" {
+		t.Errorf("inner nodes = %v, want [C]", inner.Nodes)
+	}
+
+	subgraphOf := make(map[string]string)
+	for _, n := range d.Nodes {
+		subgraphOf[n.ID] = n.Subgraph
+	}
+	if subgraphOf["D"] != "" {
+		t.Errorf("node D subgraph = %q, want top level", subgraphOf["D"])
+	}
+	if _, ok := subgraphOf["outer"]; ok {
+		t.Error("edge to subgraph ID should not create a node")
+	}
+	if len(d.Edges) != 3 {
+		t.Errorf("expected 3 edges, got %d", len(d.Edges))
+	}
+}
+
+func TestParse_FlowchartSubgraphFirstMention(t *testing.T) {
+	source := "flowchart LR\n" +
+		"  A[Top] --> B\n" +
+		"  subgraph box\n" +
+		"    A --> C\n" +
+		"  end"
+	d, _ := Parse(source, 1)
+
+	subgraphOf := make(map[string]string)
+	for _, n := range d.Nodes {
+		subgraphOf[n.ID] = n.Subgraph
+	}
+	if subgraphOf["A"] != "" || subgraphOf["C"] != "box" {
+		t.Errorf("subgraphs = %v, want A at top level and C in box", subgraphOf)
+	}
+	if nodes := d.Subgraphs[0].Nodes; len(nodes) != 1 || nodes[0] != "C" {
+		t.Errorf("box nodes = %v, want [C]", nodes)
+	}
+}
+
+func TestParse_FlowchartSubgraphHeaders(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantID    string
+		wantTitle string
+	}{
+		{"one", "one", "one"},
+		{"one [Title]", "one", "Title"},
+		{`one["Quoted title"]`, "one", "Quoted title"},
+		{`"Only a title"`, "subGraph0", "Only a title"},
+		{"Two words", "subGraph0", "Two words"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			d, _ := Parse("flowchart LR\n  subgraph "+tt.header+"\n    A\n  end", 1)
+			if len(d.Subgraphs) != 1 {
+				t.Fatalf("expected 1 subgraph, got %d", len(d.Subgraphs))
+			}
+			sg := d.Subgraphs[0]
+			if sg.ID != tt.wantID || sg.Title != tt.wantTitle {
+				t.Errorf("got %q/%q, want %q/%q", sg.ID, sg.Title, tt.wantID, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestParse_FlowchartUnclosedSubgraph(t *testing.T) {
+	d, diags := Parse("flowchart LR\n  subgraph one\n    A --> B", 1)
+	if len(d.Subgraphs) != 1 || d.Subgraphs[0].EndLine != 0 {
+		t.Fatalf("expected one unclosed subgraph, got %+v", d.Subgraphs)
+	}
+	if len(d.Edges) != 1 {
+		t.Errorf("expected statements inside an unclosed subgraph to be parsed")
+	}
+	want := Diagnostic{Message: `subgraph "one" is not closed with "end"`, Line: 2, Column: 3, EndLine: 2, EndColumn: 11}
+	if len(diags) != 1 || diags[0] != want {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
+
+func TestParse_FlowchartSyntaxErrors(t *testing.T) {
+	source := "flowchart LR\n" +
+		"  A[Start --> B\n" +
+		"  C[\"unterminated] --> D\n" +
+		"  E -->|oops F\n" +
+		"  G --> H\n" +
+		"  H -->\n" +
+		"  subgraph \"sg\n" +
+		"  end\n" +
+		"  end"
+	d, diags := Parse(source, 10)
+
+	want := []Diagnostic{
+		{Message: `"[" has no matching "]"`, Line: 11, Column: 4, EndLine: 11, EndColumn: 16},
+		{Message: "unterminated quoted string", Line: 12, Column: 4, EndLine: 12, EndColumn: 25},
+		{Message: `"|" has no matching "|"`, Line: 13, Column: 8, EndLine: 13, EndColumn: 15},
+		{Message: `edge "-->" has no target node`, Line: 15, Column: 5, EndLine: 15, EndColumn: 8},
+		{Message: "unterminated quoted string", Line: 16, Column: 12, EndLine: 16, EndColumn: 15},
+		{Message: `"end" without an open subgraph`, Line: 18, Column: 3, EndLine: 18, EndColumn: 6},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %+v", len(want), diags)
+	}
+	for i, w := range want {
+		if diags[i] != w {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, diags[i], w)
+		}
+	}
+
+	// The well-formed statements are still parsed.
+	if len(d.Edges) != 1 || d.Edges[0].From != "G" || d.Edges[0].To != "H" {
+		t.Errorf("edges = %+v", d.Edges)
+	}
+}
+
+func TestParse_FlowchartEdgeGrammar(t *testing.T) {
+	source := "flowchart LR\n" +
+		"  A ----> B\n" +
+		"  B <-.-> C\n" +
+		"  C o==o D\n" +
+		"  D -- \"some text\" --> E\n" +
+		"  E e1@x--x F\n" +
+		"  e1@{ animate: true, curve: linear }"
+	d, diags := Parse(source, 1)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	want := []Edge{
+		{From: "A", To: "B", Style: "---->", Stroke: "normal", EndArrow: "arrow", Length: 3},
+		{From: "B", To: "C", Style: "<-.->", Stroke: "dotted", StartArrow: "arrow", EndArrow: "arrow", Length: 1},
+		{From: "C", To: "D", Style: "o==o", Stroke: "thick", StartArrow: "circle", EndArrow: "circle", Length: 1},
+		{From: "D", To: "E", Style: "-->", Stroke: "normal", EndArrow: "arrow", Length: 1, Label: "some text"},
+		{ID: "e1", From: "E", To: "F", Style: "x--x", Stroke: "normal", StartArrow: "cross", EndArrow: "cross", Length: 1},
+	}
+	if len(d.Edges) != len(want) {
+		t.Fatalf("expected %d edges, got %+v", len(want), d.Edges)
+	}
+	for i, w := range want {
+		e := d.Edges[i]
+		if e.ID != w.ID || e.From != w.From || e.To != w.To || e.Style != w.Style || e.Stroke != w.Stroke ||
+			e.StartArrow != w.StartArrow || e.EndArrow != w.EndArrow || e.Length != w.Length || e.Label != w.Label {
+			t.Errorf("edge %d = %+v, want %+v", i, e, w)
+		}
+	}
+
+	if attrs := d.Edges[4].Attrs; attrs["animate"] != true || attrs["curve"] != "linear" {
+		t.Errorf("edge attrs = %v", attrs)
+	}
+	for _, n := range d.Nodes {
+		if n.ID == "e1" {
+			t.Errorf("edge attribute statement should not create a node")
+		}
+	}
+}
+
+func TestParse_FlowchartUnclosedLinkText(t *testing.T) {
+	d, diags := Parse("flowchart LR\n  A -- oops B\n  C --> D", 1)
+	if len(diags) != 1 || diags[0].Message != `link text after "--" is not closed by a link` || diags[0].Line != 2 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+	if len(d.Edges) != 1 || d.Edges[0].From != "C" {
+		t.Errorf("edges = %+v", d.Edges)
+	}
+}
+
+func TestParse_FlowchartLeftoverTokens(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"A --> B C", []string{`unexpected "C"`}},
+		{"F[x] G[y]", []string{`unexpected "G[y]"`}},
+		{"D --> !E", []string{`edge "-->" has no target node`, `unexpected "!E"`}},
+		{"I --> J)", []string{`unexpected ")"`}},
+		{"--> K", []string{`unexpected "--> K"`}},
+	}
+	for _, tt := range tests {
+		_, diags := Parse("flowchart LR\n  "+tt.src, 1)
+		if len(diags) != len(tt.want) {
+			t.Errorf("%q: diagnostics = %+v, want %q", tt.src, diags, tt.want)
+			continue
+		}
+		for i, w := range tt.want {
+			if diags[i].Message != w || diags[i].Line != 2 {
+				t.Errorf("%q: diagnostic %d = %+v, want %q on line 2", tt.src, i, diags[i], w)
+			}
+		}
+	}
+}
+
+func TestParse_FlowchartAccessibility(t *testing.T) {
+	source := "flowchart LR\n" +
+		"  accTitle: Login flow\n" +
+		"  accDescr {\n" +
+		"    How a user\n" +
+		"    logs in\n" +
+		"  }\n" +
+		"  A --> B"
+	d, diags := Parse(source, 1)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(d.Nodes) != 2 {
+		t.Errorf("nodes = %+v", d.Nodes)
+	}
+	title, ok := d.Flowchart.Statements[1].(*KeywordStatement)
+	if !ok || title.Keyword != "acctitle" || title.Text != "Login flow" {
+		t.Errorf("title = %+v", d.Flowchart.Statements[1])
+	}
+	descr, ok := d.Flowchart.Statements[2].(*KeywordStatement)
+	if !ok || descr.Keyword != "accdescr" || descr.Text != "How a user\n    logs in" {
+		t.Errorf("description = %+v", d.Flowchart.Statements[2])
+	}
+}
+
+func TestParse_FlowchartInvalidLink(t *testing.T) {
+	d, diags := Parse("flowchart LR\n  A -.-.-> B\n  C --> D", 1)
+	want := Diagnostic{Message: `invalid link "-.-.->"`, Line: 2, Column: 5, EndLine: 2, EndColumn: 11}
+	if len(diags) == 0 || diags[0] != want {
+		t.Errorf("diagnostics = %+v, want %+v first", diags, want)
+	}
+	if len(d.Edges) != 1 || d.Edges[0].From != "C" {
+		t.Errorf("edges = %+v", d.Edges)
+	}
+}
+
+func TestParse_FlowchartArrowheadBeforeTarget(t *testing.T) {
+	d, diags := Parse("flowchart LR\n  A--xB\n  C--oD\n  E==xF", 1)
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", diags)
+	}
+	want := []struct{ from, to, end string }{{"A", "B", "cross"}, {"C", "D", "circle"}, {"E", "F", "cross"}}
+	if len(d.Edges) != len(want) {
+		t.Fatalf("edges = %+v", d.Edges)
+	}
+	for i, w := range want {
+		if e := d.Edges[i]; e.From != w.from || e.To != w.to || e.EndArrow != w.end {
+			t.Errorf("edge %d = %+v, want %s to %s with a %s end", i, e, w.from, w.to, w.end)
+		}
+	}
+}
+
+func TestParse_FlowchartInvalidAttrs(t *testing.T) {
+	_, diags := Parse("flowchart LR\n  A@{ shape: }\n  B@{ shape: rect", 1)
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", diags)
+	}
+	if diags[0].Line != 2 || !strings.HasPrefix(diags[0].Message, "invalid attributes: ") {
+		t.Errorf("diagnostic 0 = %+v", diags[0])
+	}
+	if diags[1].Message != `"@{" has no matching "}"` || diags[1].Line != 3 {
+		t.Errorf("diagnostic 1 = %+v", diags[1])
+	}
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiagramConfig is the configuration a diagram sets for itself, in YAML
+// frontmatter or in %%{init: ...}%% directives. Directives are applied
+// over the frontmatter config, in document order.
+type DiagramConfig struct {
+	Title          string                    // From the frontmatter
+	Theme          string                    // e.g. "default", "forest", "dark"
+	ThemeVariables map[string]any            // e.g. {"primaryColor": "#ff0000"}
+	Wrap           bool                      // Set by %%{wrap}%% or wrap: true
+	Diagrams       map[string]map[string]any // Per-diagram settings, keyed by section such as "flowchart"
+	Values         map[string]any            // All settings, as decoded from YAML and JSON
+	Directives     []Directive
+	Frontmatter    Range // Span of the frontmatter block, including its --- lines
+}
+
+// Directive is a %%{name: args}%% directive, such as %%{wrap}%% or
+// %%{init: {"theme": "dark"}}%%.
+type Directive struct {
+	Name  string
+	Args  string // Raw text after the colon, if any
+	Range Range
+}
+
+// config returns the diagram's config, creating it on first use.
+func (d *Diagram) config() *DiagramConfig {
+	if d.Config == nil {
+		d.Config = &DiagramConfig{Values: make(map[string]any)}
+	}
+	return d.Config
+}
+
+// parsePreamble reads the frontmatter and the directives, comments and
+// blank lines in front of the diagram type declaration. It returns the
+// index of the first line after them.
+func (d *Diagram) parsePreamble() int {
+	i := 0
+	for i < len(d.Lines) && strings.TrimSpace(d.Lines[i]) == "" {
+		i++
+	}
+	if i < len(d.Lines) && strings.TrimSpace(d.Lines[i]) == "---" {
+		i = d.parseFrontmatter(i)
+	}
+	for i < len(d.Lines) {
+		trimmed := strings.TrimSpace(d.Lines[i])
+		switch {
+		case strings.HasPrefix(trimmed, "%%{"):
+			i = d.parseDirective(i)
+		case trimmed == "" || strings.HasPrefix(trimmed, "%%"):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// parseFrontmatter reads the YAML block opened by the "---" at line
+// index start and returns the index of the line after its closing "---".
+func (d *Diagram) parseFrontmatter(start int) int {
+	end := start + 1
+	for end < len(d.Lines) && strings.TrimSpace(d.Lines[end]) != "---" {
+		end++
+	}
+	if end == len(d.Lines) {
+		d.addSpanDiagnostic(d.starts[start], d.starts[start]+len(d.Lines[start]),
+			`frontmatter is not closed with "---"`)
+		return end
+	}
+
+	cfg := d.config()
+	cfg.Frontmatter = d.span(d.starts[start], d.starts[end]+len(d.Lines[end]))
+
+	var lines []yamlLine
+	for i := start + 1; i < end; i++ {
+		text := strings.TrimRight(d.Lines[i], " \t\r")
+		content := strings.TrimLeft(text, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		offset := d.starts[i] + len(text) - len(content)
+		if strings.HasPrefix(content, "\t") {
+			d.addSpanDiagnostic(offset, offset+1, "frontmatter is indented with a tab")
+			return end + 1
+		}
+		lines = append(lines, yamlLine{indent: len(text) - len(content), text: content, offset: offset})
+	}
+
+	v, err := parseYAML(lines)
+	if err != nil {
+		d.configError(err, "frontmatter")
+		return end + 1
+	}
+	fm, ok := v.(map[string]any)
+	if !ok {
+		d.addSpanDiagnostic(lines[0].offset, lines[0].offset+len(lines[0].text), "frontmatter must be a mapping")
+		return end + 1
+	}
+
+	if title, ok := fm["title"]; ok && title != nil {
+		cfg.Title = fmt.Sprint(title)
+	}
+	switch c := fm["config"].(type) {
+	case map[string]any:
+		d.applyConfig(c)
+	case nil:
+	default:
+		d.addDiagnostic(d.StartLine+start, "frontmatter config must be a mapping")
+	}
+	return end + 1
+}
+
+// parseDirective reads the directive starting on line index i, which may
+// continue over several lines, and returns the index of the line after it.
+func (d *Diagram) parseDirective(i int) int {
+	start := d.starts[i] + strings.Index(d.Lines[i], "%%{")
+	closing := strings.Index(d.source[start:], "}%%")
+	if closing < 0 {
+		d.addSpanDiagnostic(start, d.starts[i]+len(d.Lines[i]), `directive is not closed with "}%%"`)
+		return i + 1
+	}
+	end := start + closing + len("}%%")
+	body := d.source[start+len("%%{") : start+closing]
+
+	name, args, _ := strings.Cut(body, ":")
+	dir := Directive{
+		Name:  strings.TrimSpace(name),
+		Args:  strings.TrimSpace(args),
+		Range: d.span(start, end),
+	}
+	cfg := d.config()
+	cfg.Directives = append(cfg.Directives, dir)
+
+	switch dir.Name {
+	case "wrap":
+		cfg.Wrap = true
+	case "init", "initialize":
+		argsAt := start + len("%%{") + len(name) + 1
+		v, err := parseRelaxedJSON(args, argsAt)
+		if err != nil {
+			d.configError(err, dir.Name+" directive")
+			break
+		}
+		values, ok := v.(map[string]any)
+		if !ok {
+			d.addSpanDiagnostic(start, end, "%s directive must be an object", dir.Name)
+			break
+		}
+		d.applyConfig(values)
+	}
+	return sort.SearchInts(d.starts, end) // The line after the one holding end
+}
+
+// parseBodyDirectives reads single-line directives after the diagram type
+// declaration.
+func (d *Diagram) parseBodyDirectives() {
+	for i := d.header + 1; i < len(d.Lines); i++ {
+		trimmed := strings.TrimSpace(d.Lines[i])
+		if strings.HasPrefix(trimmed, "%%{") && strings.HasSuffix(trimmed, "}%%") {
+			d.parseDirective(i)
+		}
+	}
+}
+
+// configError reports a malformed frontmatter or directive value.
+func (d *Diagram) configError(err *configError, what string) {
+	d.addSpanDiagnostic(err.offset, err.offset, "invalid %s: %s", what, err.msg)
+}
+
+// applyConfig merges values into the diagram's config and refreshes its
+// typed fields.
+func (d *Diagram) applyConfig(values map[string]any) {
+	cfg := d.config()
+	mergeConfig(cfg.Values, values)
+
+	if theme, ok := cfg.Values["theme"].(string); ok {
+		cfg.Theme = theme
+	}
+	if vars, ok := cfg.Values["themeVariables"].(map[string]any); ok {
+		cfg.ThemeVariables = vars
+	}
+	if wrap, ok := cfg.Values["wrap"].(bool); ok && wrap {
+		cfg.Wrap = true
+	}
+	for key, v := range cfg.Values {
+		section, ok := v.(map[string]any)
+		if !ok || key == "themeVariables" {
+			continue
+		}
+		if cfg.Diagrams == nil {
+			cfg.Diagrams = make(map[string]map[string]any)
+		}
+		cfg.Diagrams[key] = section
+	}
+}
+
+// mergeConfig copies src into dst, merging nested objects key by key.
+func mergeConfig(dst, src map[string]any) {
+	for key, v := range src {
+		if srcMap, ok := v.(map[string]any); ok {
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				mergeConfig(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[key] = v
+	}
+}
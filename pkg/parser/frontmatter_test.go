@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_Frontmatter(t *testing.T) {
+	source := "---\n" +
+		"title: Order flow # shown above the diagram\n" +
+		"config:\n" +
+		"  theme: forest\n" +
+		"  themeVariables:\n" +
+		"    primaryColor: \"#00ff00\"\n" +
+		"  flowchart:\n" +
+		"    curve: basis\n" +
+		"    padding: 15\n" +
+		"  gantt: {barHeight: 20, 'sections': [a, b]}\n" +
+		"---\n" +
+		"flowchart LR\n" +
+		"  A --> B"
+	d, diags := Parse(source, 1)
+
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if d.Type != DiagramFlowchart || d.TypeRange.Start.Line != 12 {
+		t.Errorf("type = %q at line %d", d.Type, d.TypeRange.Start.Line)
+	}
+	if len(d.Nodes) != 2 || d.Nodes[0].Line != 13 {
+		t.Errorf("nodes = %+v", d.Nodes)
+	}
+
+	cfg := d.Config
+	if cfg == nil {
+		t.Fatal("expected a config")
+	}
+	if cfg.Title != "Order flow" || cfg.Theme != "forest" {
+		t.Errorf("title = %q, theme = %q", cfg.Title, cfg.Theme)
+	}
+	if cfg.ThemeVariables["primaryColor"] != "#00ff00" {
+		t.Errorf("themeVariables = %v", cfg.ThemeVariables)
+	}
+	if fc := cfg.Diagrams["flowchart"]; fc["curve"] != "basis" || fc["padding"] != 15.0 {
+		t.Errorf("flowchart config = %v", fc)
+	}
+	if gantt := cfg.Diagrams["gantt"]; gantt["barHeight"] != 20.0 || len(gantt["sections"].([]any)) != 2 {
+		t.Errorf("gantt config = %v", gantt)
+	}
+	if cfg.Frontmatter.Start.Line != 1 || cfg.Frontmatter.End.Line != 11 {
+		t.Errorf("frontmatter range = %+v", cfg.Frontmatter)
+	}
+}
+
+func TestParse_Directives(t *testing.T) {
+	source := "---\n" +
+		"config:\n" +
+		"  theme: forest\n" +
+		"  flowchart:\n" +
+		"    curve: basis\n" +
+		"---\n" +
+		"%%{init: {'theme': 'dark', \"flowchart\": {htmlLabels: false}}}%%\n" +
+		"%%{\n" +
+		"  initialize: {\"themeVariables\": {\"fontSize\": \"18px\"}}\n" +
+		"}%%\n" +
+		"sequenceDiagram\n" +
+		"  %%{wrap}%%\n" +
+		"  Alice->>Bob: Hi"
+	d, diags := Parse(source, 1)
+
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if d.Type != DiagramSequence || len(d.Sequence.Messages) != 1 {
+		t.Fatalf("expected a sequence diagram with one message, got %q", d.Type)
+	}
+
+	cfg := d.Config
+	if cfg.Theme != "dark" {
+		t.Errorf("theme = %q, want the directive to override the frontmatter", cfg.Theme)
+	}
+	if fc := cfg.Diagrams["flowchart"]; fc["curve"] != "basis" || fc["htmlLabels"] != false {
+		t.Errorf("flowchart config = %v", fc)
+	}
+	if cfg.ThemeVariables["fontSize"] != "18px" || !cfg.Wrap {
+		t.Errorf("themeVariables = %v, wrap = %v", cfg.ThemeVariables, cfg.Wrap)
+	}
+	if len(cfg.Directives) != 3 {
+		t.Fatalf("expected 3 directives, got %+v", cfg.Directives)
+	}
+	if dir := cfg.Directives[1]; dir.Name != "initialize" || dir.Range.Start.Line != 8 || dir.Range.End.Line != 10 {
+		t.Errorf("directive 1 = %+v", dir)
+	}
+	if dir := cfg.Directives[2]; dir.Name != "wrap" || dir.Range.Start.Line != 12 || dir.Range.Start.Column != 3 {
+		t.Errorf("directive 2 = %+v", dir)
+	}
+}
+
+func TestParse_ConfigErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		line, col int
+		message   string
+	}{
+		{
+			name:    "yaml indentation",
+			source:  "---\nconfig:\n  theme: dark\n    look: neo\n---\nflowchart LR",
+			line:    4,
+			col:     5,
+			message: "invalid frontmatter: unexpected indentation",
+		},
+		{
+			name:    "yaml unterminated string",
+			source:  "---\ntitle: \"Oops\n---\nflowchart LR",
+			line:    2,
+			col:     8,
+			message: "invalid frontmatter: unterminated quoted string",
+		},
+		{
+			name:    "unclosed frontmatter",
+			source:  "---\ntitle: x\nflowchart LR",
+			line:    1,
+			col:     1,
+			message: "frontmatter is not closed",
+		},
+		{
+			name:    "json missing comma",
+			source:  "%%{init: {\"theme\": \"dark\" \"look\": \"neo\"}}%%\nflowchart LR",
+			line:    1,
+			col:     27,
+			message: "invalid init directive: expected ',' or '}'",
+		},
+		{
+			name:    "json bare value",
+			source:  "flowchart LR\n%%{init: {theme: dark}}%%",
+			line:    2,
+			col:     18,
+			message: `invalid init directive: unexpected "dark"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := Parse(tt.source, 1)
+			if len(diags) != 1 {
+				t.Fatalf("expected 1 diagnostic, got %+v", diags)
+			}
+			diag := diags[0]
+			if diag.Line != tt.line || diag.Column != tt.col || !strings.HasPrefix(diag.Message, tt.message) {
+				t.Errorf("diagnostic = %+v, want %d:%d %q", diag, tt.line, tt.col, tt.message)
+			}
+		})
+	}
+}
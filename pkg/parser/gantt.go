@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GanttChart is the model of a gantt chart.
+type GanttChart struct {
+	Title        string
+	DateFormat   string // dayjs format of task dates; YYYY-MM-DD when not declared
+	AxisFormat   string
+	TickInterval string
+	TodayMarker  string
+	Weekday      string
+	Excludes     []string // e.g., "weekends", "sunday", "2024-01-10"
+	Includes     []string
+	Sections     []GanttSection
+	Tasks        []GanttTask
+}
+
+// GanttSection is a section heading of a gantt chart.
+type GanttSection struct {
+	Name  string
+	Line  int
+	Range Range
+}
+
+// GanttTask is a task of a gantt chart, such as
+// "Design :crit, a1, after a0, 3d".
+type GanttTask struct {
+	Name    string
+	ID      string
+	Tags    []string // "done", "active", "crit" and "milestone"
+	Section string   // Enclosing section, empty before the first section
+	Start   string   // Start as written: a date, "after ...", or empty
+	End     string   // End as written: a date, a duration, "until ..."
+	After   []string // Task IDs from "after a b"
+	Until   []string // Task IDs from "until a b"
+
+	// StartDate is the start interpreted with the chart's DateFormat. It
+	// is zero when the start is relative, omitted or not a valid date.
+	StartDate time.Time
+	// EndDate is the end date, either written or the start plus the
+	// duration. It is zero when it cannot be determined.
+	EndDate time.Time
+	// Duration is the task length for durations in ms, s, m, h, d or w.
+	Duration time.Duration
+
+	Line  int
+	Range Range
+}
+
+// ganttTags are the task tags that may precede the other task fields.
+var ganttTags = map[string]bool{
+	"done": true, "active": true, "crit": true, "milestone": true,
+}
+
+// ganttDurationPattern matches task durations such as 3d or 1.5h.
+var ganttDurationPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(ms|s|m|h|d|w|M|y)$`)
+
+func (d *Diagram) parseGantt() {
+	g := &GanttChart{}
+	section := ""
+	d.forEachBodyLine(func(i int, line string) {
+		lineNum := d.StartLine + i
+		keyword, rest := splitKeyword(line)
+		switch keyword {
+		case "title":
+			g.Title = rest
+		case "dateFormat":
+			g.DateFormat = rest
+		case "axisFormat":
+			g.AxisFormat = rest
+		case "tickInterval":
+			g.TickInterval = rest
+		case "todayMarker":
+			g.TodayMarker = rest
+		case "weekday":
+			g.Weekday = rest
+		case "excludes":
+			g.Excludes = append(g.Excludes, splitList(rest)...)
+		case "includes":
+			g.Includes = append(g.Includes, splitList(rest)...)
+		case "section":
+			section = rest
+			g.Sections = append(g.Sections, GanttSection{Name: rest, Line: lineNum, Range: d.lineRange(lineNum)})
+		case "click", "inclusiveEndDates", "topAxis", "displayMode", "accTitle:", "accDescr:":
+			// Not part of the chart model.
+		default:
+			if task, ok := parseGanttTask(line, lineNum); ok {
+				task.Section, task.Range = section, d.lineRange(lineNum)
+				g.Tasks = append(g.Tasks, task)
+			}
+		}
+	})
+
+	// Mermaid applies dateFormat to every task, wherever it is declared.
+	for i := range g.Tasks {
+		g.resolveDates(&g.Tasks[i])
+	}
+	d.Gantt = g
+}
+
+// parseGanttTask parses "name : tags, id, start, end".
+func parseGanttTask(line string, lineNum int) (GanttTask, bool) {
+	name, meta, ok := strings.Cut(line, ":")
+	if !ok {
+		return GanttTask{}, false
+	}
+	task := GanttTask{Name: strings.TrimSpace(name), Line: lineNum}
+
+	fields := splitList(meta)
+	for len(fields) > 0 && ganttTags[fields[0]] {
+		task.Tags = append(task.Tags, fields[0])
+		fields = fields[1:]
+	}
+	switch len(fields) {
+	case 1:
+		task.End = fields[0]
+	case 2:
+		task.Start, task.End = fields[0], fields[1]
+	case 3:
+		task.ID, task.Start, task.End = fields[0], fields[1], fields[2]
+	}
+
+	if rest, ok := strings.CutPrefix(task.Start, "after "); ok {
+		task.After = strings.Fields(rest)
+	}
+	if rest, ok := strings.CutPrefix(task.End, "until "); ok {
+		task.Until = strings.Fields(rest)
+	}
+	return task, true
+}
+
+// resolveDates interprets the start and end of a task.
+func (g *GanttChart) resolveDates(t *GanttTask) {
+	if t.Start != "" && t.After == nil {
+		if date, err := g.ParseDate(t.Start); err == nil {
+			t.StartDate = date
+		}
+	}
+	if t.End == "" || t.Until != nil {
+		return
+	}
+	if m := ganttDurationPattern.FindStringSubmatch(t.End); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2] {
+		case "M", "y":
+			if !t.StartDate.IsZero() {
+				months := int(n)
+				if m[2] == "y" {
+					months *= 12
+				}
+				t.EndDate = t.StartDate.AddDate(0, months, 0)
+			}
+			return
+		}
+		t.Duration = time.Duration(n * float64(ganttUnits[m[2]]))
+		if !t.StartDate.IsZero() {
+			t.EndDate = t.StartDate.Add(t.Duration)
+		}
+		return
+	}
+	if date, err := g.ParseDate(t.End); err == nil {
+		t.EndDate = date
+	}
+}
+
+// ganttUnits gives the length of the fixed-length duration units.
+var ganttUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// ParseDate interprets a date written in the chart's DateFormat.
+func (g *GanttChart) ParseDate(s string) (time.Time, error) {
+	format := g.DateFormat
+	if format == "" {
+		format = "YYYY-MM-DD"
+	}
+	switch format {
+	case "x", "X":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+		}
+		if format == "X" {
+			return time.Unix(n, 0).UTC(), nil
+		}
+		return time.UnixMilli(n).UTC(), nil
+	}
+	return time.Parse(dayjsLayout(format), s)
+}
+
+// dayjsTokens maps dayjs format tokens to Go layout elements, longest
+// token first.
+var dayjsTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"DD", "02"}, {"D", "2"},
+	{"dddd", "Monday"}, {"ddd", "Mon"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"},
+	{"ss", "05"}, {"s", "5"},
+	{"SSS", "000"},
+	{"A", "PM"}, {"a", "pm"},
+	{"ZZ", "-0700"}, {"Z", "-07:00"},
+}
+
+// dayjsLayout converts a dayjs date format to a Go time layout. Text in
+// square brackets is copied literally.
+func dayjsLayout(format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				b.WriteString(format[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+		matched := false
+		for _, t := range dayjsTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				b.WriteString(t.layout)
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(format[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// splitList splits a comma-separated list and trims each item.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_GanttSettings(t *testing.T) {
+	source := "gantt\n" +
+		"  title Release plan\n" +
+		"  dateFormat YYYY-MM-DD\n" +
+		"  axisFormat %m/%d\n" +
+		"  excludes weekends, 2024-01-10\n" +
+		"  todayMarker off\n" +
+		"  section Build\n" +
+		"  Compile :a1, 2024-01-01, 3d\n" +
+		"  section Ship\n" +
+		"  Deploy :after a1, 1d"
+	d, _ := Parse(source, 1)
+
+	g := d.Gantt
+	if g == nil {
+		t.Fatal("expected a gantt model")
+	}
+	if g.Title != "Release plan" || g.DateFormat != "YYYY-MM-DD" || g.AxisFormat != "%m/%d" || g.TodayMarker != "off" {
+		t.Errorf("settings = %+v", g)
+	}
+	if len(g.Excludes) != 2 || g.Excludes[0] != "weekends" || g.Excludes[1] != "2024-01-10" {
+		t.Errorf("excludes = %v", g.Excludes)
+	}
+	if len(g.Sections) != 2 || g.Sections[1].Name != "Ship" || g.Sections[1].Line != 9 {
+		t.Errorf("sections = %+v", g.Sections)
+	}
+	if len(g.Tasks) != 2 || g.Tasks[0].Section != "Build" || g.Tasks[1].Section != "Ship" {
+		t.Errorf("tasks = %+v", g.Tasks)
+	}
+}
+
+func TestParse_GanttTasks(t *testing.T) {
+	source := "gantt\n" +
+		"  dateFormat YYYY-MM-DD\n" +
+		"  First  :done, crit, t1, 2024-03-01, 2024-03-05\n" +
+		"  Second :active, t2, after t1 t0, 2w\n" +
+		"  Third  :12h\n" +
+		"  Gate   :milestone, m1, 2024-03-20, 0d\n" +
+		"  Fourth :2024-04-01, until m1"
+	d, _ := Parse(source, 1)
+
+	tasks := d.Gantt.Tasks
+	if len(tasks) != 5 {
+		t.Fatalf("expected 5 tasks, got %d", len(tasks))
+	}
+
+	first := tasks[0]
+	if first.ID != "t1" || len(first.Tags) != 2 || first.Tags[0] != "done" || first.Tags[1] != "crit" {
+		t.Errorf("first = %+v", first)
+	}
+	if !first.StartDate.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) ||
+		!first.EndDate.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first dates = %v - %v", first.StartDate, first.EndDate)
+	}
+
+	second := tasks[1]
+	if len(second.After) != 2 || second.After[0] != "t1" || second.After[1] != "t0" {
+		t.Errorf("second after = %v", second.After)
+	}
+	if !second.StartDate.IsZero() || second.Duration != 14*24*time.Hour {
+		t.Errorf("second = %+v", second)
+	}
+
+	if third := tasks[2]; third.End != "12h" || third.Duration != 12*time.Hour || third.Start != "" {
+		t.Errorf("third = %+v", third)
+	}
+	if gate := tasks[3]; gate.Tags[0] != "milestone" || !gate.EndDate.Equal(gate.StartDate) {
+		t.Errorf("gate = %+v", gate)
+	}
+	if fourth := tasks[4]; len(fourth.Until) != 1 || fourth.Until[0] != "m1" {
+		t.Errorf("fourth = %+v", fourth)
+	}
+}
+
+func TestParse_GanttDateFormat(t *testing.T) {
+	source := "gantt\n" +
+		"  Task :a, 01/02/2024 10:30, 1d\n" +
+		"  Bad  :b, 2024-02-01, 1d\n" +
+		"  dateFormat DD/MM/YYYY HH:mm"
+	d, _ := Parse(source, 1)
+
+	want := time.Date(2024, 2, 1, 10, 30, 0, 0, time.UTC)
+	if got := d.Gantt.Tasks[0].StartDate; !got.Equal(want) {
+		t.Errorf("start = %v, want %v", got, want)
+	}
+	if got := d.Gantt.Tasks[1].StartDate; !got.IsZero() {
+		t.Errorf("expected a date not matching dateFormat to be rejected, got %v", got)
+	}
+}
+
+func TestGanttChart_ParseDateTimestamps(t *testing.T) {
+	g := &GanttChart{DateFormat: "X"}
+	got, err := g.ParseDate("86400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v", got)
+	}
+}
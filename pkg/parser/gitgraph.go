@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GitGraph is the model of a gitGraph. Commands are kept as written;
+// Branches and Commits are the history they produce when replayed, the
+// way Mermaid draws it.
+type GitGraph struct {
+	Direction string // "LR", "TB" or "BT", from "gitGraph TB:"
+	Commands  []GitCommand
+	Branches  []GitBranch
+	Commits   []GitCommit
+}
+
+// GitCommand is a single commit, branch, checkout, merge or cherry-pick
+// command.
+type GitCommand struct {
+	Kind   string // "commit", "branch", "checkout", "merge" or "cherry-pick"
+	Branch string // Branch created, checked out or merged
+	ID     string // id: of a commit, merge or cherry-pick
+	Tag    string
+	Type   string // "NORMAL", "REVERSE" or "HIGHLIGHT"
+	Order  int    // order: of a branch, -1 when not given
+	Parent string // parent: of a cherry-pick
+	On     string // Branch checked out when the command runs
+	Line   int
+	Range  Range
+}
+
+// GitBranch is a branch of a gitGraph. The main branch exists from the
+// start and has Line 0 and a zero Range.
+type GitBranch struct {
+	Name  string
+	Order int    // -1 when not given
+	From  string // Branch it was created from, empty for main
+	Base  string // Commit it was created at, empty if none
+	Line  int
+	Range Range // Span of the branch command
+}
+
+// GitCommit is a commit in the replayed history.
+type GitCommit struct {
+	ID         string
+	AutoID     bool // The ID was generated, not written
+	Branch     string
+	Tag        string
+	Type       string
+	Parents    []string
+	Merge      bool
+	CherryPick string // ID of the commit picked, for cherry-picks
+	Line       int
+	Range      Range // Span of the command that made the commit
+}
+
+// gitMainBranch is the branch a gitGraph starts on.
+const gitMainBranch = "main"
+
+// gitAttrPattern matches command attributes: id: "x", order: 2
+var gitAttrPattern = regexp.MustCompile(`(\w+)\s*:\s*("[^"]*"|\S+)`)
+
+// gitGraphParser replays gitGraph commands line by line.
+type gitGraphParser struct {
+	d       *Diagram
+	g       *GitGraph
+	current string
+	heads   map[string]string // branch -> ID of its last commit
+	known   map[string]bool   // branch names
+}
+
+func (d *Diagram) parseGitGraph() {
+	p := &gitGraphParser{
+		d:       d,
+		g:       &GitGraph{},
+		current: gitMainBranch,
+		heads:   make(map[string]string),
+		known:   map[string]bool{gitMainBranch: true},
+	}
+	p.g.Branches = append(p.g.Branches, GitBranch{Name: gitMainBranch, Order: -1})
+
+	_, header := splitKeyword(strings.TrimSpace(d.Lines[d.header]))
+	p.g.Direction = strings.TrimSuffix(header, ":")
+
+	d.forEachBodyLine(p.parseLine)
+	d.GitGraph = p.g
+}
+
+func (p *gitGraphParser) parseLine(i int, line string) {
+	kind, rest := splitKeyword(line)
+	if kind == "switch" {
+		kind = "checkout"
+	}
+
+	lineNum := p.d.StartLine + i
+	cmd := GitCommand{Kind: kind, Order: -1, On: p.current, Line: lineNum, Range: p.d.lineRange(lineNum)}
+	switch kind {
+	case "commit", "cherry-pick":
+	case "branch", "checkout", "merge":
+		name, attrs := splitKeyword(rest)
+		cmd.Branch = unquote(name)
+		rest = attrs
+	default:
+		return
+	}
+
+	for _, m := range gitAttrPattern.FindAllStringSubmatch(rest, -1) {
+		value := unquote(m[2])
+		switch m[1] {
+		case "id":
+			cmd.ID = value
+		case "tag":
+			cmd.Tag = value
+		case "type":
+			cmd.Type = value
+		case "parent":
+			cmd.Parent = value
+		case "order":
+			if n, err := strconv.Atoi(value); err == nil {
+				cmd.Order = n
+			}
+		}
+	}
+
+	p.g.Commands = append(p.g.Commands, cmd)
+	p.replay(cmd)
+}
+
+// replay applies a command to the simulated history. Commands that make
+// no sense, such as checking out an unknown branch, leave the history
+// unchanged; rules find them from the commands.
+func (p *gitGraphParser) replay(cmd GitCommand) {
+	switch cmd.Kind {
+	case "commit":
+		p.commit(cmd, nil)
+	case "branch":
+		if p.known[cmd.Branch] {
+			return
+		}
+		p.known[cmd.Branch] = true
+		p.g.Branches = append(p.g.Branches, GitBranch{
+			Name:  cmd.Branch,
+			Order: cmd.Order,
+			From:  p.current,
+			Base:  p.heads[p.current],
+			Line:  cmd.Line,
+			Range: cmd.Range,
+		})
+		p.heads[cmd.Branch] = p.heads[p.current]
+		p.current = cmd.Branch
+	case "checkout":
+		if p.known[cmd.Branch] {
+			p.current = cmd.Branch
+		}
+	case "merge":
+		if !p.known[cmd.Branch] || cmd.Branch == p.current {
+			return
+		}
+		c := p.commit(cmd, []string{p.heads[cmd.Branch]})
+		c.Merge = true
+	case "cherry-pick":
+		// The id: of a cherry-pick names the commit picked, not the new one.
+		pick := cmd
+		pick.ID = ""
+		c := p.commit(pick, nil)
+		c.CherryPick = cmd.ID
+	}
+}
+
+// commit adds a commit on the current branch, on top of its head and any
+// extra parents.
+func (p *gitGraphParser) commit(cmd GitCommand, extra []string) *GitCommit {
+	c := GitCommit{
+		ID:     cmd.ID,
+		Branch: p.current,
+		Tag:    cmd.Tag,
+		Type:   cmd.Type,
+		Line:   cmd.Line,
+		Range:  cmd.Range,
+	}
+	if c.ID == "" {
+		c.ID, c.AutoID = p.autoID(), true
+	}
+	if c.Type == "" {
+		c.Type = "NORMAL"
+	}
+	if head := p.heads[p.current]; head != "" {
+		c.Parents = append(c.Parents, head)
+	}
+	for _, parent := range extra {
+		if parent != "" {
+			c.Parents = append(c.Parents, parent)
+		}
+	}
+	p.heads[p.current] = c.ID
+	p.g.Commits = append(p.g.Commits, c)
+	return &p.g.Commits[len(p.g.Commits)-1]
+}
+
+// autoID generates an ID for a commit written without one.
+func (p *gitGraphParser) autoID() string {
+	return fmt.Sprintf("%d-auto", len(p.g.Commits))
+}
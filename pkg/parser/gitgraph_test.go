@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_GitGraphHistory(t *testing.T) {
+	source := "gitGraph TB:\n" +
+		"  commit id: \"A\"\n" +
+		"  branch develop order: 2\n" +
+		"  commit id: \"B\" tag: \"v0.1\" type: HIGHLIGHT\n" +
+		"  switch main\n" +
+		"  commit\n" +
+		"  merge develop id: \"M\"\n" +
+		"  cherry-pick id: \"B\""
+	d, _ := Parse(source, 1)
+
+	g := d.GitGraph
+	if g == nil {
+		t.Fatal("expected a gitGraph model")
+	}
+	if g.Direction != "TB" {
+		t.Errorf("direction = %q, want TB", g.Direction)
+	}
+	if len(g.Commands) != 7 {
+		t.Fatalf("expected 7 commands, got %d", len(g.Commands))
+	}
+	if cmd := g.Commands[3]; cmd.Kind != "checkout" || cmd.Branch != "main" || cmd.On != "develop" {
+		t.Errorf("switch command = %+v", cmd)
+	}
+
+	if len(g.Branches) != 2 {
+		t.Fatalf("expected 2 branches, got %d", len(g.Branches))
+	}
+	if b := g.Branches[1]; b.Name != "develop" || b.Order != 2 || b.From != "main" || b.Base != "A" || b.Line != 3 {
+		t.Errorf("develop = %+v", b)
+	}
+
+	if len(g.Commits) != 5 {
+		t.Fatalf("expected 5 commits, got %d", len(g.Commits))
+	}
+	b := g.Commits[1]
+	if b.ID != "B" || b.Branch != "develop" || b.Tag != "v0.1" || b.Type != "HIGHLIGHT" ||
+		len(b.Parents) != 1 || b.Parents[0] != "A" {
+		t.Errorf("commit B = %+v", b)
+	}
+	auto := g.Commits[2]
+	if !auto.AutoID || auto.Branch != "main" || auto.Parents[0] != "A" {
+		t.Errorf("auto commit = %+v", auto)
+	}
+	merge := g.Commits[3]
+	if !merge.Merge || merge.ID != "M" || len(merge.Parents) != 2 || merge.Parents[0] != auto.ID || merge.Parents[1] != "B" {
+		t.Errorf("merge = %+v", merge)
+	}
+	pick := g.Commits[4]
+	if pick.CherryPick != "B" || !pick.AutoID || pick.Parents[0] != "M" {
+		t.Errorf("cherry-pick = %+v", pick)
+	}
+}
+
+func TestParse_GitGraphInvalidCommands(t *testing.T) {
+	source := "gitGraph\n" +
+		"  commit\n" +
+		"  checkout nowhere\n" +
+		"  merge main\n" +
+		"  commit"
+	d, _ := Parse(source, 1)
+
+	g := d.GitGraph
+	if cmd := g.Commands[1]; cmd.Kind != "checkout" || cmd.Branch != "nowhere" {
+		t.Errorf("checkout command = %+v", cmd)
+	}
+	if cmd := g.Commands[2]; cmd.Branch != cmd.On {
+		t.Errorf("expected merge of the current branch to be visible, got %+v", cmd)
+	}
+	if len(g.Commits) != 2 {
+		t.Errorf("expected invalid commands to leave history unchanged, got %d commits", len(g.Commits))
+	}
+	if g.Commits[1].Branch != "main" {
+		t.Errorf("commit after a failed checkout should stay on main, got %q", g.Commits[1].Branch)
+	}
+}
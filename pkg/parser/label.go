@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LabelText is the label of a flowchart node or edge, both as written and
+// as Mermaid renders it.
+type LabelText struct {
+	Raw      string // Text between the delimiters, with any quotes and backticks
+	Display  string // Text as rendered: unquoted, entity codes decoded, markdown markers removed
+	Quoted   bool   // Written in double quotes
+	Markdown bool   // A markdown string, written as "`...`"
+}
+
+// labelError is an invalid entity code found at a byte offset of a raw
+// label.
+type labelError struct {
+	offset int
+	code   string
+}
+
+// entityPattern matches Mermaid's entity codes, such as "#quot;" and
+// "#9829;".
+var entityPattern = regexp.MustCompile(`#(\w+);`)
+
+// decodeLabel decodes a label as written between its delimiters. Entity
+// codes are decoded in every label; markdown emphasis is removed from
+// markdown strings only.
+func decodeLabel(raw string) (LabelText, []labelError) {
+	label := LabelText{Raw: raw, Display: raw}
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		label.Quoted = true
+		label.Display = raw[1 : len(raw)-1]
+		if inner := label.Display; len(inner) >= 2 && inner[0] == '`' && inner[len(inner)-1] == '`' {
+			label.Markdown = true
+			label.Display = stripMarkdown(inner[1 : len(inner)-1])
+		}
+	}
+
+	var errs []labelError
+	for _, m := range entityPattern.FindAllStringSubmatchIndex(raw, -1) {
+		if _, ok := decodeEntity(raw[m[2]:m[3]]); !ok {
+			errs = append(errs, labelError{offset: m[0], code: raw[m[0]:m[1]]})
+		}
+	}
+	label.Display = entityPattern.ReplaceAllStringFunc(label.Display, func(code string) string {
+		if text, ok := decodeEntity(code[1 : len(code)-1]); ok {
+			return text
+		}
+		return code
+	})
+	return label, errs
+}
+
+// decodeEntity returns the text of an HTML entity name or decimal code
+// point.
+func decodeEntity(name string) (string, bool) {
+	n, err := strconv.Atoi(name)
+	if err != nil {
+		// An unknown name is left as is, or has only a prefix decoded as
+		// a legacy entity without a semicolon, as "&ampx;" gives "&x;".
+		code := "&" + name + ";"
+		text := html.UnescapeString(code)
+		if text == code || strings.HasSuffix(text, name[len(name)-1:]+";") {
+			return "", false
+		}
+		return text, true
+	}
+	// Control characters other than whitespace are most likely an
+	// issue number followed by a semicolon.
+	if (n < 0x20 && n != '\t' && n != '\n' && n != '\r') || n == 0x7f || n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+		return "", false
+	}
+	return string(rune(n)), true
+}
+
+// markdownEmphasis matches the emphasis markers Mermaid renders in markdown
+// strings. Underscores only count at word boundaries, so that snake_case
+// stays intact.
+var markdownEmphasis = []*regexp.Regexp{
+	regexp.MustCompile(`\*\*(.+?)\*\*`),
+	regexp.MustCompile(`\b__(.+?)__\b`),
+	regexp.MustCompile(`\*(.+?)\*`),
+	regexp.MustCompile(`\b_(.+?)_\b`),
+}
+
+// stripMarkdown removes bold and italic markers from a markdown string.
+func stripMarkdown(s string) string {
+	for _, re := range markdownEmphasis {
+		s = re.ReplaceAllString(s, "$1")
+	}
+	return strings.TrimSpace(s)
+}
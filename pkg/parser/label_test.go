@@ -0,0 +1,66 @@
+package parser
+
+import "testing"
+
+func TestDecodeLabel(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want LabelText
+	}{
+		{"plain text", LabelText{Raw: "plain text", Display: "plain text"}},
+		{`"Text with (parens) and ]"`, LabelText{Raw: `"Text with (parens) and ]"`, Display: "Text with (parens) and ]", Quoted: true}},
+		{"\"`**bold** and *it* snake_case`\"", LabelText{Raw: "\"`**bold** and *it* snake_case`\"", Display: "bold and it snake_case", Quoted: true, Markdown: true}},
+		{"#quot;hi#quot; #9829;", LabelText{Raw: "#quot;hi#quot; #9829;", Display: `"hi" ♥`}},
+		{`"a #amp; b"`, LabelText{Raw: `"a #amp; b"`, Display: "a & b", Quoted: true}},
+		{"caf#eacute; #auml; #semi;", LabelText{Raw: "caf#eacute; #auml; #semi;", Display: "café ä ;"}},
+	}
+	for _, tt := range tests {
+		got, errs := decodeLabel(tt.raw)
+		if got != tt.want || len(errs) != 0 {
+			t.Errorf("decodeLabel(%q) = %+v, %v; want %+v", tt.raw, got, errs, tt.want)
+		}
+	}
+}
+
+func TestDecodeLabel_InvalidEntities(t *testing.T) {
+	got, errs := decodeLabel("#bogus; fix #12; #1114112; #65; #ampx;")
+	if got.Display != "#bogus; fix #12; #1114112; A #ampx;" {
+		t.Errorf("Display = %q", got.Display)
+	}
+	want := []labelError{{0, "#bogus;"}, {12, "#12;"}, {17, "#1114112;"}, {32, "#ampx;"}}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %+v, want %+v", errs, want)
+	}
+	for i, w := range want {
+		if errs[i] != w {
+			t.Errorf("error %d = %+v, want %+v", i, errs[i], w)
+		}
+	}
+}
+
+func TestParse_FlowchartLabelText(t *testing.T) {
+	source := "flowchart LR\n" +
+		"  A[\"`**Start** here`\"] -- \"go #rarr;\" --> B[#nope; end]\n" +
+		"  B -->|#hearts;| C@{ label: \"#quot;C#quot;\" }\n" +
+		"  C --> D[\"caf#eacute; #auml;\"] --> E@{ shape: rect, label: \"x #zap; y\" }"
+	d, diags := Parse(source, 1)
+
+	a := d.Nodes[0]
+	if a.Label != "Start here" || !a.LabelText.Markdown || !a.LabelText.Quoted || a.LabelText.Raw != "\"`**Start** here`\"" {
+		t.Errorf("node A = %+v", a)
+	}
+	if d.Nodes[1].Label != "#nope; end" || d.Nodes[2].Label != `"C"` || d.Nodes[3].Label != "café ä" {
+		t.Errorf("labels = %q, %q, %q", d.Nodes[1].Label, d.Nodes[2].Label, d.Nodes[3].Label)
+	}
+	if d.Edges[0].Label != "go →" || !d.Edges[0].LabelText.Quoted || d.Edges[1].Label != "♥" {
+		t.Errorf("edge labels = %+v, %+v", d.Edges[0].LabelText, d.Edges[1].LabelText)
+	}
+
+	want := []Diagnostic{
+		{Message: `invalid entity code "#nope;"`, Line: 2, Column: 46, EndLine: 2, EndColumn: 52},
+		{Message: `invalid entity code "#zap;"`, Line: 4, Column: 64, EndLine: 4, EndColumn: 69},
+	}
+	if len(diags) != len(want) || diags[0] != want[0] || diags[1] != want[1] {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
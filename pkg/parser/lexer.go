@@ -0,0 +1,489 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the kind of a flowchart token.
+type tokenKind int
+
+const (
+	tokEOF       tokenKind = iota
+	tokSeparator           // newline or ';'
+	tokKeyword             // statement keyword, e.g. "subgraph" or "style"
+	tokText                // unparsed arguments following a keyword
+	tokID                  // node identifier
+	tokShape               // bracketed node text, e.g. [label]
+	tokAttrs               // @{ ... } attribute block following a node or edge ID
+	tokClass               // :::className following a node
+	tokLinkID              // edge ID written in front of a link, e.g. e1@
+	tokLink                // edge operator, e.g. -->, with any text inside it
+	tokLinkLabel           // |label| following a link
+	tokAmp                 // &
+	tokIllegal             // input the lexer could not make sense of; value holds a syntax error, if any
+)
+
+// token is a single lexical element of a flowchart.
+type token struct {
+	kind  tokenKind
+	text  string // source text of the token
+	value string // label for tokShape/tokLinkLabel/tokLink, arguments for tokText, name for tokClass/tokLinkID, block for tokAttrs
+	shape string // shape name for tokShape
+	link  linkOp // decoded operator for tokLink
+	pos   int    // byte offset of the token in the source
+}
+
+// flowchartKeywords are the words that introduce a statement other than a
+// node or edge chain when they appear at the start of a statement.
+var flowchartKeywords = map[string]bool{
+	"flowchart": true,
+	"graph":     true,
+	"subgraph":  true,
+	"end":       true,
+	"direction": true,
+	"style":     true,
+	"classdef":  true,
+	"class":     true,
+	"click":     true,
+	"linkstyle": true,
+	"acctitle":  true,
+	"accdescr":  true,
+}
+
+// shapeDelimiter pairs the opening and closing brackets of a node shape.
+type shapeDelimiter struct {
+	open, close, shape string
+}
+
+// shapeDelimiters lists node shapes, longest opener first. Shapes sharing
+// an opener are told apart by whichever closer comes first.
+var shapeDelimiters = []shapeDelimiter{
+	{"(((", ")))", "double-circle"},
+	{"[[", "]]", "subroutine"},
+	{"[(", ")]", "cylinder"},
+	{"([", "])", "stadium"},
+	{"((", "))", "circle"},
+	{"{{", "}}", "hexagon"},
+	{"[/", "/]", "parallelogram"},
+	{"[/", `\]`, "trapezoid"},
+	{`[\`, `\]`, "parallelogram-alt"},
+	{`[\`, "/]", "trapezoid-alt"},
+	{"[", "]", "rect"},
+	{"(", ")", "round"},
+	{"{", "}", "rhombus"},
+	{">", "]", "asymmetric"},
+}
+
+// lexer splits flowchart source into tokens. Some decisions are
+// contextual: keywords are only recognized at the start of a statement,
+// shapes only directly after an identifier and link labels only after a
+// link.
+type lexer struct {
+	src       string
+	pos       int
+	tokens    []token
+	stmtStart bool
+}
+
+// lexFlowchart tokenizes flowchart source from byte offset start. The
+// returned slice always ends with a tokEOF token.
+func lexFlowchart(src string, start int) []token {
+	l := &lexer{src: src, pos: start, stmtStart: true}
+	for l.pos < len(l.src) {
+		l.lexToken()
+	}
+	l.emit(tokEOF, l.pos, "")
+	return l.tokens
+}
+
+func (l *lexer) emit(kind tokenKind, start int, value string) *token {
+	l.tokens = append(l.tokens, token{
+		kind:  kind,
+		text:  l.src[start:l.pos],
+		value: value,
+		pos:   start,
+	})
+	l.stmtStart = kind == tokSeparator
+	return &l.tokens[len(l.tokens)-1]
+}
+
+func (l *lexer) lastKind() tokenKind {
+	if len(l.tokens) == 0 {
+		return tokSeparator
+	}
+	return l.tokens[len(l.tokens)-1].kind
+}
+
+func (l *lexer) lexToken() {
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case c == ' ' || c == '\t' || c == '\r':
+		l.pos++
+	case c == '\n' || c == ';':
+		l.pos++
+		l.emit(tokSeparator, start, "")
+	case strings.HasPrefix(l.src[l.pos:], "%%"):
+		l.skipLine()
+	case c == '&':
+		l.pos++
+		l.emit(tokAmp, start, "")
+	case c == '|' && l.lastKind() == tokLink:
+		l.lexLinkLabel()
+	case strings.HasPrefix(l.src[l.pos:], ":::") && l.afterNode():
+		l.lexClass()
+	case l.atLink():
+		l.lexLink()
+	case isIDChar(l.peekRune()):
+		if l.stmtStart && l.lexKeyword() {
+			return
+		}
+		l.lexID()
+	default:
+		_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		l.pos += size
+		l.emit(tokIllegal, start, "")
+	}
+}
+
+func (l *lexer) peekRune() rune {
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return r
+}
+
+func (l *lexer) skipLine() {
+	if i := strings.IndexByte(l.src[l.pos:], '\n'); i >= 0 {
+		l.pos += i
+	} else {
+		l.pos = len(l.src)
+	}
+}
+
+// lexKeyword emits a keyword and its raw arguments if the word at the
+// current position is a statement keyword.
+func (l *lexer) lexKeyword() bool {
+	start := l.pos
+	end := start
+	for end < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[end:])
+		if !isIDChar(r) {
+			break
+		}
+		end += size
+	}
+	word := strings.ToLower(l.src[start:end])
+	if !flowchartKeywords[word] {
+		return false
+	}
+	accessible := word == "acctitle" || word == "accdescr"
+	if end < len(l.src) && !strings.ContainsRune(" \t\r\n;", rune(l.src[end])) &&
+		!(accessible && strings.ContainsRune(":{", rune(l.src[end]))) {
+		return false
+	}
+
+	l.pos = end
+	l.emit(tokKeyword, start, "")
+	if accessible {
+		l.lexAccessibility()
+		return true
+	}
+
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+	argStart := l.pos
+	quote := -1
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\n' || (c == ';' && quote < 0) {
+			break
+		}
+		if c == '"' {
+			if quote < 0 {
+				quote = l.pos
+			} else {
+				quote = -1
+			}
+		}
+		l.pos++
+	}
+	if args := strings.TrimSpace(l.src[argStart:l.pos]); args != "" {
+		l.emit(tokText, argStart, args)
+	}
+	if quote >= 0 {
+		l.tokens = append(l.tokens, token{
+			kind:  tokIllegal,
+			text:  l.src[quote:l.pos],
+			value: "unterminated quoted string",
+			pos:   quote,
+		})
+	}
+	return true
+}
+
+// lexAccessibility emits the text of an "accTitle: ..." or
+// "accDescr: ..." statement, which runs to the end of the line, or of an
+// "accDescr { ... }" block, which may span several lines.
+func (l *lexer) lexAccessibility() {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+	start := l.pos
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], ":"):
+		l.pos++
+		l.skipLine()
+		if text := strings.TrimSpace(l.src[start+1 : l.pos]); text != "" {
+			l.emit(tokText, start, text)
+		}
+	case strings.HasPrefix(l.src[l.pos:], "{"):
+		end := strings.IndexByte(l.src[l.pos:], '}')
+		if end < 0 {
+			l.skipLine()
+			l.emit(tokIllegal, start, `"{" has no matching "}"`)
+			return
+		}
+		l.pos += end + 1
+		l.emit(tokText, start, strings.TrimSpace(l.src[start+1:l.pos-1]))
+	}
+}
+
+// lexID emits an identifier, followed by a shape or attribute token when
+// a bracket or "@{" directly follows it. An identifier followed by "@" and
+// a link is an edge ID.
+func (l *lexer) lexID() {
+	start := l.pos
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if r == '-' && l.pos > start {
+			// A dash is part of the ID only between two ID characters,
+			// so that "A-->B" still yields A and a link.
+			next, _ := utf8.DecodeRuneInString(l.src[l.pos+size:])
+			if !isIDChar(next) {
+				break
+			}
+		} else if !isIDChar(r) {
+			break
+		}
+		l.pos += size
+	}
+	if rest := l.src[l.pos:]; strings.HasPrefix(rest, "@") && !strings.HasPrefix(rest, "@{") {
+		if _, _, n := matchLink(rest[1:], true); n != 0 {
+			id := l.src[start:l.pos]
+			l.pos++
+			l.emit(tokLinkID, start, id)
+			return
+		}
+	}
+	l.emit(tokID, start, "")
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "@{"):
+		l.lexAttrs()
+	case l.pos < len(l.src) && strings.ContainsRune("[({>", rune(l.src[l.pos])):
+		l.lexShape()
+	}
+}
+
+// lexClass emits the :::className shorthand that follows a node. Class
+// names may contain dashes.
+func (l *lexer) lexClass() {
+	start := l.pos
+	l.pos += len(":::")
+	nameStart := l.pos
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isIDChar(r) && r != '-' {
+			break
+		}
+		l.pos += size
+	}
+	if l.pos == nameStart {
+		l.emit(tokIllegal, start, `":::" must be followed by a class name`)
+		return
+	}
+	l.emit(tokClass, start, l.src[nameStart:l.pos])
+}
+
+// lexShape emits a tokShape for the bracketed node text at the current
+// position, trying each delimiter pair until one closes on this line.
+func (l *lexer) lexShape() {
+	start := l.pos
+	rest := l.src[start:]
+	var best *shapeDelimiter
+	var bestLabel string
+	var bestLen int
+	for i, delim := range shapeDelimiters {
+		if !strings.HasPrefix(rest, delim.open) {
+			continue
+		}
+		if best != nil && delim.open != best.open {
+			break // Only shorter openers are left
+		}
+		label, n, ok := scanLabel(rest[len(delim.open):], delim.close)
+		if !ok || (best != nil && n >= bestLen) {
+			continue
+		}
+		best, bestLabel, bestLen = &shapeDelimiters[i], label, n
+	}
+	if best != nil {
+		l.pos = start + len(best.open) + bestLen
+		tok := l.emit(tokShape, start, bestLabel)
+		tok.shape = best.shape
+		return
+	}
+
+	// No delimiter pair closes: the rest of the line is unusable.
+	line, _, _ := strings.Cut(rest, "\n")
+	msg := ""
+	for _, delim := range shapeDelimiters {
+		if strings.HasPrefix(line, delim.open) {
+			msg = unclosedMessage(line[len(delim.open):], delim.open, delim.close)
+			break
+		}
+	}
+	l.skipLine()
+	l.emit(tokIllegal, start, msg)
+}
+
+// unclosedMessage describes a label after open that close never ends.
+func unclosedMessage(label, open, close string) string {
+	label = strings.TrimLeft(label, " \t")
+	if strings.HasPrefix(label, `"`) && !strings.Contains(label[1:], `"`) {
+		return "unterminated quoted string"
+	}
+	return fmt.Sprintf("%q has no matching %q", open, close)
+}
+
+func (l *lexer) atLink() bool {
+	_, _, n := l.matchLink()
+	return n != 0
+}
+
+// matchLink matches a link at the current position. An "x" or "o"
+// arrowhead may only start a link that follows a node or an edge ID.
+func (l *lexer) matchLink() (linkOp, string, int) {
+	return matchLink(l.src[l.pos:], l.afterNode() || l.lastKind() == tokLinkID)
+}
+
+// afterNode reports whether the last token ends a node reference.
+func (l *lexer) afterNode() bool {
+	switch l.lastKind() {
+	case tokID, tokShape, tokAttrs, tokClass:
+		return true
+	}
+	return false
+}
+
+// lexLink emits the link operator at the current position, with any text
+// written inside it as the token value.
+func (l *lexer) lexLink() {
+	start := l.pos
+	op, text, n := l.matchLink()
+	if n < 0 {
+		l.skipLine()
+		l.emit(tokIllegal, start, fmt.Sprintf("link text after %q is not closed by a link", l.src[start:start+2]))
+		return
+	}
+	l.pos += n
+	// A link directly followed by more of one, as in "-.-.->", is not a
+	// longer link but two that Mermaid rejects.
+	if _, m := scanLinkLine(l.src[l.pos:], true); m > 0 {
+		for m > 0 {
+			l.pos += m
+			_, m = scanLinkLine(l.src[l.pos:], true)
+		}
+		l.emit(tokIllegal, start, fmt.Sprintf("invalid link %q", l.src[start:l.pos]))
+		return
+	}
+	tok := l.emit(tokLink, start, text)
+	tok.link = op
+}
+
+// lexAttrs emits the "@{ ... }" attribute block that follows a node or
+// edge ID. The block may span several lines.
+func (l *lexer) lexAttrs() {
+	start := l.pos
+	depth := 0
+	var quote byte
+	for i := start + 1; i < len(l.src); i++ {
+		c := l.src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				l.pos = i + 1
+				l.emit(tokAttrs, start, l.src[start+1:l.pos])
+				return
+			}
+		}
+	}
+	l.skipLine()
+	l.emit(tokIllegal, start, `"@{" has no matching "}"`)
+}
+
+// lexLinkLabel emits the |label| that follows a link.
+func (l *lexer) lexLinkLabel() {
+	start := l.pos
+	label, n, ok := scanLabel(l.src[start+1:], "|")
+	if !ok {
+		l.skipLine()
+		l.emit(tokIllegal, start, unclosedMessage(l.src[start+1:l.pos], "|", "|"))
+		return
+	}
+	l.pos = start + 1 + n
+	l.emit(tokLinkLabel, start, label)
+}
+
+// scanLabel reads label text up to and including the closing delimiter.
+// A label wrapped in double quotes may contain the delimiter; an unquoted
+// label ends at the first occurrence of it and may not span lines. It
+// returns the label as written, with any quotes but without surrounding
+// whitespace, the number of bytes consumed and whether the closing
+// delimiter was found.
+func scanLabel(s, closer string) (string, int, bool) {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	if i < len(s) && s[i] == '"' {
+		end := strings.IndexByte(s[i+1:], '"')
+		if end < 0 {
+			return "", 0, false
+		}
+		label := s[i : i+1+end+1]
+		j := i + 1 + end + 1
+		for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+			j++
+		}
+		if !strings.HasPrefix(s[j:], closer) {
+			return "", 0, false
+		}
+		return label, j + len(closer), true
+	}
+
+	end := strings.Index(s, closer)
+	if end < 0 {
+		return "", 0, false
+	}
+	if nl := strings.IndexByte(s[:end], '\n'); nl >= 0 {
+		return "", 0, false
+	}
+	return strings.TrimSpace(s[:end]), end + len(closer), true
+}
+
+func isIDChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestLexFlowchart_Kinds(t *testing.T) {
+	tokens := lexFlowchart("A[x] -->|y| B-1 & C", 0)
+
+	want := []tokenKind{tokID, tokShape, tokLink, tokLinkLabel, tokID, tokAmp, tokID, tokEOF}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(tokens), len(want), tokens)
+	}
+	for i, k := range want {
+		if tokens[i].kind != k {
+			t.Errorf("token %d kind = %d, want %d", i, tokens[i].kind, k)
+		}
+	}
+	if tokens[4].text != "B-1" {
+		t.Errorf("ID = %q, want %q", tokens[4].text, "B-1")
+	}
+}
+
+func TestLexFlowchart_LinkWithoutSpaces(t *testing.T) {
+	tokens := lexFlowchart("A-->B", 0)
+	if len(tokens) != 4 || tokens[0].text != "A" || tokens[1].text != "-->" || tokens[2].text != "B" {
+		t.Errorf("unexpected tokens: %+v", tokens)
+	}
+}
+
+func TestLexFlowchart_KeywordOnlyAtStatementStart(t *testing.T) {
+	tokens := lexFlowchart("style A fill:#f9f;A --> style", 0)
+
+	if tokens[0].kind != tokKeyword || tokens[1].kind != tokText || tokens[1].value != "A fill:#f9f" {
+		t.Errorf("unexpected keyword tokens: %+v", tokens[:2])
+	}
+	last := tokens[len(tokens)-2]
+	if last.kind != tokID || last.text != "style" {
+		t.Errorf("expected trailing ID %q, got %+v", "style", last)
+	}
+}
+
+func TestLexFlowchart_UnterminatedShape(t *testing.T) {
+	tokens := lexFlowchart("A[oops\nB", 0)
+	if tokens[1].kind != tokIllegal {
+		t.Errorf("expected illegal token, got %+v", tokens[1])
+	}
+	if tokens[3].kind != tokID || tokens[3].text != "B" {
+		t.Errorf("expected lexing to resume on the next line, got %+v", tokens[3])
+	}
+}
+
+func TestLexFlowchart_ClassShorthand(t *testing.T) {
+	tokens := lexFlowchart("A[x]:::hot-path --> B:::cold", 0)
+
+	want := []tokenKind{tokID, tokShape, tokClass, tokLink, tokID, tokClass, tokEOF}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(tokens), len(want), tokens)
+	}
+	for i, k := range want {
+		if tokens[i].kind != k {
+			t.Errorf("token %d kind = %d, want %d", i, tokens[i].kind, k)
+		}
+	}
+	if tokens[2].value != "hot-path" || tokens[5].value != "cold" {
+		t.Errorf("class names = %q, %q", tokens[2].value, tokens[5].value)
+	}
+}
+
+func TestLexFlowchart_EdgeIDAndAttrs(t *testing.T) {
+	tokens := lexFlowchart("A e1@--> B\ne1@{ animate: true }", 0)
+
+	want := []tokenKind{tokID, tokLinkID, tokLink, tokID, tokSeparator, tokID, tokAttrs, tokEOF}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(tokens), len(want), tokens)
+	}
+	for i, k := range want {
+		if tokens[i].kind != k {
+			t.Errorf("token %d kind = %d, want %d", i, tokens[i].kind, k)
+		}
+	}
+	if tokens[1].value != "e1" || tokens[1].text != "e1@" {
+		t.Errorf("edge ID token = %+v", tokens[1])
+	}
+	if tokens[6].value != "{ animate: true }" {
+		t.Errorf("attrs value = %q", tokens[6].value)
+	}
+}
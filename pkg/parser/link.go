@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// linkOp is a decoded flowchart link operator.
+type linkOp struct {
+	stroke     string // "normal", "thick", "dotted" or "invisible"
+	start, end string // arrowheads: "", "arrow", "cross" or "circle"
+	length     int    // 1 for "-->", 2 for "--->" and so on
+}
+
+// String returns the operator in canonical form, e.g. "<-->" or "-..->".
+func (op linkOp) String() string {
+	var b strings.Builder
+	if op.start != "" {
+		b.WriteByte(arrowheadChar(op.start, '<'))
+	}
+	switch op.stroke {
+	case "dotted":
+		b.WriteString("-" + strings.Repeat(".", op.length) + "-")
+	case "invisible":
+		b.WriteString(strings.Repeat("~", op.length+2))
+	default:
+		line := "-"
+		if op.stroke == "thick" {
+			line = "="
+		}
+		n := op.length + 1
+		if op.end == "" {
+			n++
+		}
+		b.WriteString(strings.Repeat(line, n))
+	}
+	if op.end != "" {
+		b.WriteByte(arrowheadChar(op.end, '>'))
+	}
+	return b.String()
+}
+
+// arrowheadChar returns the character drawing an arrowhead kind; arrow
+// is the one used for "arrow" at this end of the link.
+func arrowheadChar(kind string, arrow byte) byte {
+	switch kind {
+	case "cross":
+		return 'x'
+	case "circle":
+		return 'o'
+	}
+	return arrow
+}
+
+// linkTextOpeners are the operator halves that start link text, as in
+// "A -- text --> B", and the stroke of the link they start.
+var linkTextOpeners = []struct{ open, stroke string }{
+	{"--", "normal"},
+	{"==", "thick"},
+	{"-.", "dotted"},
+}
+
+// matchLink reads the link operator at the start of s, such as "-->",
+// "<-.->", "x==x" or "---->", including any text written inside it, as in
+// "-- text -->". startHead allows an "x" or "o" arrowhead at the start,
+// which could otherwise begin a node ID. It returns the operator, the text
+// as written and the number of bytes consumed. n is 0 if s does not start
+// with a link, and -1 if it starts link text that no operator on the line
+// closes.
+func matchLink(s string, startHead bool) (op linkOp, text string, n int) {
+	i := 0
+	start := ""
+	if s != "" && (s[0] == '<' || startHead) {
+		switch s[0] {
+		case '<':
+			start, i = "arrow", 1
+		case 'x':
+			start, i = "cross", 1
+		case 'o':
+			start, i = "circle", 1
+		}
+	}
+
+	if op, n := scanLinkLine(s[i:], false); n > 0 && (op.stroke != "invisible" || start == "") {
+		op.start = start
+		return op, "", i + n
+	}
+	// The shortest link with an "x" or "o" end needs no space before its
+	// target: Mermaid reads "--xB" as a link to B, not as link text.
+	if rest := s[i:]; len(rest) > 2 && (rest[:2] == "--" || rest[:2] == "==") && (rest[2] == 'x' || rest[2] == 'o') {
+		op := linkOp{stroke: "normal", start: start, end: arrowheadAt(rest[2:3]), length: 1}
+		if rest[0] == '=' {
+			op.stroke = "thick"
+		}
+		return op, "", i + 3
+	}
+
+	stroke := ""
+	for _, o := range linkTextOpeners {
+		if strings.HasPrefix(s[i:], o.open) {
+			stroke = o.stroke
+			break
+		}
+	}
+	if stroke == "" {
+		return linkOp{}, "", 0
+	}
+	textStart := i + 2
+	line, _, _ := strings.Cut(s, "\n")
+	for j := textStart; j < len(line); j++ {
+		if line[j] == '"' {
+			if end := strings.IndexByte(line[j+1:], '"'); end >= 0 {
+				j += end + 1
+				continue
+			}
+		}
+		op, n := scanLinkLine(line[j:], true)
+		if n == 0 || op.stroke != stroke {
+			continue
+		}
+		op.start = start
+		return op, strings.TrimSpace(line[textStart:j]), j + n
+	}
+	return linkOp{}, "", -1
+}
+
+// scanLinkLine reads the line of a link and its end arrowhead, leaving out
+// any start arrowhead. closing allows the shortened dotted form ".->" that
+// ends link text. It returns the number of bytes read, 0 if none.
+func scanLinkLine(s string, closing bool) (linkOp, int) {
+	var op linkOp
+	i := 0
+	switch {
+	case strings.HasPrefix(s, "-.") || closing && strings.HasPrefix(s, "."):
+		op.stroke = "dotted"
+		if s[0] == '-' {
+			i++
+		}
+		dots := i
+		for i < len(s) && s[i] == '.' {
+			i++
+		}
+		op.length = i - dots
+		if i == len(s) || s[i] != '-' {
+			return linkOp{}, 0
+		}
+		i++
+		if op.end = arrowheadAt(s[i:]); op.end != "" {
+			i++
+		}
+		return op, i
+
+	case strings.HasPrefix(s, "~~~"):
+		op.stroke = "invisible"
+		for i < len(s) && s[i] == '~' {
+			i++
+		}
+		op.length = i - 2
+		return op, i
+
+	case strings.HasPrefix(s, "--") || strings.HasPrefix(s, "=="):
+		op.stroke = "normal"
+		if s[0] == '=' {
+			op.stroke = "thick"
+		}
+		for i < len(s) && s[i] == s[0] {
+			i++
+		}
+		if op.end = arrowheadAt(s[i:]); op.end != "" {
+			op.length = i - 1
+			return op, i + 1
+		}
+		if i < 3 {
+			return linkOp{}, 0
+		}
+		op.length = i - 2
+		return op, i
+	}
+	return linkOp{}, 0
+}
+
+// arrowheadAt returns the kind of end arrowhead at the start of s, if any.
+// An "x" or "o" directly followed by an ID character begins a node ID
+// instead.
+func arrowheadAt(s string) string {
+	if s == "" {
+		return ""
+	}
+	switch s[0] {
+	case '>':
+		return "arrow"
+	case 'x', 'o':
+		next, _ := utf8.DecodeRuneInString(s[1:])
+		if isIDChar(next) {
+			return ""
+		}
+		if s[0] == 'x' {
+			return "cross"
+		}
+		return "circle"
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package parser
+
+import "testing"
+
+func TestMatchLink(t *testing.T) {
+	tests := []struct {
+		src       string
+		startHead bool
+		want      linkOp
+		text      string
+		n         int
+	}{
+		{"--> B", false, linkOp{"normal", "", "arrow", 1}, "", 3},
+		{"----> B", false, linkOp{"normal", "", "arrow", 3}, "", 5},
+		{"---- B", false, linkOp{"normal", "", "", 2}, "", 4},
+		{"===> B", false, linkOp{"thick", "", "arrow", 2}, "", 4},
+		{"-.- B", false, linkOp{"dotted", "", "", 1}, "", 3},
+		{"-..-> B", false, linkOp{"dotted", "", "arrow", 2}, "", 5},
+		{"<--> B", false, linkOp{"normal", "arrow", "arrow", 1}, "", 4},
+		{"o--o B", true, linkOp{"normal", "circle", "circle", 1}, "", 4},
+		{"x==x B", true, linkOp{"thick", "cross", "cross", 1}, "", 4},
+		{"~~~~ B", false, linkOp{"invisible", "", "", 2}, "", 4},
+		{"-- text --> B", false, linkOp{"normal", "", "arrow", 1}, "text", 11},
+		{`-. "a --> b" .-> B`, false, linkOp{"dotted", "", "arrow", 1}, `"a --> b"`, 16},
+		{"== text ===> B", false, linkOp{"thick", "", "arrow", 2}, "text", 12},
+		{"--oB", false, linkOp{"normal", "", "circle", 1}, "", 3},
+		{"==xB", false, linkOp{"thick", "", "cross", 1}, "", 3},
+		{"o--o B", false, linkOp{}, "", 0},
+		{"-x-> B", false, linkOp{}, "", 0},
+		{"-- text\n--> B", false, linkOp{}, "", -1},
+	}
+	for _, tt := range tests {
+		op, text, n := matchLink(tt.src, tt.startHead)
+		if op != tt.want || text != tt.text || n != tt.n {
+			t.Errorf("matchLink(%q) = %+v, %q, %d; want %+v, %q, %d", tt.src, op, text, n, tt.want, tt.text, tt.n)
+		}
+	}
+}
+
+func TestLinkOpString(t *testing.T) {
+	for _, s := range []string{"-->", "---", "---->", "==>", "===", "-.-", "-..->", "<-->", "o--o", "x==x", "~~~", "--x"} {
+		op, _, n := matchLink(s, true)
+		if n != len(s) || op.String() != s {
+			t.Errorf("round trip of %q = %q (n = %d)", s, op.String(), n)
+		}
+	}
+}
@@ -2,15 +2,21 @@ package parser
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 // MermaidBlock represents a mermaid code block found in a markdown file.
 type MermaidBlock struct {
-	Source    string // The mermaid source code (without fences)
-	StartLine int   // 1-based line number of the opening fence
-	EndLine   int   // 1-based line number of the closing fence
+	Source     string // The mermaid source code (without fences)
+	StartLine  int    // 1-based line number of the opening fence
+	EndLine    int    // 1-based line number of the closing fence
+	SourceLine int    // 1-based line number of the first source line, if not the line after StartLine
+	Indents    []int  // Characters removed in front of each source line
+	Info       string // Info string of the opening fence, e.g. "mermaid {.wide}"
+	Cell       int    // 1-based notebook cell holding the block, 0 outside notebooks
 }
 
 // ExtractMermaidBlocks extracts all mermaid code blocks from a markdown reader.
@@ -19,58 +25,323 @@ type MermaidBlock struct {
 //	```mermaid
 //	...
 //	```
-func ExtractMermaidBlocks(r io.Reader) ([]MermaidBlock, error) {
+//
+// Fences follow CommonMark: a block is closed by a fence of the same
+// character that is at least as long as the opening one, fences indented
+// four or more spaces are indented code, and the language is the first word
+// of the info string. Code blocks of other languages are skipped, together
+// with any mermaid fences written inside them.
+//
+// Blocks may be inside blockquotes and list items. The blockquote markers,
+// the list item indentation and the indentation of the opening fence are
+// removed from each line of the block, and recorded in Indents. A block
+// whose blockquote or list item ends before its closing fence ends with it.
+//
+// Mermaid fences that are never closed and blocks holding only whitespace
+// are not returned as blocks, but reported as diagnostics located at the
+// opening fence.
+func ExtractMermaidBlocks(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	e, err := scanMarkdown(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.blocks, e.diags, nil
+}
+
+// markdownCode returns the lines of the fenced and indented code blocks
+// of a markdown document, in which markup such as an HTML element or a
+// "::: mermaid" fence is only sample text.
+func markdownCode(text string) []lineSpan {
+	e, _ := scanMarkdown(strings.NewReader(text))
+	return e.code
+}
+
+// scanMarkdown reads a markdown document, collecting its mermaid blocks
+// and code blocks.
+func scanMarkdown(r io.Reader) (*fenceExtractor, error) {
 	scanner := bufio.NewScanner(r)
-	var blocks []MermaidBlock
-	var current *MermaidBlock
-	var lines []string
+	e := &fenceExtractor{}
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		if current == nil {
-			// Look for opening fence
-			if isMermaidFenceOpen(trimmed) {
-				current = &MermaidBlock{StartLine: lineNum}
-				lines = nil
-			}
-		} else {
-			// Inside a mermaid block, look for closing fence
-			if isClosingFence(trimmed) {
-				current.EndLine = lineNum
-				current.Source = strings.Join(lines, "\n")
-				blocks = append(blocks, *current)
-				current = nil
-				lines = nil
-			} else {
-				lines = append(lines, line)
-			}
+
+		if e.open != nil && e.inFence(lineNum, line) {
+			continue
 		}
+		e.outside(lineNum, line)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
 
-	return blocks, nil
+	if e.open != nil {
+		// An unclosed fence runs to the end of the document.
+		e.code = append(e.code, lineSpan{e.fenced.Line, lineNum})
+	}
+	if e.block != nil {
+		e.report("mermaid code block is not closed with %q", strings.Repeat(string(e.open.char), e.open.length))
+	}
+	return e, nil
 }
 
-func isMermaidFenceOpen(line string) bool {
-	// Match ```mermaid or ~~~mermaid (with optional whitespace)
-	if strings.HasPrefix(line, "```") {
-		lang := strings.TrimSpace(strings.TrimPrefix(line, "```"))
-		return strings.EqualFold(lang, "mermaid")
-	}
-	if strings.HasPrefix(line, "~~~") {
-		lang := strings.TrimSpace(strings.TrimPrefix(line, "~~~"))
-		return strings.EqualFold(lang, "mermaid")
+// lineSpan is a range of 1-based lines, from start to end inclusive.
+type lineSpan struct {
+	start, end int
+}
+
+// inSpans reports whether line is in one of spans.
+func inSpans(spans []lineSpan, line int) bool {
+	for _, s := range spans {
+		if line >= s.start && line <= s.end {
+			return true
+		}
 	}
 	return false
 }
 
-func isClosingFence(line string) bool {
-	return line == "```" || line == "~~~"
+// fence is the opening fence of a fenced code block.
+type fence struct {
+	char   byte   // '`' or '~'
+	length int    // Number of fence characters, at least three
+	indent int    // Columns of indentation in front of the fence
+	info   string // The trimmed text after the fence characters
+}
+
+// fenceExtractor collects the mermaid blocks of a markdown document, one
+// line at a time.
+type fenceExtractor struct {
+	blocks []MermaidBlock
+	diags  []Diagnostic
+	open   *fence        // The fenced code block being read, if any
+	fenced Diagnostic    // Span of the open fence, for reporting problems
+	block  *MermaidBlock // The block being read, if open is a mermaid fence
+	lines  []string
+	depth  int   // Blockquote depth of the open fence
+	base   int   // Columns of list item indentation of the open fence
+	quotes int   // Blockquote depth of the last line outside a fence
+	items  []int // Content columns of the list items enclosing that line
+	text   bool  // Whether that line continues a paragraph, which indented code cannot interrupt
+
+	code []lineSpan // Lines of the code blocks read so far, of any language
+}
+
+// outside reads a line that is not inside a fenced code block, and opens
+// one if the line holds an opening fence.
+func (e *fenceExtractor) outside(lineNum int, line string) {
+	quoted, quotes := stripBlockquote(line, -1)
+	if quotes != e.quotes {
+		e.quotes, e.items, e.text = quotes, nil, false
+	}
+	if strings.TrimSpace(quoted) == "" {
+		e.text = false
+		return
+	}
+
+	// A line indented less than a list item's content ends the item.
+	col := leadingColumns(quoted)
+	for len(e.items) > 0 && col < e.items[len(e.items)-1] {
+		e.items = e.items[:len(e.items)-1]
+	}
+	base := 0
+	if len(e.items) > 0 {
+		base = e.items[len(e.items)-1]
+	}
+	rest := stripColumns(quoted, base)
+	for {
+		spaces := len(rest) - len(strings.TrimLeft(rest, " "))
+		width, ok := listMarker(rest[spaces:])
+		if spaces > 3 || !ok {
+			break
+		}
+		base += spaces + width
+		e.items = append(e.items, base)
+		rest = rest[min(spaces+width, len(rest)):]
+	}
+
+	if leadingColumns(rest) >= 4 && !e.text {
+		e.code = append(e.code, lineSpan{lineNum, lineNum})
+		return
+	}
+	f, ok := parseFence(rest)
+	if !ok {
+		e.text = true
+		return
+	}
+	e.text = false
+	e.open, e.depth, e.base, e.lines = &f, quotes, base, nil
+	text := strings.TrimSpace(rest)
+	start := utf8.RuneCountInString(line[:len(line)-len(strings.TrimLeft(rest, " \t"))]) + 1
+	e.fenced = Diagnostic{Line: lineNum, Column: start, EndLine: lineNum, EndColumn: start + utf8.RuneCountInString(text)}
+	e.block = nil
+	if strings.EqualFold(fenceLanguage(f.info), "mermaid") {
+		e.block = &MermaidBlock{StartLine: lineNum, Info: f.info}
+	}
+}
+
+// inFence reads a line after an opening fence. It returns false if the
+// line ends the fence's blockquote or list item, leaving it to be read
+// again outside the fence.
+func (e *fenceExtractor) inFence(lineNum int, line string) bool {
+	quoted, quotes := stripBlockquote(line, e.depth)
+	blank := strings.TrimSpace(quoted) == ""
+	if quotes < e.depth || (!blank && leadingColumns(quoted) < e.base) {
+		e.close(lineNum - 1)
+		return false
+	}
+
+	content := stripColumns(quoted, e.base)
+	if e.open.closedBy(content) {
+		e.close(lineNum)
+		return true
+	}
+	if e.block != nil {
+		content = stripColumns(content, e.open.indent)
+		e.lines = append(e.lines, content)
+		e.block.Indents = append(e.block.Indents, utf8.RuneCountInString(line[:len(line)-len(content)]))
+	}
+	return true
+}
+
+// close ends the open fence at line endLine.
+func (e *fenceExtractor) close(endLine int) {
+	e.code = append(e.code, lineSpan{e.fenced.Line, endLine})
+	if e.block != nil {
+		e.block.EndLine = endLine
+		e.block.Source = strings.Join(e.lines, "\n")
+		if strings.TrimSpace(e.block.Source) == "" {
+			e.report("mermaid code block is empty")
+		} else {
+			e.blocks = append(e.blocks, *e.block)
+		}
+	}
+	e.open, e.block, e.lines = nil, nil, nil
+}
+
+// report records a problem with the open fence.
+func (e *fenceExtractor) report(format string, args ...any) {
+	diag := e.fenced
+	diag.Message = fmt.Sprintf(format, args...)
+	e.diags = append(e.diags, diag)
+}
+
+// parseFence reports whether line, with its container prefixes removed,
+// is an opening code fence.
+func parseFence(line string) (fence, bool) {
+	indent := leadingColumns(line)
+	rest := strings.TrimLeft(line, " \t")
+	if indent > 3 || rest == "" || (rest[0] != '`' && rest[0] != '~') {
+		return fence{}, false
+	}
+	n := len(rest) - len(strings.TrimLeft(rest, rest[:1]))
+	info := strings.TrimSpace(rest[n:])
+	if n < 3 || (rest[0] == '`' && strings.Contains(info, "`")) {
+		return fence{}, false
+	}
+	return fence{char: rest[0], length: n, indent: indent, info: info}, true
+}
+
+// closedBy reports whether line, with its container prefixes removed,
+// closes the fence.
+func (f *fence) closedBy(line string) bool {
+	rest := strings.TrimLeft(line, " \t")
+	n := len(rest) - len(strings.TrimLeft(rest, string(f.char)))
+	return leadingColumns(line) <= 3 && n >= f.length && strings.TrimSpace(rest[n:]) == ""
+}
+
+// fenceLanguage returns the language of a fence's info string, its first
+// word: "mermaid" for both "mermaid" and "mermaid {.wide}".
+func fenceLanguage(info string) string {
+	if i := strings.IndexAny(info, " \t{"); i >= 0 {
+		return info[:i]
+	}
+	return info
+}
+
+// listMarker returns the width of the list item marker at the start of s,
+// including the spaces that follow it: "- ", "* ", "+ ", "1. " or "1) ".
+// As in CommonMark, more than four spaces count as one, the rest being
+// indented code.
+func listMarker(s string) (int, bool) {
+	n := 0
+	switch {
+	case s == "":
+		return 0, false
+	case s[0] == '-' || s[0] == '*' || s[0] == '+':
+		n = 1
+	default:
+		for n < len(s) && n < 9 && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 || n == len(s) || (s[n] != '.' && s[n] != ')') {
+			return 0, false
+		}
+		n++
+	}
+	if n == len(s) {
+		return n + 1, true
+	}
+	spaces := len(s[n:]) - len(strings.TrimLeft(s[n:], " "))
+	switch {
+	case spaces == 0:
+		return 0, false
+	case spaces > 4:
+		spaces = 1
+	}
+	return n + spaces, true
+}
+
+// leadingColumns returns the width of the indentation of line, with tab
+// stops every four columns.
+func leadingColumns(line string) int {
+	col := 0
+	for _, c := range []byte(line) {
+		switch c {
+		case ' ':
+			col++
+		case '\t':
+			col += 4 - col%4
+		default:
+			return col
+		}
+	}
+	return col
+}
+
+// stripColumns removes up to n columns of indentation from line.
+func stripColumns(line string, n int) string {
+	col := 0
+	for col < n && line != "" {
+		switch line[0] {
+		case ' ':
+			col++
+		case '\t':
+			col += 4 - col%4
+		default:
+			return line
+		}
+		line = line[1:]
+	}
+	return line
+}
+
+// stripBlockquote removes up to limit blockquote markers ("> ") from the
+// start of line, or all of them if limit is negative. It returns the rest of
+// the line and the number of markers removed.
+func stripBlockquote(line string, limit int) (string, int) {
+	n := 0
+	for limit < 0 || n < limit {
+		rest := strings.TrimLeft(line, " ")
+		if len(line)-len(rest) > 3 || !strings.HasPrefix(rest, ">") {
+			break
+		}
+		line = rest[1:]
+		if strings.HasPrefix(line, " ") {
+			line = line[1:]
+		}
+		n++
+	}
+	return line, n
 }
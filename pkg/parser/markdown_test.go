@@ -1,13 +1,14 @@
 package parser
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
 
 func TestExtractMermaidBlocks_Single(t *testing.T) {
 	md := "# Title\n\nSome text\n\n```mermaid\nflowchart LR\n  A --> B\n```\n\nMore text\n"
-	blocks, err := ExtractMermaidBlocks(strings.NewReader(md))
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -27,7 +28,7 @@ func TestExtractMermaidBlocks_Single(t *testing.T) {
 
 func TestExtractMermaidBlocks_Multiple(t *testing.T) {
 	md := "```mermaid\ngraph TD\n  A --> B\n```\n\nText\n\n```mermaid\nsequenceDiagram\n  Alice->>Bob: Hi\n```\n"
-	blocks, err := ExtractMermaidBlocks(strings.NewReader(md))
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -38,7 +39,7 @@ func TestExtractMermaidBlocks_Multiple(t *testing.T) {
 
 func TestExtractMermaidBlocks_IgnoresNonMermaid(t *testing.T) {
 	md := "```python\nprint('hello')\n```\n\n```javascript\nconsole.log('hi')\n```\n"
-	blocks, err := ExtractMermaidBlocks(strings.NewReader(md))
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,7 +50,7 @@ func TestExtractMermaidBlocks_IgnoresNonMermaid(t *testing.T) {
 
 func TestExtractMermaidBlocks_TildeFence(t *testing.T) {
 	md := "~~~mermaid\nflowchart LR\n  A --> B\n~~~\n"
-	blocks, err := ExtractMermaidBlocks(strings.NewReader(md))
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -60,7 +61,7 @@ func TestExtractMermaidBlocks_TildeFence(t *testing.T) {
 
 func TestExtractMermaidBlocks_CaseInsensitive(t *testing.T) {
 	md := "```Mermaid\nflowchart LR\n  A --> B\n```\n"
-	blocks, err := ExtractMermaidBlocks(strings.NewReader(md))
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -70,7 +71,7 @@ func TestExtractMermaidBlocks_CaseInsensitive(t *testing.T) {
 }
 
 func TestExtractMermaidBlocks_Empty(t *testing.T) {
-	blocks, err := ExtractMermaidBlocks(strings.NewReader(""))
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(""))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -78,3 +79,225 @@ func TestExtractMermaidBlocks_Empty(t *testing.T) {
 		t.Fatalf("expected 0 blocks, got %d", len(blocks))
 	}
 }
+
+func TestExtractMermaidBlocks_BlockquoteAndIndent(t *testing.T) {
+	md := "> Note:\n" +
+		"> ```mermaid\n" +
+		"> flowchart LR\n" +
+		">   A --> B\n" +
+		"> ```\n" +
+		"\n" +
+		"  ```mermaid\n" +
+		"  graph TD\n" +
+		"    C --> D\n" +
+		"  ```\n"
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+
+	quoted := blocks[0]
+	if quoted.Source != "flowchart LR\n  A --> B" || quoted.EndLine != 5 {
+		t.Errorf("quoted block = %+v", quoted)
+	}
+	if len(quoted.Indents) != 2 || quoted.Indents[0] != 2 || quoted.Indents[1] != 2 {
+		t.Errorf("quoted indents = %v", quoted.Indents)
+	}
+
+	indented := blocks[1]
+	if indented.Source != "graph TD\n  C --> D" {
+		t.Errorf("indented source = %q", indented.Source)
+	}
+	if len(indented.Indents) != 2 || indented.Indents[1] != 2 {
+		t.Errorf("indented indents = %v", indented.Indents)
+	}
+}
+
+func TestExtractMermaidBlocks_FenceLength(t *testing.T) {
+	md := "````mermaid\n" +
+		"flowchart LR\n" +
+		"```\n" +
+		"  A --> B\n" +
+		"~~~~\n" +
+		"`````\n" +
+		"\n" +
+		"~~~mermaid\n" +
+		"graph TD\n" +
+		"```\n" +
+		"~~~\n"
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	if blocks[0].Source != "flowchart LR\n```\n  A --> B\n~~~~" || blocks[0].EndLine != 6 {
+		t.Errorf("backtick block = %+v", blocks[0])
+	}
+	if blocks[1].Source != "graph TD\n```" || blocks[1].EndLine != 11 {
+		t.Errorf("tilde block = %+v", blocks[1])
+	}
+}
+
+func TestExtractMermaidBlocks_InfoString(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"```mermaid", true},
+		{"```  mermaid  ", true},
+		{"```mermaid {.class title=\"x\"}", true},
+		{"```mermaid{.wide}", true},
+		{"~~~ MERMAID extra words", true},
+		{"```mermaid-js", false},
+		{"``` {.mermaid}", false},
+		{"```mermaid `x`", false},
+		{"``mermaid", false},
+	}
+	for _, tt := range tests {
+		md := tt.line + "\ngraph TD\n```\n~~~\n"
+		blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(blocks) == 1; got != tt.want {
+			t.Errorf("%q: found block = %v, want %v", tt.line, got, tt.want)
+			continue
+		}
+		if tt.want && blocks[0].Info != strings.TrimSpace(strings.TrimLeft(tt.line, "`~")) {
+			t.Errorf("%q: Info = %q", tt.line, blocks[0].Info)
+		}
+	}
+}
+
+func TestExtractMermaidBlocks_SkipsOtherCodeBlocks(t *testing.T) {
+	md := "````markdown\n" +
+		"```mermaid\n" +
+		"graph TD\n" +
+		"```\n" +
+		"````\n" +
+		"\n" +
+		"    ```mermaid\n" +
+		"    graph TD\n" +
+		"    ```\n"
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 0 {
+		t.Fatalf("expected 0 blocks, got %+v", blocks)
+	}
+}
+
+func TestExtractMermaidBlocks_ListItems(t *testing.T) {
+	md := "1. First step:\n" +
+		"\n" +
+		"   ```mermaid\n" +
+		"   flowchart LR\n" +
+		"\n" +
+		"     A --> B\n" +
+		"   ```\n" +
+		"2. Second\n" +
+		"   - Nested:\n" +
+		"     - ```mermaid\n" +
+		"       graph TD\n" +
+		"       ```\n"
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	if blocks[0].Source != "flowchart LR\n\n  A --> B" || blocks[0].StartLine != 3 || blocks[0].EndLine != 7 {
+		t.Errorf("list block = %+v", blocks[0])
+	}
+	if want := []int{3, 0, 3}; !reflect.DeepEqual(blocks[0].Indents, want) {
+		t.Errorf("list indents = %v, want %v", blocks[0].Indents, want)
+	}
+	if blocks[1].Source != "graph TD" || blocks[1].StartLine != 10 || blocks[1].Indents[0] != 7 {
+		t.Errorf("nested block = %+v", blocks[1])
+	}
+}
+
+func TestExtractMermaidBlocks_ContainerEndsBlock(t *testing.T) {
+	md := "> ```mermaid\n" +
+		"> graph TD\n" +
+		"after the quote\n" +
+		"- ```mermaid\n" +
+		"  flowchart LR\n" +
+		"not in the item\n" +
+		"```\n"
+	blocks, _, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	if blocks[0].Source != "graph TD" || blocks[0].EndLine != 2 {
+		t.Errorf("quoted block = %+v", blocks[0])
+	}
+	if blocks[1].Source != "flowchart LR" || blocks[1].EndLine != 5 {
+		t.Errorf("list block = %+v", blocks[1])
+	}
+}
+
+func TestExtractMermaidBlocks_Unclosed(t *testing.T) {
+	md := "```mermaid\n" +
+		"graph TD\n" +
+		"```\n" +
+		"\n" +
+		"> ````Mermaid {.wide}\n" +
+		"> flowchart LR\n" +
+		">   A --> B\n" +
+		"> ```\n"
+	blocks, diags, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].StartLine != 1 {
+		t.Errorf("expected the closed block only, got %+v", blocks)
+	}
+	want := []Diagnostic{{
+		Message:   "mermaid code block is not closed with \"````\"",
+		Line:      5,
+		Column:    3,
+		EndLine:   5,
+		EndColumn: 22,
+	}}
+	if !reflect.DeepEqual(diags, want) {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
+
+func TestExtractMermaidBlocks_EmptyBlocks(t *testing.T) {
+	md := "```mermaid\n" +
+		"```\n" +
+		"- ~~~mermaid\n" +
+		"\n" +
+		"  \t  \n" +
+		"  ~~~\n" +
+		"```python\n" +
+		"```\n"
+	blocks, diags, err := ExtractMermaidBlocks(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 0 {
+		t.Errorf("expected no blocks, got %+v", blocks)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", diags)
+	}
+	if d := diags[0]; d.Message != "mermaid code block is empty" || d.Line != 1 || d.Column != 1 || d.EndColumn != 11 {
+		t.Errorf("first diagnostic = %+v", d)
+	}
+	if d := diags[1]; d.Line != 3 || d.Column != 3 {
+		t.Errorf("second diagnostic = %+v", d)
+	}
+}
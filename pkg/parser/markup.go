@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// AsciiDocExtractor finds diagrams in AsciiDoc "[mermaid]" blocks,
+// delimited by "----" or "....", or written as a paragraph:
+//
+//	[mermaid, format=svg]
+//	----
+//	graph TD
+//	----
+type AsciiDocExtractor struct{}
+
+func (x *AsciiDocExtractor) Name() string { return "asciidoc" }
+
+// asciidocStyle matches the block attribute line of a mermaid block, such
+// as "[mermaid]", "[mermaid,target=x]" or "[source,mermaid]".
+var asciidocStyle = regexp.MustCompile(`(?i)^\[\s*(?:source\s*,\s*)?mermaid\s*(?:,[^\]]*)?\]\s*$`)
+
+// asciidocDelimiter matches the delimiter lines of listing and literal
+// blocks.
+var asciidocDelimiter = regexp.MustCompile(`^(?:-{4,}|\.{4,})$`)
+
+func (x *AsciiDocExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var found extracted
+	for i := 0; i < len(lines); i++ {
+		if !asciidocStyle.MatchString(lines[i]) {
+			continue
+		}
+		j := i + 1
+		for j < len(lines) && isAsciiDocTitle(lines[j]) {
+			j++
+		}
+
+		if j < len(lines) && asciidocDelimiter.MatchString(strings.TrimRight(lines[j], " \t")) {
+			delim := strings.TrimRight(lines[j], " \t")
+			end := j + 1
+			for end < len(lines) && strings.TrimRight(lines[end], " \t") != delim {
+				end++
+			}
+			if end == len(lines) {
+				found.unclosed(lines, i, delim)
+				break
+			}
+			found.add(lines, lineBlock(lines, i, j+1, end, end, nil))
+			i = end
+			continue
+		}
+
+		// Without delimiters, the block is the paragraph that follows.
+		end := j
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		found.add(lines, lineBlock(lines, i, j, end, end-1, nil))
+		i = end
+	}
+	return found.blocks, found.diags, nil
+}
+
+// isAsciiDocTitle reports whether line is a block title, such as
+// ".Login flow".
+func isAsciiDocTitle(line string) bool {
+	return len(line) > 1 && line[0] == '.' && line[1] != '.' && line[1] != ' '
+}
+
+// RSTExtractor finds diagrams in reStructuredText "mermaid" directives,
+// the diagram being the directive's indented body:
+//
+//	.. mermaid::
+//	   :caption: Login flow
+//
+//	   graph TD
+//	     A --> B
+//
+// Code blocks such as ".. code-block:: mermaid" are read the same way.
+// Directives that name a diagram file instead are skipped.
+type RSTExtractor struct{}
+
+func (x *RSTExtractor) Name() string { return "rst" }
+
+// rstDirective matches the first line of a directive and captures its name
+// and argument.
+var rstDirective = regexp.MustCompile(`^\s*\.\.\s+(mermaid|code-block|code|sourcecode)::\s*(.*?)\s*$`)
+
+func (x *RSTExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var found extracted
+	for i := 0; i < len(lines); i++ {
+		m := rstDirective.FindStringSubmatch(lines[i])
+		if m == nil || !isRSTMermaid(m[1], m[2]) {
+			continue
+		}
+
+		// The options, then blank lines, then the body; all of them are
+		// indented further than the directive.
+		indent := leadingColumns(lines[i])
+		inside := func(j int) bool {
+			return j < len(lines) && (strings.TrimSpace(lines[j]) == "" || leadingColumns(lines[j]) > indent)
+		}
+		first := i + 1
+		for inside(first) && strings.HasPrefix(strings.TrimSpace(lines[first]), ":") {
+			first++
+		}
+		for inside(first) && strings.TrimSpace(lines[first]) == "" {
+			first++
+		}
+		end := first
+		for inside(end) {
+			end++
+		}
+		for end > first && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		if end == first {
+			found.add(lines, MermaidBlock{StartLine: i + 1, EndLine: i + 1})
+			continue
+		}
+
+		body := leadingColumns(lines[first])
+		found.add(lines, lineBlock(lines, i, first, end, end-1, func(line string) string {
+			return stripColumns(line, body)
+		}))
+		i = end - 1
+	}
+	return found.blocks, found.diags, nil
+}
+
+// isRSTMermaid reports whether a directive holds a mermaid diagram. A
+// mermaid directive with an argument names a diagram file.
+func isRSTMermaid(name, arg string) bool {
+	if name == "mermaid" {
+		return arg == ""
+	}
+	return strings.EqualFold(arg, "mermaid")
+}
+
+// OrgExtractor finds diagrams in Org mode "#+begin_src mermaid" blocks.
+// Lines that Org escapes with a comma, such as ",* heading", are
+// unescaped.
+type OrgExtractor struct{}
+
+func (x *OrgExtractor) Name() string { return "org" }
+
+var (
+	orgBegin = regexp.MustCompile(`(?i)^[ \t]*#\+begin_src[ \t]+mermaid(?:[ \t]|$)`)
+	orgEnd   = regexp.MustCompile(`(?i)^[ \t]*#\+end_src[ \t]*$`)
+)
+
+func (x *OrgExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var found extracted
+	for i := 0; i < len(lines); i++ {
+		if !orgBegin.MatchString(lines[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !orgEnd.MatchString(lines[end]) {
+			end++
+		}
+		if end == len(lines) {
+			found.unclosed(lines, i, "#+end_src")
+			break
+		}
+
+		indent := leadingColumns(lines[i])
+		found.add(lines, lineBlock(lines, i, i+1, end, end, func(line string) string {
+			line = stripColumns(line, indent)
+			if strings.HasPrefix(line, ",*") || strings.HasPrefix(line, ",#+") {
+				line = line[1:]
+			}
+			return line
+		}))
+		i = end
+	}
+	return found.blocks, found.diags, nil
+}
+
+// readLines returns the lines of a document.
+func readLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// lineBlock returns the block whose source is lines[first:end], opened by
+// lines[open] and closed by lines[close]. strip, if set, removes the
+// indentation and prefixes in front of a line, returning the rest of it.
+func lineBlock(lines []string, open, first, end, close int, strip func(line string) string) MermaidBlock {
+	b := MermaidBlock{StartLine: open + 1, EndLine: close + 1, SourceLine: first + 1}
+	var source []string
+	for _, line := range lines[first:end] {
+		content := line
+		if strip != nil {
+			content = strip(line)
+		}
+		source = append(source, content)
+		b.Indents = append(b.Indents, utf8.RuneCountInString(line[:len(line)-len(content)]))
+	}
+	b.Source = strings.Join(source, "\n")
+	return b
+}
+
+// extracted collects the blocks and diagnostics of a line-based extractor.
+type extracted struct {
+	blocks []MermaidBlock
+	diags  []Diagnostic
+}
+
+// add records a block, or reports it if it is empty.
+func (x *extracted) add(lines []string, b MermaidBlock) {
+	if strings.TrimSpace(b.Source) == "" {
+		x.diags = append(x.diags, lineDiagnostic(lines, b.StartLine-1, "mermaid code block is empty"))
+		return
+	}
+	x.blocks = append(x.blocks, b)
+}
+
+// unclosed reports a block opened at lines[i] that is never closed.
+func (x *extracted) unclosed(lines []string, i int, closer string) {
+	x.diags = append(x.diags, lineDiagnostic(lines, i, "mermaid code block is not closed with %q", closer))
+}
+
+// lineDiagnostic returns a problem located at the text of lines[i].
+func lineDiagnostic(lines []string, i int, format string, args ...any) Diagnostic {
+	line := lines[i]
+	col := utf8.RuneCountInString(line[:len(line)-len(strings.TrimLeft(line, " \t"))]) + 1
+	return Diagnostic{
+		Message:   fmt.Sprintf(format, args...),
+		Line:      i + 1,
+		Column:    col,
+		EndLine:   i + 1,
+		EndColumn: col + utf8.RuneCountInString(strings.TrimSpace(line)),
+	}
+}
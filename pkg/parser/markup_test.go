@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAsciiDocExtractor(t *testing.T) {
+	doc := "= Title\n" +
+		"\n" +
+		".Login flow\n" +
+		"[mermaid, format=svg]\n" +
+		".Login flow\n" +
+		"----\n" +
+		"graph TD\n" +
+		"....\n" +
+		"----\n" +
+		"\n" +
+		"[source,mermaid]\n" +
+		"pie\n" +
+		"  \"A\" : 1\n" +
+		"\n" +
+		"[source,python]\n" +
+		"----\n" +
+		"print()\n" +
+		"----\n" +
+		"[mermaid]\n" +
+		"....\n" +
+		"graph LR\n"
+	blocks, diags, err := (&AsciiDocExtractor{}).Extract(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	if b := blocks[0]; b.Source != "graph TD\n...." || b.StartLine != 4 || b.SourceLine != 7 || b.EndLine != 9 {
+		t.Errorf("delimited block = %+v", b)
+	}
+	if b := blocks[1]; b.Source != "pie\n  \"A\" : 1" || b.StartLine != 11 || b.SourceLine != 12 || b.EndLine != 13 {
+		t.Errorf("paragraph block = %+v", b)
+	}
+	if len(diags) != 1 || diags[0].Message != "mermaid code block is not closed with \"....\"" || diags[0].Line != 19 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+}
+
+func TestRSTExtractor(t *testing.T) {
+	doc := "Title\n" +
+		"=====\n" +
+		"\n" +
+		"  .. mermaid::\n" +
+		"     :caption: Login flow\n" +
+		"     :align: center\n" +
+		"\n" +
+		"     graph TD\n" +
+		"\n" +
+		"       A --> B\n" +
+		"\n" +
+		"  Text after.\n" +
+		"\n" +
+		".. mermaid:: diagrams/flow.mmd\n" +
+		"\n" +
+		".. code-block:: mermaid\n" +
+		"\n" +
+		"\tpie\n" +
+		".. mermaid::\n" +
+		"\n" +
+		"Not a body.\n"
+	blocks, diags, err := (&RSTExtractor{}).Extract(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	b := blocks[0]
+	if b.Source != "graph TD\n\n  A --> B" || b.StartLine != 4 || b.SourceLine != 8 || b.EndLine != 10 {
+		t.Errorf("directive block = %+v", b)
+	}
+	if !reflect.DeepEqual(b.Indents, []int{5, 0, 5}) {
+		t.Errorf("indents = %v, want [5 0 5]", b.Indents)
+	}
+	if b := blocks[1]; b.Source != "pie" || b.SourceLine != 18 || b.Indents[0] != 1 {
+		t.Errorf("code block = %+v", b)
+	}
+	if len(diags) != 1 || diags[0].Message != "mermaid code block is empty" || diags[0].Line != 19 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+
+	d, _ := ParseBlock(b)
+	if len(d.Nodes) != 2 || d.Nodes[0].Range.Start.Line != 10 || d.Nodes[0].Range.Start.Column != 8 {
+		t.Errorf("nodes = %+v", d.Nodes)
+	}
+}
+
+func TestOrgExtractor(t *testing.T) {
+	doc := "* Heading\n" +
+		"  #+BEGIN_SRC mermaid :file flow.svg\n" +
+		"  graph TD\n" +
+		"  ,#+not a keyword\n" +
+		"  #+END_SRC\n" +
+		"#+begin_src mermaidx\n" +
+		"#+end_src\n" +
+		"#+begin_src mermaid\n" +
+		"pie\n"
+	blocks, diags, err := (&OrgExtractor{}).Extract(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 block, got %+v", blocks)
+	}
+	if b := blocks[0]; b.Source != "graph TD\n#+not a keyword" || b.StartLine != 2 || b.EndLine != 5 || !reflect.DeepEqual(b.Indents, []int{2, 3}) {
+		t.Errorf("block = %+v", b)
+	}
+	want := []Diagnostic{{
+		Message:   "mermaid code block is not closed with \"#+end_src\"",
+		Line:      8,
+		Column:    1,
+		EndLine:   8,
+		EndColumn: 20,
+	}}
+	if !reflect.DeepEqual(diags, want) {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Mindmap is the model of a mindmap. A valid mindmap has exactly one
+// root; any further top-level nodes are kept in Roots and reported as
+// diagnostics.
+type Mindmap struct {
+	Roots []*MindmapNode
+}
+
+// MindmapNode is a node of a mindmap, placed in the tree by its
+// indentation.
+type MindmapNode struct {
+	ID       string
+	Label    string
+	Shape    string // "default", "square", "rounded", "circle", "bang", "cloud" or "hexagon"
+	Icon     string // From a following ::icon(...) line
+	Classes  []string
+	Level    int // Depth in the tree, 0 for a root
+	Indent   int // Leading whitespace, in characters
+	Children []*MindmapNode
+	Line     int
+	Range    Range // Span of the node's line, without its icon and class lines
+}
+
+// mindmapShapes lists node delimiters, longest opener first.
+var mindmapShapes = []shapeDelimiter{
+	{"((", "))", "circle"},
+	{"))", "((", "bang"},
+	{"{{", "}}", "hexagon"},
+	{"[", "]", "square"},
+	{"(", ")", "rounded"},
+	{")", "(", "cloud"},
+}
+
+// mindmapParser builds the mindmap tree, keeping the path from the
+// current root to the last node read.
+type mindmapParser struct {
+	d    *Diagram
+	mm   *Mindmap
+	path []*MindmapNode
+	last *MindmapNode
+}
+
+func (d *Diagram) parseMindmap() {
+	p := &mindmapParser{d: d, mm: &Mindmap{}}
+	d.forEachBodyLine(p.parseLine)
+	d.Mindmap = p.mm
+}
+
+func (p *mindmapParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+
+	if icon, ok := strings.CutPrefix(line, "::icon("); ok {
+		if p.last != nil {
+			p.last.Icon = strings.TrimSuffix(icon, ")")
+		}
+		return
+	}
+	if classes, ok := strings.CutPrefix(line, ":::"); ok {
+		if p.last != nil {
+			p.last.Classes = append(p.last.Classes, strings.Fields(classes)...)
+		}
+		return
+	}
+
+	raw := p.d.Lines[i]
+	node := parseMindmapNode(line)
+	node.Indent = len(raw) - len(strings.TrimLeft(raw, " \t"))
+	node.Line, node.Range = lineNum, p.d.lineRange(lineNum)
+	p.last = node
+
+	for len(p.path) > 0 && p.path[len(p.path)-1].Indent >= node.Indent {
+		p.path = p.path[:len(p.path)-1]
+	}
+	if len(p.path) == 0 {
+		if len(p.mm.Roots) > 0 {
+			p.d.addDiagnostic(lineNum, "mindmap has more than one root: %q is not indented below %q",
+				node.Label, p.mm.Roots[0].Label)
+		}
+		p.mm.Roots = append(p.mm.Roots, node)
+		p.path = []*MindmapNode{node}
+		return
+	}
+
+	parent := p.path[len(p.path)-1]
+	if len(parent.Children) > 0 && parent.Children[0].Indent != node.Indent {
+		sibling := parent.Children[0]
+		p.d.addDiagnostic(lineNum, "inconsistent indentation: %q is indented %d, but its sibling %q at line %d is indented %d",
+			node.Label, node.Indent, sibling.Label, sibling.Line, sibling.Indent)
+	}
+	node.Level = parent.Level + 1
+	parent.Children = append(parent.Children, node)
+	p.path = append(p.path, node)
+}
+
+// parseMindmapNode parses "id[label]" and the other shapes. Text without
+// delimiters is both the ID and the label of a default node.
+func parseMindmapNode(text string) *MindmapNode {
+	node := &MindmapNode{ID: text, Label: text, Shape: "default"}
+
+	start := strings.IndexAny(text, "([{)")
+	if start < 0 {
+		return node
+	}
+	for _, delim := range mindmapShapes {
+		if !strings.HasPrefix(text[start:], delim.open) || !strings.HasSuffix(text, delim.close) {
+			continue
+		}
+		if len(text)-len(delim.close) < start+len(delim.open) {
+			continue
+		}
+		inner := text[start+len(delim.open) : len(text)-len(delim.close)]
+		node.ID = strings.TrimSpace(text[:start])
+		node.Label = unquote(strings.TrimSpace(inner))
+		node.Shape = delim.shape
+		if node.ID == "" {
+			node.ID = node.Label
+		}
+		return node
+	}
+	return node
+}
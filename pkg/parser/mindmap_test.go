@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_MindmapTree(t *testing.T) {
+	source := "mindmap\n" +
+		"  root((Mindmaps))\n" +
+		"    Origins\n" +
+		"      Long history\n" +
+		"      ::icon(fa fa-book)\n" +
+		"    Research\n" +
+		"      id1[Square]\n" +
+		"      :::urgent large\n" +
+		"      id2))Bang((\n" +
+		"      id3)Cloud(\n" +
+		"      id4{{Hexagon}}\n" +
+		"      id5(\"Quoted (text)\")"
+	d, _ := Parse(source, 1)
+
+	if d.Mindmap == nil || len(d.Mindmap.Roots) != 1 {
+		t.Fatalf("expected one root, got %+v", d.Mindmap)
+	}
+	if len(d.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d.Diagnostics)
+	}
+	root := d.Mindmap.Roots[0]
+	if root.ID != "root" || root.Label != "Mindmaps" || root.Shape != "circle" || root.Level != 0 {
+		t.Errorf("root = %+v", root)
+	}
+	if len(root.Children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(root.Children))
+	}
+
+	origins := root.Children[0]
+	if origins.Label != "Origins" || origins.Shape != "default" || origins.Level != 1 {
+		t.Errorf("origins = %+v", origins)
+	}
+	if history := origins.Children[0]; history.Icon != "fa fa-book" || history.Level != 2 || history.Line != 4 {
+		t.Errorf("history = %+v", history)
+	}
+
+	research := root.Children[1]
+	want := []struct{ id, label, shape string }{
+		{"id1", "Square", "square"},
+		{"id2", "Bang", "bang"},
+		{"id3", "Cloud", "cloud"},
+		{"id4", "Hexagon", "hexagon"},
+		{"id5", "Quoted (text)", "rounded"},
+	}
+	if len(research.Children) != len(want) {
+		t.Fatalf("expected %d research children, got %d", len(want), len(research.Children))
+	}
+	for i, w := range want {
+		n := research.Children[i]
+		if n.ID != w.id || n.Label != w.label || n.Shape != w.shape {
+			t.Errorf("child %d = %s/%s/%s, want %s/%s/%s", i, n.ID, n.Label, n.Shape, w.id, w.label, w.shape)
+		}
+	}
+	if classes := research.Children[0].Classes; len(classes) != 2 || classes[1] != "large" {
+		t.Errorf("classes = %v", classes)
+	}
+}
+
+func TestParse_MindmapMultipleRoots(t *testing.T) {
+	d, _ := Parse("mindmap\n  First\n    Child\n  Second", 10)
+
+	if len(d.Mindmap.Roots) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(d.Mindmap.Roots))
+	}
+	if len(d.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", d.Diagnostics)
+	}
+	if diag := d.Diagnostics[0]; diag.Line != 13 || !strings.Contains(diag.Message, "more than one root") {
+		t.Errorf("diagnostic = %+v", diag)
+	}
+}
+
+func TestParse_MindmapInconsistentIndentation(t *testing.T) {
+	d, _ := Parse("mindmap\n  Root\n    A\n      A1\n     B", 1)
+
+	if len(d.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", d.Diagnostics)
+	}
+	if diag := d.Diagnostics[0]; diag.Line != 5 || !strings.Contains(diag.Message, "inconsistent indentation") {
+		t.Errorf("diagnostic = %+v", diag)
+	}
+	// B is deeper than A, so it is still placed under A.
+	if a := d.Mindmap.Roots[0].Children[0]; len(a.Children) != 2 {
+		t.Errorf("expected A to have 2 children, got %d", len(a.Children))
+	}
+}
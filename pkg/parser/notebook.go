@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NotebookExtractor finds fenced "```mermaid" code blocks in the Markdown
+// cells of a Jupyter notebook. Blocks and diagnostics record the cell they
+// are in, and their line numbers count from the start of that cell.
+type NotebookExtractor struct{}
+
+func (x *NotebookExtractor) Name() string { return "notebook" }
+
+// notebook is the part of the Jupyter notebook format (nbformat 4) the
+// extractor reads.
+type notebook struct {
+	Cells []struct {
+		CellType string          `json:"cell_type"`
+		Source   json.RawMessage `json:"source"`
+	} `json:"cells"`
+}
+
+func (x *NotebookExtractor) Extract(r io.Reader) ([]MermaidBlock, []Diagnostic, error) {
+	var nb notebook
+	if err := json.NewDecoder(r).Decode(&nb); err != nil {
+		return nil, nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	var blocks []MermaidBlock
+	var diags []Diagnostic
+	for i, cell := range nb.Cells {
+		if cell.CellType != "markdown" {
+			continue
+		}
+		source, err := cellSource(cell.Source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid notebook: cell %d: %w", i+1, err)
+		}
+		b, d, err := ExtractMermaidBlocks(strings.NewReader(source))
+		if err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j].Cell = i + 1
+		}
+		for j := range d {
+			d[j].Cell = i + 1
+		}
+		blocks, diags = append(blocks, b...), append(diags, d...)
+	}
+	return blocks, diags, nil
+}
+
+// cellSource returns the text of a cell, which notebooks store either as a
+// string or as a list of lines that keep their newlines.
+func cellSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		return strings.Join(lines, ""), nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", fmt.Errorf("source is neither a string nor a list of strings")
+	}
+	return text, nil
+}
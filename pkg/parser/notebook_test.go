@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Flow\n", "\n", "` + "```mermaid" + `\n", "graph TD\n", "  A --> B\n", "` + "```" + `"]},
+  {"cell_type": "code", "metadata": {}, "source": ["print('` + "```mermaid" + `')"], "outputs": []},
+  {"cell_type": "markdown", "metadata": {}, "source": "Text\n` + "```mermaid" + `\npie\n"}
+ ],
+ "metadata": {},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+func TestNotebookExtractor(t *testing.T) {
+	blocks, diags, err := (&NotebookExtractor{}).Extract(strings.NewReader(testNotebook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 block, got %+v", blocks)
+	}
+	if b := blocks[0]; b.Cell != 1 || b.StartLine != 3 || b.EndLine != 6 || b.Source != "graph TD\n  A --> B" {
+		t.Errorf("block = %+v", b)
+	}
+	if len(diags) != 1 || diags[0].Cell != 3 || diags[0].Line != 2 || diags[0].Message != "mermaid code block is not closed with \"```\"" {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+
+	d, _ := ParseBlock(blocks[0])
+	if d.TypeRange.Start.Line != 4 {
+		t.Errorf("type line = %d, want 4", d.TypeRange.Start.Line)
+	}
+}
+
+func TestNotebookExtractor_Invalid(t *testing.T) {
+	for _, src := range []string{
+		"{not json",
+		`{"cells": [{"cell_type": "markdown", "source": 42}]}`,
+	} {
+		if _, _, err := (&NotebookExtractor{}).Extract(strings.NewReader(src)); err == nil || !strings.HasPrefix(err.Error(), "invalid notebook: ") {
+			t.Errorf("%s: err = %v", src, err)
+		}
+	}
+}
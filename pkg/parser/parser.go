@@ -2,7 +2,6 @@
 package parser
 
 import (
-	"regexp"
 	"strings"
 )
 
@@ -68,52 +67,168 @@ var ValidFlowchartDirections = map[string]bool{
 
 // Node represents a node in a Mermaid diagram.
 type Node struct {
-	ID    string
-	Label string
-	Line  int
-	Shape string // e.g., "round", "stadium", "rect", "rhombus", "circle", etc.
+	ID        string
+	Label     string    // LabelText.Display
+	LabelText LabelText // The label as written and as rendered
+	Line      int
+	Shape     string          // e.g., "round", "stadium", "rect", "rhombus", "circle", "icon", etc.
+	Subgraph  string          // ID of the enclosing subgraph, empty at the top level
+	Attrs     map[string]any  // From "@{ ... }", e.g. {"shape": "cyl", "icon": "fa:user"}
+	Classes   []string        // From "class" statements and ":::", in order
+	Styles    []StyleProperty // From "style" statements
+	Range     Range           // Span of the first mention
 }
 
 // Edge represents a connection between nodes.
 type Edge struct {
-	From  string
-	To    string
-	Label string
-	Line  int
-	Style string // e.g., "-->", "---", "-.->", "==>"
+	ID         string // From "id@-->", empty if the edge has none
+	From       string
+	To         string
+	Label      string    // LabelText.Display
+	LabelText  LabelText // The label as written and as rendered
+	Line       int
+	Style      string         // Canonical operator, e.g., "-->", "---", "-.->", "==>", "<-->"
+	Stroke     string         // "normal", "thick", "dotted" or "invisible"
+	StartArrow string         // Arrowhead at From: "", "arrow", "cross" or "circle"
+	EndArrow   string         // Arrowhead at To, as for StartArrow
+	Length     int            // 1 for "-->", 2 for "--->" and so on
+	Attrs      map[string]any // From an "id@{ ... }" statement, e.g. {"animate": true}
+	Range      Range          // From the start of the source node to the end of the target
+}
+
+// Subgraph represents a subgraph block in a flowchart.
+type Subgraph struct {
+	ID        string
+	Title     string
+	Direction string   // Direction set inside the subgraph, if any
+	Parent    string   // ID of the enclosing subgraph, empty at the top level
+	Nodes     []string // IDs of the nodes directly inside this subgraph
+	Line      int      // Line of the subgraph keyword
+	EndLine   int      // Line of the closing "end", 0 if unclosed
+	Range     Range    // From the subgraph keyword to the end of its block
 }
 
 // Diagram represents a parsed Mermaid diagram.
 type Diagram struct {
-	Type      DiagramType
-	TypeRaw   string // The raw type string as written
-	Direction string // For flowcharts: TB, TD, BT, LR, RL
-	Nodes     []Node
-	Edges     []Edge
-	Lines     []string // Original source lines
-	StartLine int      // Starting line in the original file (1-based)
+	Type           DiagramType
+	TypeRaw        string         // The raw type string as written
+	TypeRange      Range          // Span of the type keyword, empty where it is missing
+	Config         *DiagramConfig // From frontmatter and directives, nil if there are none
+	Direction      string         // For flowcharts: TB, TD, BT, LR, RL
+	DirectionRange Range          // Span of Direction, if any
+	Nodes          []Node
+	Edges          []Edge
+	Lines          []string // Original source lines
+	StartLine      int      // Starting line in the original file (1-based)
+
+	Flowchart        *Flowchart          // Syntax tree, for flowchart and graph diagrams
+	Subgraphs        []Subgraph          // Subgraphs of a flowchart, in document order
+	ClassDefs        []ClassDef          // classDef statements of a flowchart
+	ClassAssignments []ClassAssignment   // class statements and ":::" shorthands
+	LinkStyles       []LinkStyle         // linkStyle statements
+	Sequence         *SequenceDiagram    // Model of a sequenceDiagram
+	Class            *ClassDiagram       // Model of a classDiagram
+	State            *StateDiagram       // Model of a stateDiagram or stateDiagram-v2
+	ER               *ERDiagram          // Model of an erDiagram
+	Gantt            *GanttChart         // Model of a gantt chart
+	Pie              *PieChart           // Model of a pie chart
+	Quadrant         *QuadrantChart      // Model of a quadrantChart
+	XYChart          *XYChart            // Model of an xychart-beta chart
+	GitGraph         *GitGraph           // Model of a gitGraph
+	Mindmap          *Mindmap            // Model of a mindmap
+	Timeline         *Timeline           // Model of a timeline
+	C4               *C4Diagram          // Model of a C4 diagram
+	Requirement      *RequirementDiagram // Model of a requirementDiagram
+	Sankey           *SankeyDiagram      // Model of a sankey-beta diagram
+	Block            *BlockDiagram       // Model of a block-beta diagram
+
+	Diagnostics []Diagnostic // Problems found while parsing
+
+	header  int    // Index in Lines of the diagram type declaration
+	source  string // The source passed to Parse
+	starts  []int  // Byte offset of each line in the source
+	indents []int  // Characters removed in front of each line, if extracted
 }
 
 // Parse parses a Mermaid diagram source string into a Diagram.
 // startLine is the 1-based line number where this diagram starts in the file.
-func Parse(source string, startLine int) *Diagram {
+// Parse always returns a Diagram, built from whatever parts of the source
+// could be read; the problems it found are returned as diagnostics, which
+// are also kept in Diagram.Diagnostics.
+func Parse(source string, startLine int) (*Diagram, []Diagnostic) {
+	return parse(source, startLine, nil)
+}
+
+// ParseBlock parses a diagram extracted from a larger file. Its lines are
+// numbered from b.SourceLine, or the line after the opening fence if that
+// is not set, and its columns account for the indentation and prefixes
+// removed from each line.
+func ParseBlock(b MermaidBlock) (*Diagram, []Diagnostic) {
+	line := b.SourceLine
+	if line == 0 {
+		line = b.StartLine + 1
+	}
+	return parse(b.Source, line, b.Indents)
+}
+
+func parse(source string, startLine int, indents []int) (*Diagram, []Diagnostic) {
 	lines := strings.Split(source, "\n")
 	d := &Diagram{
 		Lines:     lines,
 		StartLine: startLine,
+		source:    source,
+		starts:    lineStarts(source),
+		indents:   indents,
 	}
 
-	d.parseType(lines)
+	d.parseType(lines, d.parsePreamble())
+	if d.TypeRaw != "" {
+		d.parseBodyDirectives()
+	}
 
-	if d.Type == DiagramFlowchart || d.Type == DiagramGraph {
-		d.parseFlowchart(lines)
+	switch d.Type {
+	case DiagramFlowchart, DiagramGraph:
+		d.parseFlowchart()
+	case DiagramSequence:
+		d.parseSequence()
+	case DiagramClass:
+		d.parseClass()
+	case DiagramState, DiagramStateV2:
+		d.parseState()
+	case DiagramER:
+		d.parseER()
+	case DiagramGantt:
+		d.parseGantt()
+	case DiagramPie:
+		d.parsePie()
+	case DiagramQuadrant:
+		d.parseQuadrant()
+	case DiagramXYChart:
+		d.parseXYChart()
+	case DiagramGitGraph:
+		d.parseGitGraph()
+	case DiagramMindmap:
+		d.parseMindmap()
+	case DiagramTimeline:
+		d.parseTimeline()
+	case DiagramC4Context, DiagramC4Container, DiagramC4Component, DiagramC4Dynamic, DiagramC4Deployment:
+		d.parseC4()
+	case DiagramRequirement:
+		d.parseRequirement()
+	case DiagramSankey:
+		d.parseSankey()
+	case DiagramBlock:
+		d.parseBlock()
 	}
 
-	return d
+	return d, d.Diagnostics
 }
 
-func (d *Diagram) parseType(lines []string) {
-	for _, line := range lines {
+// parseType finds the diagram type declaration, the first line from index
+// start that is neither blank nor a comment.
+func (d *Diagram) parseType(lines []string, start int) {
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
@@ -127,6 +242,9 @@ func (d *Diagram) parseType(lines []string) {
 
 		keyword := parts[0]
 		d.TypeRaw = keyword
+		d.header = i
+		start := d.starts[i] + strings.Index(line, keyword)
+		d.TypeRange = d.span(start, start+len(keyword))
 
 		normalized := strings.ToLower(keyword)
 		if dt, ok := KnownDiagramTypes[normalized]; ok {
@@ -134,166 +252,26 @@ func (d *Diagram) parseType(lines []string) {
 		} else {
 			d.Type = DiagramUnknown
 		}
-
-		// For flowchart/graph, extract direction
-		if (d.Type == DiagramFlowchart || d.Type == DiagramGraph) && len(parts) > 1 {
-			d.Direction = parts[1]
-		}
 		return
 	}
-}
-
-var (
-	// Match node definitions: A, A[label], A(label), A{label}, A((label)), A>label], A[/label/], etc.
-	nodeDefPattern = regexp.MustCompile(
-		`(?:^|[\s;])([A-Za-z_][A-Za-z0-9_]*)` +
-			`(?:` +
-			`\[([^\]]*)\]` + // [label] rectangle
-			`|\(([^)]*)\)` + // (label) rounded
-			`|\{([^}]*)\}` + // {label} rhombus
-			`|\[\[([^\]]*)\]\]` + // [[label]] subroutine
-			`|\[\(([^)]*)\)\]` + // [(label)] cylinder
-			`|\(\[([^\]]*)\]\)` + // ([label]) stadium
-			`|\(\(([^)]*)\)\)` + // ((label)) circle
-			`|>([^\]]*)\]` + // >label] asymmetric
-			`|\[/([^\]]*)/\]` + // [/label/] parallelogram
-			`|\[\\([^\]]*)\\\]` + // [\label\] parallelogram alt
-			`)?`,
-	)
-
-	// Match edges between nodes: A --> B, A --- B, A -.-> B, A ==> B, etc.
-	edgePattern = regexp.MustCompile(
-		`([A-Za-z_][A-Za-z0-9_]*)` +
-			`\s*` +
-			`(-->|---|-.->|-.-|==>|===|--[>x]|~~>|~~)` +
-			`\s*` +
-			`(?:\|([^|]*)\|\s*)?` +
-			`([A-Za-z_][A-Za-z0-9_]*)`,
-	)
-)
-
-func (d *Diagram) parseFlowchart(lines []string) {
-	seenNodes := make(map[string]bool)
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip empty lines, comments, and the diagram declaration
-		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
-			continue
-		}
-		if i == 0 || (d.isDeclarationLine(trimmed)) {
-			continue
-		}
-
-		// Skip subgraph/end/style/class directives
-		lower := strings.ToLower(trimmed)
-		if strings.HasPrefix(lower, "subgraph") ||
-			lower == "end" ||
-			strings.HasPrefix(lower, "style ") ||
-			strings.HasPrefix(lower, "classDef ") ||
-			strings.HasPrefix(lower, "classdef ") ||
-			strings.HasPrefix(lower, "click ") ||
-			strings.HasPrefix(lower, "linkstyle ") {
-			continue
-		}
 
-		lineNum := d.StartLine + i
-
-		// Try to parse edges
-		edgeMatches := edgePattern.FindAllStringSubmatch(trimmed, -1)
-		for _, match := range edgeMatches {
-			fromID := match[1]
-			style := match[2]
-			label := match[3]
-			toID := match[4]
-
-			d.Edges = append(d.Edges, Edge{
-				From:  fromID,
-				To:    toID,
-				Label: label,
-				Line:  lineNum,
-				Style: style,
-			})
-
-			// Register nodes referenced in edges
-			if !seenNodes[fromID] {
-				seenNodes[fromID] = true
-				d.Nodes = append(d.Nodes, Node{ID: fromID, Line: lineNum})
-			}
-			if !seenNodes[toID] {
-				seenNodes[toID] = true
-				d.Nodes = append(d.Nodes, Node{ID: toID, Line: lineNum})
-			}
-		}
-
-		// Try to parse standalone node definitions
-		nodeMatches := nodeDefPattern.FindAllStringSubmatch(trimmed, -1)
-		for _, match := range nodeMatches {
-			id := match[1]
-			if id == "" {
-				continue
-			}
-			// Determine label from whichever capture group matched
-			label := ""
-			shape := "default"
-			for j := 2; j < len(match); j++ {
-				if match[j] != "" {
-					label = match[j]
-					switch j {
-					case 2:
-						shape = "rect"
-					case 3:
-						shape = "round"
-					case 4:
-						shape = "rhombus"
-					case 5:
-						shape = "subroutine"
-					case 6:
-						shape = "cylinder"
-					case 7:
-						shape = "stadium"
-					case 8:
-						shape = "circle"
-					case 9:
-						shape = "asymmetric"
-					case 10:
-						shape = "parallelogram"
-					case 11:
-						shape = "parallelogram-alt"
-					}
-					break
-				}
-			}
-
-			if !seenNodes[id] {
-				seenNodes[id] = true
-				d.Nodes = append(d.Nodes, Node{
-					ID:    id,
-					Label: label,
-					Line:  lineNum,
-					Shape: shape,
-				})
-			} else if label != "" {
-				// Update existing node with label info
-				for k := range d.Nodes {
-					if d.Nodes[k].ID == id && d.Nodes[k].Label == "" {
-						d.Nodes[k].Label = label
-						d.Nodes[k].Shape = shape
-						break
-					}
-				}
-			}
-		}
+	// Without a type declaration, findings point at where it belongs.
+	off := len(d.source)
+	if start < len(lines) {
+		off = d.starts[start]
 	}
+	d.TypeRange = d.span(off, off)
 }
 
-func (d *Diagram) isDeclarationLine(line string) bool {
-	lower := strings.ToLower(strings.TrimSpace(line))
-	for keyword := range KnownDiagramTypes {
-		if strings.HasPrefix(lower, keyword) {
-			return true
+// forEachBodyLine calls fn for every line after the diagram type
+// declaration that is neither blank nor a comment, passing its index in
+// Lines and its trimmed text.
+func (d *Diagram) forEachBodyLine(fn func(i int, line string)) {
+	for i := d.header + 1; i < len(d.Lines); i++ {
+		trimmed := strings.TrimSpace(d.Lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
+			continue
 		}
+		fn(i, trimmed)
 	}
-	return false
 }
@@ -15,6 +15,8 @@ func TestParse_FlowchartType(t *testing.T) {
 		{"flowchart TD", "flowchart TD\n  A --> B", DiagramFlowchart, "TD"},
 		{"graph TB", "graph TB\n  A --> B", DiagramGraph, "TB"},
 		{"graph no direction", "graph\n  A --> B", DiagramGraph, ""},
+		{"graph TD;", "graph TD;\n  A --> B", DiagramGraph, "TD"},
+		{"graph LR; on one line", "graph LR;A-->B", DiagramGraph, "LR"},
 		{"sequenceDiagram", "sequenceDiagram\n  Alice->>Bob: Hello", DiagramSequence, ""},
 		{"classDiagram", "classDiagram\n  class Animal", DiagramClass, ""},
 		{"erDiagram", "erDiagram\n  CUSTOMER ||--o{ ORDER : places", DiagramER, ""},
@@ -26,7 +28,7 @@ func TestParse_FlowchartType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			d := Parse(tt.source, 1)
+			d, _ := Parse(tt.source, 1)
 			if d.Type != tt.wantType {
 				t.Errorf("got type %q, want %q", d.Type, tt.wantType)
 			}
@@ -39,7 +41,7 @@ func TestParse_FlowchartType(t *testing.T) {
 
 func TestParse_SkipsComments(t *testing.T) {
 	source := "%% This is a comment\nflowchart LR\n  A --> B"
-	d := Parse(source, 1)
+	d, _ := Parse(source, 1)
 	if d.Type != DiagramFlowchart {
 		t.Errorf("got type %q, want %q", d.Type, DiagramFlowchart)
 	}
@@ -47,7 +49,7 @@ func TestParse_SkipsComments(t *testing.T) {
 
 func TestParse_FlowchartNodes(t *testing.T) {
 	source := "flowchart LR\n  A[Start] --> B[End]"
-	d := Parse(source, 1)
+	d, _ := Parse(source, 1)
 
 	if len(d.Nodes) < 2 {
 		t.Fatalf("expected at least 2 nodes, got %d", len(d.Nodes))
@@ -73,7 +75,7 @@ func TestParse_FlowchartNodes(t *testing.T) {
 
 func TestParse_FlowchartEdges(t *testing.T) {
 	source := "flowchart LR\n  A --> B\n  B --- C\n  C -.-> D"
-	d := Parse(source, 1)
+	d, _ := Parse(source, 1)
 
 	if len(d.Edges) != 3 {
 		t.Fatalf("expected 3 edges, got %d", len(d.Edges))
@@ -100,7 +102,7 @@ func TestParse_FlowchartEdges(t *testing.T) {
 
 func TestParse_StartLine(t *testing.T) {
 	source := "flowchart LR\n  A --> B"
-	d := Parse(source, 10)
+	d, _ := Parse(source, 10)
 	if d.StartLine != 10 {
 		t.Errorf("StartLine = %d, want 10", d.StartLine)
 	}
@@ -113,7 +115,7 @@ func TestParse_StartLine(t *testing.T) {
 }
 
 func TestParse_EmptySource(t *testing.T) {
-	d := Parse("", 1)
+	d, _ := Parse("", 1)
 	if d.TypeRaw != "" {
 		t.Errorf("expected empty TypeRaw for empty source, got %q", d.TypeRaw)
 	}
@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a location in a diagram. Line and Column are 1-based
+// positions in the file, with Column counting characters; Offset is the
+// 0-based byte offset in the source passed to Parse.
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// Range is the span of a syntax element, from Start up to but not
+// including End.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// position converts a byte offset of the diagram source into a Position.
+// Columns include the characters removed in front of each line when the
+// diagram was extracted from a larger file.
+func (d *Diagram) position(offset int) Position {
+	i := sort.SearchInts(d.starts, offset+1) - 1
+	text := d.Lines[i]
+	n := min(offset-d.starts[i], len(text))
+	col := utf8.RuneCountInString(text[:n]) + 1
+	if i < len(d.indents) {
+		col += d.indents[i]
+	}
+	return Position{Line: d.StartLine + i, Column: col, Offset: offset}
+}
+
+// span returns the Range of the source bytes [start, end).
+func (d *Diagram) span(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// lineRange returns the Range of the text on a file line of the diagram,
+// leaving out the whitespace around it.
+func (d *Diagram) lineRange(line int) Range {
+	i := line - d.StartLine
+	text := d.Lines[i]
+	start := d.starts[i] + len(text) - len(strings.TrimLeft(text, " \t"))
+	end := d.starts[i] + len(strings.TrimRight(text, " \t\r"))
+	return d.span(start, max(start, end))
+}
+
+// linesRange returns the Range from the text on line first to the end of
+// the text on line last.
+func (d *Diagram) linesRange(first, last int) Range {
+	return Range{Start: d.lineRange(first).Start, End: d.lineRange(last).End}
+}
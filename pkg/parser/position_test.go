@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_FlowchartRanges(t *testing.T) {
+	source := "%% comment\n" +
+		"flowchart LR\n" +
+		"  A[Start] -->|go| Bé\n" +
+		"  subgraph s\n" +
+		"    Bé --> C\n" +
+		"  end"
+	d, _ := Parse(source, 5)
+
+	want := Range{Start: Position{Line: 6, Column: 1, Offset: 11}, End: Position{Line: 6, Column: 10, Offset: 20}}
+	if d.TypeRange != want {
+		t.Errorf("TypeRange = %+v, want %+v", d.TypeRange, want)
+	}
+
+	a := d.Nodes[0]
+	want = Range{Start: Position{Line: 7, Column: 3, Offset: 26}, End: Position{Line: 7, Column: 11, Offset: 34}}
+	if a.Range != want {
+		t.Errorf("A range = %+v, want %+v", a.Range, want)
+	}
+
+	// Columns count characters, offsets count bytes.
+	b := d.Nodes[1]
+	if b.Range.End.Column != 22 || b.Range.End.Offset != 46 {
+		t.Errorf("B range = %+v", b.Range)
+	}
+
+	e := d.Edges[0]
+	if e.Range.Start != a.Range.Start || e.Range.End != b.Range.End {
+		t.Errorf("edge range = %+v", e.Range)
+	}
+	link := d.Flowchart.Statements[1].(*ChainStatement).Links[0]
+	if link.Range.Start.Column != 12 || link.Range.End.Column != 19 {
+		t.Errorf("link range = %+v", link.Range)
+	}
+
+	sg := d.Subgraphs[0]
+	if sg.Range.Start.Line != 8 || sg.Range.Start.Column != 3 || sg.Range.End.Line != 10 || sg.Range.End.Column != 6 {
+		t.Errorf("subgraph range = %+v", sg.Range)
+	}
+}
+
+func TestParseBlock_Indents(t *testing.T) {
+	block := MermaidBlock{
+		Source:    "flowchart LR\n  A[oops\n  B",
+		StartLine: 3,
+		Indents:   []int{2, 2, 2},
+	}
+	d, diags := ParseBlock(block)
+
+	if d.StartLine != 4 {
+		t.Errorf("StartLine = %d, want 4", d.StartLine)
+	}
+	if d.TypeRange.Start.Column != 3 {
+		t.Errorf("type column = %d, want 3", d.TypeRange.Start.Column)
+	}
+	if len(diags) != 1 || diags[0].Line != 5 || diags[0].Column != 6 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+}
@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// configError is a malformed frontmatter or directive value. Offset is the
+// byte offset of the problem in the diagram source.
+type configError struct {
+	offset int
+	msg    string
+}
+
+// relaxedJSON parses the JSON accepted in %%{init: ...}%% directives and
+// YAML flow values. Beyond JSON, it allows single-quoted strings, bare
+// object keys and trailing commas. Numbers are decoded as float64, as by
+// encoding/json.
+type relaxedJSON struct {
+	s           string
+	pos         int
+	base        int  // Offset of s in the diagram source
+	bareStrings bool // Whether bare words are strings, as in YAML
+	err         *configError
+}
+
+// parseRelaxedJSON parses s, which starts at offset base of the diagram
+// source, as a single value.
+func parseRelaxedJSON(s string, base int) (any, *configError) {
+	return parseRelaxed(&relaxedJSON{s: s, base: base})
+}
+
+// parseYAMLFlow parses a YAML flow collection such as {curve: basis},
+// where bare words are strings.
+func parseYAMLFlow(s string, base int) (any, *configError) {
+	return parseRelaxed(&relaxedJSON{s: s, base: base, bareStrings: true})
+}
+
+func parseRelaxed(p *relaxedJSON) (any, *configError) {
+	v := p.value()
+	if p.err == nil {
+		p.skipSpace()
+		if p.pos < len(p.s) {
+			p.fail("unexpected %q after value", p.rest())
+		}
+	}
+	return v, p.err
+}
+
+func (p *relaxedJSON) fail(format string, args ...any) {
+	if p.err == nil {
+		p.err = &configError{offset: p.base + p.pos, msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+// rest returns a short excerpt of the input at the current position.
+func (p *relaxedJSON) rest() string {
+	r := p.s[p.pos:]
+	if len(r) > 10 {
+		r = r[:10]
+	}
+	return r
+}
+
+func (p *relaxedJSON) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *relaxedJSON) value() any {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		p.fail("unexpected end of input")
+		return nil
+	}
+	switch c := p.s[p.pos]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+
+	word := p.word()
+	switch word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	case "":
+		p.fail("unexpected %q", p.rest())
+	default:
+		if p.bareStrings {
+			return word
+		}
+		p.pos -= len(word)
+		p.fail("unexpected %q", word)
+	}
+	return nil
+}
+
+// word reads a bare identifier.
+func (p *relaxedJSON) word() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		if !isIDChar(r) && r != '$' {
+			break
+		}
+		p.pos += size
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *relaxedJSON) object() map[string]any {
+	obj := make(map[string]any)
+	p.pos++ // {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			p.fail("unterminated object")
+			return obj
+		}
+		if p.s[p.pos] == '}' {
+			p.pos++
+			return obj
+		}
+
+		var key string
+		if c := p.s[p.pos]; c == '"' || c == '\'' {
+			key = p.str()
+		} else if key = p.word(); key == "" {
+			p.fail("expected object key, found %q", p.rest())
+		}
+		p.skipSpace()
+		if p.err == nil && (p.pos >= len(p.s) || p.s[p.pos] != ':') {
+			p.fail("expected ':' after key %q", key)
+		}
+		if p.err != nil {
+			return obj
+		}
+		p.pos++
+		obj[key] = p.value()
+		if p.err != nil || !p.separator('}') {
+			return obj
+		}
+	}
+}
+
+func (p *relaxedJSON) array() []any {
+	arr := []any{}
+	p.pos++ // [
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			p.fail("unterminated array")
+			return arr
+		}
+		if p.s[p.pos] == ']' {
+			p.pos++
+			return arr
+		}
+		arr = append(arr, p.value())
+		if p.err != nil || !p.separator(']') {
+			return arr
+		}
+	}
+}
+
+// separator consumes the comma after an element, leaving a closing
+// bracket for the caller. It reports false on anything else.
+func (p *relaxedJSON) separator(closer byte) bool {
+	p.skipSpace()
+	switch {
+	case p.pos >= len(p.s):
+		return true // Reported by the caller as unterminated
+	case p.s[p.pos] == ',':
+		p.pos++
+		return true
+	case p.s[p.pos] == closer:
+		return true
+	}
+	p.fail("expected ',' or '%c', found %q", closer, p.rest())
+	return false
+}
+
+func (p *relaxedJSON) str() string {
+	quote := p.s[p.pos]
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String()
+		case c == '\n':
+			p.pos = start
+			p.fail("unterminated string")
+			return ""
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			switch e := p.s[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u':
+				if p.pos+5 <= len(p.s) {
+					if n, err := strconv.ParseUint(p.s[p.pos+1:p.pos+5], 16, 32); err == nil {
+						b.WriteRune(rune(n))
+						p.pos += 4
+						break
+					}
+				}
+				p.fail("invalid escape \\u")
+				return ""
+			default:
+				b.WriteByte(e)
+			}
+			p.pos++
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	p.pos = start
+	p.fail("unterminated string")
+	return ""
+}
+
+func (p *relaxedJSON) number() float64 {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	text := p.s[start:p.pos]
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		p.pos = start
+		p.fail("invalid number %q", text)
+	}
+	return f
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"strings"
+)
+
+// RequirementDiagram is the model of a requirementDiagram.
+type RequirementDiagram struct {
+	Requirements  []Requirement
+	Elements      []RequirementElement
+	Relationships []RequirementRelationship
+}
+
+// Requirement is a requirement block, such as
+// "functionalRequirement login { id: 1 ... }".
+type Requirement struct {
+	Name         string
+	Kind         string // Keyword as written, e.g. "designConstraint"
+	ID           string
+	Text         string
+	Risk         string // "low", "medium" or "high"
+	VerifyMethod string // "analysis", "inspection", "test" or "demonstration"
+	Line         int
+	EndLine      int   // Line of the closing brace, 0 if unclosed
+	Range        Range // Up to the closing brace or, if unclosed, the opening line
+}
+
+// RequirementElement is an element block, such as "element app { ... }".
+type RequirementElement struct {
+	Name    string
+	Type    string
+	DocRef  string
+	Line    int
+	EndLine int
+	Range   Range
+}
+
+// RequirementRelationship is a typed relationship. From is always the
+// source, also for the reversed "b <- satisfies - a" form.
+type RequirementRelationship struct {
+	From  string
+	To    string
+	Kind  string // "contains", "copies", "derives", "satisfies", "verifies", "refines" or "traces"
+	Line  int
+	Range Range
+}
+
+// requirementKinds are the keywords that open a requirement block.
+var requirementKinds = map[string]bool{
+	"requirement":            true,
+	"functionalRequirement":  true,
+	"interfaceRequirement":   true,
+	"performanceRequirement": true,
+	"physicalRequirement":    true,
+	"designConstraint":       true,
+}
+
+var requirementRelationshipKinds = map[string]bool{
+	"contains": true, "copies": true, "derives": true, "satisfies": true,
+	"verifies": true, "refines": true, "traces": true,
+}
+
+var requirementRisks = map[string]bool{"low": true, "medium": true, "high": true}
+
+var requirementVerifyMethods = map[string]bool{
+	"analysis": true, "inspection": true, "test": true, "demonstration": true,
+}
+
+// requirementParser builds a RequirementDiagram line by line. At most one
+// block is open at a time; req or elem points at it.
+type requirementParser struct {
+	d    *Diagram
+	rd   *RequirementDiagram
+	req  *Requirement
+	elem *RequirementElement
+}
+
+func (d *Diagram) parseRequirement() {
+	p := &requirementParser{d: d, rd: &RequirementDiagram{}}
+	d.forEachBodyLine(p.parseLine)
+	p.closeBlock(0)
+	d.Requirement = p.rd
+}
+
+func (p *requirementParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+
+	if p.req != nil || p.elem != nil {
+		if line == "}" {
+			p.closeBlock(lineNum)
+			return
+		}
+		p.parseField(line, lineNum)
+		return
+	}
+
+	if header, ok := strings.CutSuffix(line, "{"); ok {
+		keyword, name := splitKeyword(strings.TrimSpace(header))
+		name = unquote(name)
+		switch {
+		case requirementKinds[keyword]:
+			p.rd.Requirements = append(p.rd.Requirements, Requirement{Name: name, Kind: keyword, Line: lineNum, Range: p.d.lineRange(lineNum)})
+			p.req = &p.rd.Requirements[len(p.rd.Requirements)-1]
+			return
+		case keyword == "element":
+			p.rd.Elements = append(p.rd.Elements, RequirementElement{Name: name, Line: lineNum, Range: p.d.lineRange(lineNum)})
+			p.elem = &p.rd.Elements[len(p.rd.Elements)-1]
+			return
+		}
+	}
+
+	if rel, ok := parseRequirementRelationship(line); ok {
+		if !requirementRelationshipKinds[rel.Kind] {
+			p.d.addDiagnostic(lineNum, "unknown requirement relationship type %q", rel.Kind)
+		}
+		rel.Line, rel.Range = lineNum, p.d.lineRange(lineNum)
+		p.rd.Relationships = append(p.rd.Relationships, rel)
+	}
+}
+
+// parseField reads a "key: value" line inside a block.
+func (p *requirementParser) parseField(line string, lineNum int) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = unquote(strings.TrimSpace(value))
+
+	if p.elem != nil {
+		switch key {
+		case "type":
+			p.elem.Type = value
+		case "docref":
+			p.elem.DocRef = value
+		}
+		return
+	}
+
+	switch key {
+	case "id":
+		p.req.ID = value
+	case "text":
+		p.req.Text = value
+	case "risk":
+		p.req.Risk = strings.ToLower(value)
+		if !requirementRisks[p.req.Risk] {
+			p.d.addDiagnostic(lineNum, "invalid risk %q for requirement %q", value, p.req.Name)
+		}
+	case "verifymethod":
+		p.req.VerifyMethod = strings.ToLower(value)
+		if !requirementVerifyMethods[p.req.VerifyMethod] {
+			p.d.addDiagnostic(lineNum, "invalid verifymethod %q for requirement %q", value, p.req.Name)
+		}
+	}
+}
+
+// closeBlock ends the open block at the given line, reporting it as
+// unclosed when the line is 0.
+func (p *requirementParser) closeBlock(lineNum int) {
+	switch {
+	case p.req != nil:
+		if lineNum == 0 {
+			p.d.addDiagnostic(p.req.Line, "requirement %q is not closed", p.req.Name)
+		} else {
+			p.req.Range.End = p.d.lineRange(lineNum).End
+		}
+		p.req.EndLine = lineNum
+	case p.elem != nil:
+		if lineNum == 0 {
+			p.d.addDiagnostic(p.elem.Line, "element %q is not closed", p.elem.Name)
+		} else {
+			p.elem.Range.End = p.d.lineRange(lineNum).End
+		}
+		p.elem.EndLine = lineNum
+	}
+	p.req, p.elem = nil, nil
+}
+
+// parseRequirementRelationship parses "a - kind -> b" and "b <- kind - a".
+func parseRequirementRelationship(line string) (RequirementRelationship, bool) {
+	if left, rest, ok := strings.Cut(line, " <- "); ok {
+		kind, right, ok := strings.Cut(rest, " - ")
+		if !ok {
+			return RequirementRelationship{}, false
+		}
+		return RequirementRelationship{
+			From: unquote(strings.TrimSpace(right)),
+			To:   unquote(strings.TrimSpace(left)),
+			Kind: strings.TrimSpace(kind),
+		}, true
+	}
+	if left, rest, ok := strings.Cut(line, " - "); ok {
+		kind, right, ok := strings.Cut(rest, " -> ")
+		if !ok {
+			return RequirementRelationship{}, false
+		}
+		return RequirementRelationship{
+			From: unquote(strings.TrimSpace(left)),
+			To:   unquote(strings.TrimSpace(right)),
+			Kind: strings.TrimSpace(kind),
+		}, true
+	}
+	return RequirementRelationship{}, false
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_RequirementDiagram(t *testing.T) {
+	source := "requirementDiagram\n" +
+		"  requirement test_req {\n" +
+		"    id: 1\n" +
+		"    text: the test text.\n" +
+		"    risk: high\n" +
+		"    verifymethod: test\n" +
+		"  }\n" +
+		"  functionalRequirement \"login req\" {\n" +
+		"    id: 1.1\n" +
+		"    risk: Low\n" +
+		"  }\n" +
+		"  element test_entity {\n" +
+		"    type: simulation\n" +
+		"    docRef: reqs/test_entity\n" +
+		"  }\n" +
+		"  test_entity - satisfies -> test_req\n" +
+		"  test_req <- derives - \"login req\""
+	d, _ := Parse(source, 1)
+
+	rd := d.Requirement
+	if rd == nil {
+		t.Fatal("expected a requirement model")
+	}
+	if len(d.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d.Diagnostics)
+	}
+	if len(rd.Requirements) != 2 {
+		t.Fatalf("expected 2 requirements, got %d", len(rd.Requirements))
+	}
+	r := rd.Requirements[0]
+	if r.Name != "test_req" || r.Kind != "requirement" || r.ID != "1" || r.Text != "the test text." {
+		t.Errorf("requirement 0 = %+v", r)
+	}
+	if r.Risk != "high" || r.VerifyMethod != "test" || r.Line != 2 || r.EndLine != 7 {
+		t.Errorf("requirement 0 = %+v", r)
+	}
+	if r.Range.Start.Line != 2 || r.Range.Start.Column != 3 || r.Range.End.Line != 7 || r.Range.End.Column != 4 {
+		t.Errorf("requirement 0 range = %+v, want 2:3-7:4", r.Range)
+	}
+	if r := rd.Requirements[1]; r.Name != "login req" || r.Kind != "functionalRequirement" || r.Risk != "low" {
+		t.Errorf("requirement 1 = %+v", r)
+	}
+
+	if len(rd.Elements) != 1 || rd.Elements[0].Type != "simulation" || rd.Elements[0].DocRef != "reqs/test_entity" {
+		t.Errorf("elements = %+v", rd.Elements)
+	}
+
+	want := []RequirementRelationship{
+		{From: "test_entity", To: "test_req", Kind: "satisfies", Line: 16},
+		{From: "login req", To: "test_req", Kind: "derives", Line: 17},
+	}
+	if len(rd.Relationships) != len(want) {
+		t.Fatalf("expected %d relationships, got %+v", len(want), rd.Relationships)
+	}
+	for i, w := range want {
+		got := rd.Relationships[i]
+		got.Range = Range{}
+		if got != w {
+			t.Errorf("relationship %d = %+v, want %+v", i, rd.Relationships[i], w)
+		}
+	}
+}
+
+func TestParse_RequirementDiagnostics(t *testing.T) {
+	source := "requirementDiagram\n" +
+		"  requirement r {\n" +
+		"    risk: extreme\n" +
+		"  }\n" +
+		"  a - implements -> r\n" +
+		"  element e {\n" +
+		"    type: service"
+	d, _ := Parse(source, 1)
+
+	want := []struct {
+		line int
+		text string
+	}{
+		{3, "invalid risk"},
+		{5, "unknown requirement relationship type"},
+		{6, "not closed"},
+	}
+	if len(d.Diagnostics) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %+v", len(want), d.Diagnostics)
+	}
+	for i, w := range want {
+		diag := d.Diagnostics[i]
+		if diag.Line != w.line || !strings.Contains(diag.Message, w.text) {
+			t.Errorf("diagnostic %d = %+v, want line %d containing %q", i, diag, w.line, w.text)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"strings"
+)
+
+// SankeyDiagram is the model of a sankey-beta diagram.
+type SankeyDiagram struct {
+	Nodes []string // Node names, in order of first appearance
+	Links []SankeyLink
+}
+
+// SankeyLink is a CSV row "source,target,value". Fields may be quoted,
+// with "" standing for a literal quote.
+type SankeyLink struct {
+	Source string
+	Target string
+	Value  float64 // NaN if the value is not a number
+	Line   int
+	Range  Range
+}
+
+func (d *Diagram) parseSankey() {
+	sk := &SankeyDiagram{}
+	seen := make(map[string]bool)
+	addNode := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			sk.Nodes = append(sk.Nodes, name)
+		}
+	}
+
+	d.forEachBodyLine(func(i int, line string) {
+		lineNum := d.StartLine + i
+		r := csv.NewReader(strings.NewReader(line))
+		r.TrimLeadingSpace = true
+		fields, err := r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				err = parseErr.Err // Its line and column are relative to the row
+			}
+			d.addDiagnostic(lineNum, "invalid sankey row: %v", err)
+			return
+		}
+		if len(fields) != 3 {
+			d.addDiagnostic(lineNum, "sankey row has %d fields, expected source,target,value", len(fields))
+			return
+		}
+		link := SankeyLink{
+			Source: strings.TrimSpace(fields[0]),
+			Target: strings.TrimSpace(fields[1]),
+			Value:  parseNumber(fields[2]),
+			Line:   lineNum,
+			Range:  d.lineRange(lineNum),
+		}
+		if math.IsNaN(link.Value) {
+			d.addDiagnostic(lineNum, "sankey value %q is not a number", fields[2])
+		}
+		addNode(link.Source)
+		addNode(link.Target)
+		sk.Links = append(sk.Links, link)
+	})
+	d.Sankey = sk
+}
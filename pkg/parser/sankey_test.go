@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse_Sankey(t *testing.T) {
+	source := "sankey-beta\n" +
+		"%% source,target,value\n" +
+		"Agricultural waste,Bio-conversion,124.729\n" +
+		"\"Heating, \"\"homes\"\"\",Bio-conversion,0.597\n" +
+		"Bio-conversion,Losses,26.862"
+	d, _ := Parse(source, 1)
+
+	sk := d.Sankey
+	if sk == nil {
+		t.Fatal("expected a sankey model")
+	}
+	if len(d.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d.Diagnostics)
+	}
+	if len(sk.Links) != 3 {
+		t.Fatalf("expected 3 links, got %d", len(sk.Links))
+	}
+	if l := sk.Links[0]; l.Source != "Agricultural waste" || l.Target != "Bio-conversion" || l.Value != 124.729 || l.Line != 3 {
+		t.Errorf("link 0 = %+v", l)
+	}
+	if l := sk.Links[1]; l.Source != `Heating, "homes"` {
+		t.Errorf("link 1 source = %q", l.Source)
+	}
+	if len(sk.Nodes) != 4 || sk.Nodes[3] != "Losses" {
+		t.Errorf("nodes = %v", sk.Nodes)
+	}
+}
+
+func TestParse_SankeyInvalidRows(t *testing.T) {
+	d, _ := Parse("sankey-beta\nA,B\nA,B,lots\n\"A,B,1", 1)
+
+	if len(d.Diagnostics) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %+v", d.Diagnostics)
+	}
+	for i, line := range []int{2, 3, 4} {
+		if d.Diagnostics[i].Line != line {
+			t.Errorf("diagnostic %d = %+v, want line %d", i, d.Diagnostics[i], line)
+		}
+	}
+	if len(d.Sankey.Links) != 1 || !math.IsNaN(d.Sankey.Links[0].Value) {
+		t.Errorf("links = %+v", d.Sankey.Links)
+	}
+}
@@ -0,0 +1,341 @@
+package parser
+
+import (
+	"strings"
+)
+
+// SequenceDiagram is the model of a sequenceDiagram.
+type SequenceDiagram struct {
+	// Participants lists every participant and actor once, in order of
+	// first appearance, whether declared or only referenced.
+	Participants []Participant
+	Messages     []Message
+	// Statements holds the statements in document order. Its participant
+	// declarations and messages point into Participants and Messages, so
+	// a change made to either is seen in both.
+	Statements []SequenceStatement
+}
+
+// SequenceStatement is a single statement of a sequence diagram. It is one
+// of *Participant, *Message, *Activation, *Note, *SequenceBlock or
+// *SequenceDirective.
+type SequenceStatement interface {
+	sequenceStatement()
+}
+
+// Participant is a participant or actor of a sequence diagram.
+type Participant struct {
+	ID       string
+	Alias    string // Display name given with "as", if any
+	Kind     string // "participant" or "actor"
+	Created  bool   // Declared with "create"
+	Implicit bool   // Never declared, only referenced
+	Line     int
+	Range    Range // Span of the declaration, or of the first mention
+}
+
+// Message is an arrow between two participants.
+type Message struct {
+	From       string
+	To         string
+	Arrow      string // e.g., "->>", "-->>", "-x", "-)"
+	Text       string
+	Activate   bool // "+" shorthand: activates the target
+	Deactivate bool // "-" shorthand: deactivates the source
+	Line       int
+	Range      Range
+}
+
+// Activation is an activate or deactivate statement.
+type Activation struct {
+	Participant string
+	Active      bool // true for activate, false for deactivate
+	Line        int
+	Range       Range
+}
+
+// Note is a note placed next to or over participants.
+type Note struct {
+	Placement    string // "left of", "right of" or "over"
+	Participants []string
+	Text         string
+	Line         int
+	Range        Range
+}
+
+// SequenceBlock is a loop, alt, opt, par, critical, break, rect or box
+// block. Blocks with alternatives (alt/else, par/and, critical/option)
+// have one section per alternative.
+type SequenceBlock struct {
+	Kind     string
+	Sections []SequenceSection
+	Line     int
+	EndLine  int   // Line of the closing "end", 0 if unclosed
+	Range    Range // Up to the closing "end" or, if unclosed, the opening line
+}
+
+// SequenceSection is one alternative of a block, such as an alt or else.
+type SequenceSection struct {
+	Keyword    string
+	Label      string
+	Statements []SequenceStatement
+	Line       int
+	Range      Range // Span of the line opening the section
+}
+
+// SequenceDirective is any other statement, such as autonumber, title or
+// destroy, with its arguments left unparsed.
+type SequenceDirective struct {
+	Keyword string
+	Text    string
+	Line    int
+	Range   Range
+}
+
+func (*Participant) sequenceStatement()       {}
+func (*Message) sequenceStatement()           {}
+func (*Activation) sequenceStatement()        {}
+func (*Note) sequenceStatement()              {}
+func (*SequenceBlock) sequenceStatement()     {}
+func (*SequenceDirective) sequenceStatement() {}
+
+// sequenceBlockKeywords open a block.
+var sequenceBlockKeywords = map[string]bool{
+	"loop": true, "alt": true, "opt": true, "par": true,
+	"critical": true, "break": true, "rect": true, "box": true,
+}
+
+// sequenceSectionKeywords start another section of the enclosing block.
+var sequenceSectionKeywords = map[string]bool{
+	"else": true, "and": true, "option": true,
+}
+
+// sequenceDirectives maps the other statement keywords, in lowercase, to
+// their usual spelling.
+var sequenceDirectives = map[string]string{
+	"autonumber": "autonumber", "title": "title", "destroy": "destroy", "link": "link",
+	"links": "links", "properties": "properties", "details": "details",
+	"acctitle": "accTitle", "accdescr": "accDescr",
+}
+
+// sequenceArrows lists message arrows, longest first.
+var sequenceArrows = []string{
+	"<<-->>", "<<->>", "-->>", "->>", "--x", "-x", "--)", "-)", "-->", "->",
+}
+
+// sequenceParser builds a SequenceDiagram line by line, keeping a stack of
+// the blocks that are still open.
+type sequenceParser struct {
+	d     *Diagram
+	sd    *SequenceDiagram
+	open  []*SequenceBlock
+	index map[string]int // participant ID -> index in Participants
+}
+
+func (d *Diagram) parseSequence() {
+	p := &sequenceParser{
+		d:     d,
+		sd:    &SequenceDiagram{},
+		index: make(map[string]int),
+	}
+	d.forEachBodyLine(p.parseLine)
+	for _, block := range p.open {
+		d.addDiagnostic(block.Line, "%s block is not closed with \"end\"", block.Kind)
+	}
+	messages := 0
+	p.link(p.sd.Statements, &messages)
+	d.Sequence = p.sd
+}
+
+// link points the statements at the participants and messages they
+// declare. Statements are read before the slices holding them stop
+// growing, so they start out as copies.
+func (p *sequenceParser) link(stmts []SequenceStatement, messages *int) {
+	for i, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *Participant:
+			// A participant declared again keeps its first declaration.
+			if part := &p.sd.Participants[p.index[stmt.ID]]; part.Line == stmt.Line {
+				stmts[i] = part
+			}
+		case *Message:
+			stmts[i] = &p.sd.Messages[*messages]
+			*messages++
+		case *SequenceBlock:
+			for _, section := range stmt.Sections {
+				p.link(section.Statements, messages)
+			}
+		}
+	}
+}
+
+// add appends a statement to the innermost open section.
+func (p *sequenceParser) add(stmt SequenceStatement) {
+	if len(p.open) == 0 {
+		p.sd.Statements = append(p.sd.Statements, stmt)
+		return
+	}
+	block := p.open[len(p.open)-1]
+	section := &block.Sections[len(block.Sections)-1]
+	section.Statements = append(section.Statements, stmt)
+}
+
+// mention records an implicit participant the first time an ID is used.
+func (p *sequenceParser) mention(id string, line int) {
+	if _, seen := p.index[id]; seen || id == "" {
+		return
+	}
+	p.index[id] = len(p.sd.Participants)
+	p.sd.Participants = append(p.sd.Participants, Participant{
+		ID:       id,
+		Kind:     "participant",
+		Implicit: true,
+		Line:     line,
+		Range:    p.d.lineRange(line),
+	})
+}
+
+func (p *sequenceParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+	r := p.d.lineRange(lineNum)
+	keyword, rest := splitKeyword(line)
+	keyword = strings.ToLower(keyword) // Mermaid's keywords ignore case
+
+	switch {
+	case keyword == "participant" || keyword == "actor" || keyword == "create":
+		p.parseParticipant(keyword, rest, lineNum)
+	case keyword == "activate" || keyword == "deactivate":
+		p.mention(rest, lineNum)
+		p.add(&Activation{Participant: rest, Active: keyword == "activate", Line: lineNum, Range: r})
+	case keyword == "note":
+		p.parseNote(rest, lineNum)
+	case sequenceBlockKeywords[keyword]:
+		block := &SequenceBlock{
+			Kind:     keyword,
+			Sections: []SequenceSection{{Keyword: keyword, Label: rest, Line: lineNum, Range: r}},
+			Line:     lineNum,
+			Range:    r,
+		}
+		p.add(block)
+		p.open = append(p.open, block)
+	case sequenceSectionKeywords[keyword] || keyword == "end":
+		if len(p.open) == 0 {
+			p.d.addDiagnostic(lineNum, "%q without an open block", keyword)
+			return
+		}
+		block := p.open[len(p.open)-1]
+		if keyword != "end" {
+			block.Sections = append(block.Sections, SequenceSection{Keyword: keyword, Label: rest, Line: lineNum, Range: r})
+			return
+		}
+		block.EndLine, block.Range.End = lineNum, r.End
+		p.open = p.open[:len(p.open)-1]
+	case sequenceDirectives[strings.TrimSuffix(keyword, ":")] != "":
+		p.add(&SequenceDirective{Keyword: sequenceDirectives[strings.TrimSuffix(keyword, ":")], Text: rest, Line: lineNum, Range: r})
+	default:
+		if msg, ok := parseMessage(line); ok {
+			msg.Line, msg.Range = lineNum, r
+			p.mention(msg.From, lineNum)
+			p.mention(msg.To, lineNum)
+			p.sd.Messages = append(p.sd.Messages, msg)
+			p.add(&msg)
+		}
+	}
+}
+
+// parseParticipant handles "participant A as Alice", "actor B" and the
+// "create" prefix.
+func (p *sequenceParser) parseParticipant(keyword, rest string, lineNum int) {
+	part := Participant{Kind: keyword, Line: lineNum, Range: p.d.lineRange(lineNum)}
+	if keyword == "create" {
+		part.Created = true
+		part.Kind, rest = splitKeyword(rest)
+	}
+	part.ID = rest
+	if idx := strings.Index(rest, " as "); idx >= 0 {
+		part.ID = strings.TrimSpace(rest[:idx])
+		part.Alias = strings.TrimSpace(rest[idx+len(" as "):])
+	}
+	if part.ID == "" {
+		return
+	}
+
+	// A declaration after the first mention still describes the same
+	// participant.
+	if i, seen := p.index[part.ID]; !seen {
+		p.index[part.ID] = len(p.sd.Participants)
+		p.sd.Participants = append(p.sd.Participants, part)
+	} else if p.sd.Participants[i].Implicit {
+		p.sd.Participants[i] = part
+	}
+	p.add(&part)
+}
+
+// parseNote handles "Note left of A: text", "Note right of A: text" and
+// "Note over A,B: text".
+func (p *sequenceParser) parseNote(rest string, lineNum int) {
+	target, text, _ := strings.Cut(rest, ":")
+	target = strings.TrimSpace(target)
+
+	note := &Note{Text: strings.TrimSpace(text), Line: lineNum, Range: p.d.lineRange(lineNum)}
+	lower := strings.ToLower(target)
+	for _, placement := range []string{"left of", "right of", "over"} {
+		if strings.HasPrefix(lower, placement+" ") {
+			note.Placement = placement
+			target = strings.TrimSpace(target[len(placement):])
+			break
+		}
+	}
+	for _, id := range strings.Split(target, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		note.Participants = append(note.Participants, id)
+		p.mention(id, lineNum)
+	}
+	p.add(note)
+}
+
+// parseMessage parses "From->>+To: text". The arrow is the leftmost arrow
+// operator before the message text.
+func parseMessage(line string) (Message, bool) {
+	head, text, hasText := strings.Cut(line, ":")
+	for i := 0; i < len(head); i++ {
+		for _, arrow := range sequenceArrows {
+			if !strings.HasPrefix(head[i:], arrow) {
+				continue
+			}
+			msg := Message{
+				From:  strings.TrimSpace(head[:i]),
+				Arrow: arrow,
+			}
+			to := strings.TrimSpace(head[i+len(arrow):])
+			if strings.HasPrefix(to, "+") {
+				msg.Activate = true
+				to = strings.TrimSpace(to[1:])
+			} else if strings.HasPrefix(to, "-") {
+				msg.Deactivate = true
+				to = strings.TrimSpace(to[1:])
+			}
+			msg.To = to
+			if hasText {
+				msg.Text = strings.TrimSpace(text)
+			}
+			if msg.From == "" || msg.To == "" {
+				return Message{}, false
+			}
+			return msg, true
+		}
+	}
+	return Message{}, false
+}
+
+// splitKeyword splits a line into its first word and the trimmed rest.
+func splitKeyword(line string) (string, string) {
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], strings.TrimSpace(line[i+1:])
+}
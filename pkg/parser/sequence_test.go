@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_SequenceParticipants(t *testing.T) {
+	source := "sequenceDiagram\n" +
+		"  participant A as Alice\n" +
+		"  actor B as Bob\n" +
+		"  A->>C: Hi\n" +
+		"  participant C\n" +
+		"  create participant D"
+	d, _ := Parse(source, 1)
+
+	if d.Sequence == nil {
+		t.Fatal("expected a sequence model")
+	}
+	parts := d.Sequence.Participants
+	if len(parts) != 4 {
+		t.Fatalf("expected 4 participants, got %d: %+v", len(parts), parts)
+	}
+	if parts[0].ID != "A" || parts[0].Alias != "Alice" || parts[0].Kind != "participant" {
+		t.Errorf("participant 0 = %+v", parts[0])
+	}
+	if parts[1].ID != "B" || parts[1].Alias != "Bob" || parts[1].Kind != "actor" {
+		t.Errorf("participant 1 = %+v", parts[1])
+	}
+	if parts[2].ID != "C" || parts[2].Implicit || parts[2].Line != 5 {
+		t.Errorf("participant 2 = %+v, want declared C at line 5", parts[2])
+	}
+	if parts[3].ID != "D" || !parts[3].Created {
+		t.Errorf("participant 3 = %+v", parts[3])
+	}
+}
+
+func TestParse_SequenceMessages(t *testing.T) {
+	source := "sequenceDiagram\n" +
+		"  Alice->>+Bob: Hello: there\n" +
+		"  Bob-->>-Alice: Hi\n" +
+		"  Alice-xBob: Lost\n" +
+		"  Alice-)Bob: Async\n" +
+		"  Alice<<->>Bob: Both ways"
+	d, _ := Parse(source, 1)
+
+	want := []Message{
+		{From: "Alice", To: "Bob", Arrow: "->>", Text: "Hello: there", Activate: true, Line: 2},
+		{From: "Bob", To: "Alice", Arrow: "-->>", Text: "Hi", Deactivate: true, Line: 3},
+		{From: "Alice", To: "Bob", Arrow: "-x", Text: "Lost", Line: 4},
+		{From: "Alice", To: "Bob", Arrow: "-)", Text: "Async", Line: 5},
+		{From: "Alice", To: "Bob", Arrow: "<<->>", Text: "Both ways", Line: 6},
+	}
+	if len(d.Sequence.Messages) != len(want) {
+		t.Fatalf("expected %d messages, got %d", len(want), len(d.Sequence.Messages))
+	}
+	for i, w := range want {
+		got := d.Sequence.Messages[i]
+		got.Range = Range{}
+		if got != w {
+			t.Errorf("message %d = %+v, want %+v", i, d.Sequence.Messages[i], w)
+		}
+	}
+	if len(d.Sequence.Participants) != 2 {
+		t.Errorf("expected 2 implicit participants, got %d", len(d.Sequence.Participants))
+	}
+	if r := d.Sequence.Messages[0].Range; r.Start.Line != 2 || r.Start.Column != 3 || r.End.Line != 2 || r.End.Column != 29 {
+		t.Errorf("message 0 range = %+v, want 2:3-2:29", r)
+	}
+}
+
+func TestParse_SequenceBlocks(t *testing.T) {
+	source := "sequenceDiagram\n" +
+		"  loop Every minute\n" +
+		"    alt is sick\n" +
+		"      A->>B: Not so good\n" +
+		"    else is well\n" +
+		"      A->>B: Fine\n" +
+		"    end\n" +
+		"  end\n" +
+		"  opt Extra\n" +
+		"    A->>B: Thanks"
+	d, diags := Parse(source, 1)
+
+	stmts := d.Sequence.Statements
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 top-level statements, got %d", len(stmts))
+	}
+	loop, ok := stmts[0].(*SequenceBlock)
+	if !ok || loop.Kind != "loop" || loop.Line != 2 || loop.EndLine != 8 {
+		t.Fatalf("statement 0 = %+v, want loop spanning 2-8", stmts[0])
+	}
+	if loop.Sections[0].Label != "Every minute" {
+		t.Errorf("loop label = %q", loop.Sections[0].Label)
+	}
+
+	alt, ok := loop.Sections[0].Statements[0].(*SequenceBlock)
+	if !ok || alt.Kind != "alt" || len(alt.Sections) != 2 {
+		t.Fatalf("expected nested alt with 2 sections, got %+v", loop.Sections[0].Statements[0])
+	}
+	if alt.Sections[1].Keyword != "else" || alt.Sections[1].Label != "is well" || alt.Sections[1].Line != 5 {
+		t.Errorf("else section = %+v", alt.Sections[1])
+	}
+	if len(alt.Sections[1].Statements) != 1 {
+		t.Errorf("expected 1 statement in else, got %d", len(alt.Sections[1].Statements))
+	}
+
+	opt := stmts[1].(*SequenceBlock)
+	if opt.EndLine != 0 {
+		t.Errorf("unclosed opt EndLine = %d, want 0", opt.EndLine)
+	}
+	want := Diagnostic{Message: `opt block is not closed with "end"`, Line: 9, Column: 3, EndLine: 9, EndColumn: 12}
+	if len(diags) != 1 || diags[0] != want {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+}
+
+func TestParse_SequenceStrayEnd(t *testing.T) {
+	source := "sequenceDiagram\n" +
+		"  A->>B: Hi\n" +
+		"  end\n" +
+		"  else later\n" +
+		"  LOOP Again\n" +
+		"    B->>A: Hello\n" +
+		"  End"
+	d, diags := Parse(source, 1)
+
+	want := []string{`"end" without an open block`, `"else" without an open block`}
+	if len(diags) != len(want) {
+		t.Fatalf("diagnostics = %+v, want %q", diags, want)
+	}
+	for i, w := range want {
+		if diags[i].Message != w || diags[i].Line != i+3 {
+			t.Errorf("diagnostic %d = %+v, want %q at line %d", i, diags[i], w, i+3)
+		}
+	}
+	if loop, ok := d.Sequence.Statements[1].(*SequenceBlock); !ok || loop.Kind != "loop" || loop.EndLine != 7 {
+		t.Errorf("statement 1 = %+v, want loop spanning 5-7", d.Sequence.Statements[1])
+	}
+}
+
+func TestParse_SequenceNotesAndActivations(t *testing.T) {
+	source := "sequenceDiagram\n" +
+		"  activate A\n" +
+		"  Note right of A: Thinking\n" +
+		"  note over A,B: Shared\n" +
+		"  deactivate A\n" +
+		"  autonumber"
+	d, _ := Parse(source, 1)
+
+	stmts := d.Sequence.Statements
+	if len(stmts) != 5 {
+		t.Fatalf("expected 5 statements, got %d", len(stmts))
+	}
+	if act, ok := stmts[0].(*Activation); !ok || act.Participant != "A" || !act.Active {
+		t.Errorf("statement 0 = %+v", stmts[0])
+	}
+	note := stmts[1].(*Note)
+	if note.Placement != "right of" || note.Text != "Thinking" || len(note.Participants) != 1 {
+		t.Errorf("note = %+v", note)
+	}
+	over := stmts[2].(*Note)
+	if over.Placement != "over" || len(over.Participants) != 2 || over.Participants[1] != "B" {
+		t.Errorf("note over = %+v", over)
+	}
+	if act := stmts[3].(*Activation); act.Active {
+		t.Errorf("expected deactivate, got %+v", act)
+	}
+	if dir := stmts[4].(*SequenceDirective); dir.Keyword != "autonumber" {
+		t.Errorf("directive = %+v", dir)
+	}
+}
+
+func TestParse_SequenceStatementsShareModel(t *testing.T) {
+	source := "sequenceDiagram\n" +
+		"  participant A as Alice\n" +
+		"  loop Every minute\n" +
+		"    A->>B: Hi\n" +
+		"  end\n" +
+		"  B->>A: Hello\n" +
+		"  participant A"
+	d, _ := Parse(source, 1)
+
+	sd := d.Sequence
+	if sd.Statements[0] != &sd.Participants[0] {
+		t.Errorf("statement 0 does not point at Participants[0]")
+	}
+	if sd.Statements[1].(*SequenceBlock).Sections[0].Statements[0] != &sd.Messages[0] {
+		t.Errorf("message in loop does not point at Messages[0]")
+	}
+	if sd.Statements[2] != &sd.Messages[1] {
+		t.Errorf("statement 2 does not point at Messages[1]")
+	}
+	if sd.Statements[3] == &sd.Participants[0] {
+		t.Errorf("second declaration of A points at its first")
+	}
+}
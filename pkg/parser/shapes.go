@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namedShapes maps the shape names accepted in "A@{ shape: ... }" to the
+// names used in Node.Shape. Shapes that also have a bracket syntax use the
+// bracket shape's name, so that "A[(db)]" and "A@{ shape: cyl }" agree.
+var namedShapes = map[string]string{
+	"rect": "rect", "rectangle": "rect", "proc": "rect", "process": "rect",
+	"rounded": "round", "event": "round",
+	"stadium": "stadium", "pill": "stadium", "terminal": "stadium",
+	"fr-rect": "subroutine", "framed-rectangle": "subroutine", "subproc": "subroutine", "subprocess": "subroutine", "subroutine": "subroutine",
+	"cyl": "cylinder", "cylinder": "cylinder", "database": "cylinder", "db": "cylinder",
+	"circle": "circle", "circ": "circle",
+	"dbl-circ": "double-circle", "double-circle": "double-circle",
+	"diam": "rhombus", "diamond": "rhombus", "decision": "rhombus", "question": "rhombus",
+	"hex": "hexagon", "hexagon": "hexagon", "prepare": "hexagon",
+	"lean-r": "parallelogram", "lean-right": "parallelogram", "in-out": "parallelogram",
+	"lean-l": "parallelogram-alt", "lean-left": "parallelogram-alt", "out-in": "parallelogram-alt",
+	"trap-b": "trapezoid", "trapezoid": "trapezoid", "trapezoid-bottom": "trapezoid", "priority": "trapezoid",
+	"trap-t": "trapezoid-alt", "trapezoid-top": "trapezoid-alt", "inv-trapezoid": "trapezoid-alt", "manual": "trapezoid-alt",
+	"odd": "asymmetric", "text": "text",
+	"notch-rect": "notched-rectangle", "notched-rectangle": "notched-rectangle", "card": "notched-rectangle",
+	"hourglass": "hourglass", "collate": "hourglass",
+	"bolt": "lightning-bolt", "lightning-bolt": "lightning-bolt", "com-link": "lightning-bolt",
+	"brace": "curly-brace", "brace-l": "curly-brace", "comment": "curly-brace",
+	"brace-r": "curly-brace-right", "braces": "curly-braces",
+	"delay": "half-rounded-rectangle", "half-rounded-rectangle": "half-rounded-rectangle",
+	"h-cyl": "horizontal-cylinder", "horizontal-cylinder": "horizontal-cylinder", "das": "horizontal-cylinder",
+	"lin-cyl": "lined-cylinder", "lined-cylinder": "lined-cylinder", "disk": "lined-cylinder",
+	"curv-trap": "curved-trapezoid", "curved-trapezoid": "curved-trapezoid", "display": "curved-trapezoid",
+	"div-rect": "divided-rectangle", "divided-rectangle": "divided-rectangle", "div-proc": "divided-rectangle", "divided-process": "divided-rectangle",
+	"doc": "document", "document": "document",
+	"tri": "triangle", "triangle": "triangle", "extract": "triangle",
+	"fork": "filled-rectangle", "join": "filled-rectangle",
+	"win-pane": "window-pane", "window-pane": "window-pane", "internal-storage": "window-pane",
+	"f-circ": "filled-circle", "filled-circle": "filled-circle", "junction": "filled-circle",
+	"lin-doc": "lined-document", "lined-document": "lined-document",
+	"lin-rect": "lined-rectangle", "lined-rectangle": "lined-rectangle", "lin-proc": "lined-rectangle", "lined-process": "lined-rectangle", "shaded-process": "lined-rectangle",
+	"notch-pent": "trapezoidal-pentagon", "notched-pentagon": "trapezoidal-pentagon", "loop-limit": "trapezoidal-pentagon",
+	"flip-tri": "flipped-triangle", "flipped-triangle": "flipped-triangle", "manual-file": "flipped-triangle",
+	"sl-rect": "sloped-rectangle", "sloped-rectangle": "sloped-rectangle", "manual-input": "sloped-rectangle",
+	"docs": "stacked-document", "stacked-document": "stacked-document", "st-doc": "stacked-document", "documents": "stacked-document",
+	"st-rect": "stacked-rectangle", "stacked-rectangle": "stacked-rectangle", "processes": "stacked-rectangle", "procs": "stacked-rectangle",
+	"flag": "flag", "paper-tape": "flag",
+	"sm-circ": "small-circle", "small-circle": "small-circle", "start": "small-circle",
+	"fr-circ": "framed-circle", "framed-circle": "framed-circle", "stop": "framed-circle",
+	"bow-rect": "bow-tie-rectangle", "bow-tie-rectangle": "bow-tie-rectangle", "stored-data": "bow-tie-rectangle",
+	"cross-circ": "crossed-circle", "crossed-circle": "crossed-circle", "summary": "crossed-circle",
+	"tag-doc": "tagged-document", "tagged-document": "tagged-document",
+	"tag-rect": "tagged-rectangle", "tagged-rectangle": "tagged-rectangle", "tag-proc": "tagged-rectangle", "tagged-process": "tagged-rectangle",
+}
+
+// attrError reports an invalid entity code of the label attribute raw,
+// located in the source of the attrs token tok. An entity written with
+// escapes that hide it from the source is reported on the whole token.
+func (p *flowchartParser) attrError(tok token, raw string, e labelError) {
+	key := max(strings.Index(tok.text, "label"), 0)
+	offset := -1
+	if i := strings.Index(tok.text[key:], raw); i >= 0 {
+		offset = key + i + e.offset
+	} else if i := strings.Index(tok.text[key:], e.code); i >= 0 {
+		offset = key + i
+	}
+	if offset < 0 {
+		p.errorAt(tok, "invalid entity code %q", e.code)
+		return
+	}
+	start := tok.pos + offset
+	p.d.addSpanDiagnostic(start, start+len(e.code), "invalid entity code %q", e.code)
+}
+
+// applyAttrs sets the shape and label of a node reference from its
+// "@{ ... }" attributes. The label is decoded as if unquoted. An "icon" or
+// "img" attribute makes it an icon or image node, whatever its shape. It
+// reports an unknown shape name.
+func (p *flowchartParser) applyAttrs(ref *NodeRef, tok token) {
+	if label, ok := ref.Attrs["label"]; ok && label != nil {
+		raw := fmt.Sprint(label)
+		text, errs := decodeLabel(raw)
+		for _, e := range errs {
+			p.attrError(tok, raw, e)
+		}
+		ref.LabelText, ref.Label = text, text.Display
+	}
+	switch {
+	case ref.Attrs["icon"] != nil:
+		ref.Shape = "icon"
+	case ref.Attrs["img"] != nil:
+		ref.Shape = "image"
+	case ref.Attrs["shape"] != nil:
+		name := fmt.Sprint(ref.Attrs["shape"])
+		shape, ok := namedShapes[strings.ToLower(name)]
+		if !ok {
+			p.errorAt(tok, "unknown shape %q", name)
+			shape = "rect"
+		}
+		ref.Shape = shape
+	}
+}
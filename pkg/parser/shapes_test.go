@@ -0,0 +1,64 @@
+package parser
+
+import "testing"
+
+func TestParse_FlowchartBracketShapes(t *testing.T) {
+	source := "flowchart TD\n" +
+		"  a{{hex}}\n  b[/trap\\]\n  c[\\inv/]\n  d(((stop)))\n  e[/lean/]\n" +
+		"  f[/x\\] --> g[/y/]"
+	d, diags := Parse(source, 1)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	want := map[string]string{
+		"a": "hexagon", "b": "trapezoid", "c": "trapezoid-alt", "d": "double-circle",
+		"e": "parallelogram", "f": "trapezoid", "g": "parallelogram",
+	}
+	if len(d.Nodes) != len(want) {
+		t.Fatalf("expected %d nodes, got %+v", len(want), d.Nodes)
+	}
+	for _, n := range d.Nodes {
+		if n.Shape != want[n.ID] {
+			t.Errorf("node %s shape = %q, want %q", n.ID, n.Shape, want[n.ID])
+		}
+	}
+	if d.Nodes[3].Label != "stop" || d.Nodes[5].Label != "x" {
+		t.Errorf("labels = %q, %q", d.Nodes[3].Label, d.Nodes[5].Label)
+	}
+}
+
+func TestParse_FlowchartNamedShapes(t *testing.T) {
+	source := "flowchart TD\n" +
+		"  db@{ shape: cyl, label: \"Users DB\" } --> api@{ shape: Rounded }\n" +
+		"  user@{ icon: \"fa:user\", form: square, label: User }\n" +
+		"  logo@{ img: \"https://example.com/logo.png\", w: 60 }\n" +
+		"  plain@{ label: Plain }\n" +
+		"  odd@{ shape: blob }"
+	d, diags := Parse(source, 1)
+
+	want := []struct{ id, shape, label string }{
+		{"db", "cylinder", "Users DB"},
+		{"api", "round", ""},
+		{"user", "icon", "User"},
+		{"logo", "image", ""},
+		{"plain", "rect", "Plain"},
+		{"odd", "rect", ""},
+	}
+	if len(d.Nodes) != len(want) {
+		t.Fatalf("expected %d nodes, got %+v", len(want), d.Nodes)
+	}
+	for i, w := range want {
+		n := d.Nodes[i]
+		if n.ID != w.id || n.Shape != w.shape || n.Label != w.label {
+			t.Errorf("node %d = %s %q %q, want %s %q %q", i, n.ID, n.Shape, n.Label, w.id, w.shape, w.label)
+		}
+	}
+	if d.Nodes[2].Attrs["form"] != "square" || d.Nodes[3].Attrs["w"] != float64(60) {
+		t.Errorf("attrs = %v, %v", d.Nodes[2].Attrs, d.Nodes[3].Attrs)
+	}
+
+	if len(diags) != 1 || diags[0].Message != `unknown shape "blob"` || diags[0].Line != 6 || diags[0].Column != 6 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+}
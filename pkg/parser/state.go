@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StateDiagram is the model of a stateDiagram or stateDiagram-v2.
+type StateDiagram struct {
+	Direction   string
+	States      []State // Each state once, in order of first appearance
+	Transitions []Transition
+	Notes       []StateNote
+}
+
+// State is a state of a state diagram. The [*] pseudo-states get one
+// start and one end state per scope, named "<scope>_start" and
+// "<scope>_end", where the scope is the enclosing composite state or
+// "root" at the top level.
+type State struct {
+	ID          string
+	Description string // Text from state "text" as ID or ID : text
+	Kind        string // "state", "start", "end", "fork", "join" or "choice"
+	Composite   bool   // Has a { } body
+	Parent      string // Enclosing composite state, empty at the top level
+	Region      int    // Concurrent region within the parent, from 0
+	Implicit    bool   // Never declared, only used in transitions
+	Line        int
+	EndLine     int   // Line of the closing brace of a composite, 0 if unclosed
+	Range       Range // Span of the declaration, up to the closing brace of a composite
+}
+
+// Transition is an arrow between two states.
+type Transition struct {
+	From  string
+	To    string
+	Label string
+	Line  int
+	Range Range
+}
+
+// StateNote is a note attached to a state.
+type StateNote struct {
+	Placement string // "left of" or "right of"
+	State     string
+	Text      string
+	Line      int
+	Range     Range // Up to "end note" for a note written on several lines
+}
+
+var (
+	// Match transitions: A --> B : label, with [*] for start and end
+	transitionPattern = regexp.MustCompile(
+		`^(\[\*\]|[\w.]+(?:-[\w.]+)*)(?::::\w+)?\s*-->\s*` +
+			`(\[\*\]|[\w.]+(?:-[\w.]+)*)(?::::\w+)?\s*(?::\s*(.*))?$`,
+	)
+
+	// Match state declarations: state "text" as ID <<fork>> {
+	stateDeclPattern = regexp.MustCompile(
+		`^state\s+(?:"([^"]*)"\s+as\s+)?([\w.]+(?:-[\w.]+)*)` +
+			`(?:\s*<<(\w+)>>)?(?:\s*:\s*([^{]*?))?\s*(\{)?$`,
+	)
+
+	// Match descriptions: ID : text
+	stateDescPattern = regexp.MustCompile(`^([\w.]+(?:-[\w.]+)*)\s*:\s*(.*)$`)
+
+	// Match notes: note left of ID : text
+	stateNotePattern = regexp.MustCompile(`^note\s+(left of|right of)\s+([\w.]+(?:-[\w.]+)*)\s*(?::\s*(.*))?$`)
+
+	// Match a bare state ID on its own line
+	stateIDPattern = regexp.MustCompile(`^([\w.]+(?:-[\w.]+)*)(?::::\w+)?$`)
+)
+
+// stateScope is an open composite state.
+type stateScope struct {
+	id     string
+	region int
+}
+
+// stateParser builds a StateDiagram line by line, keeping a stack of the
+// composite states that are still open.
+type stateParser struct {
+	d     *Diagram
+	sd    *StateDiagram
+	index map[string]int // state ID -> index in States
+	open  []stateScope
+	note  *StateNote // multi-line note being read, if any
+}
+
+func (d *Diagram) parseState() {
+	p := &stateParser{
+		d:     d,
+		sd:    &StateDiagram{},
+		index: make(map[string]int),
+	}
+	d.forEachBodyLine(p.parseLine)
+	for _, scope := range p.open {
+		d.addDiagnostic(p.sd.States[p.index[scope.id]].Line, "state %q is not closed with \"}\"", scope.id)
+	}
+	if p.note != nil {
+		d.addDiagnostic(p.note.Line, "note is not closed with \"end note\"")
+		p.sd.Notes = append(p.sd.Notes, *p.note)
+	}
+	d.State = p.sd
+}
+
+// scope returns the innermost open composite state and region.
+func (p *stateParser) scope() stateScope {
+	if len(p.open) == 0 {
+		return stateScope{}
+	}
+	return p.open[len(p.open)-1]
+}
+
+// state returns the state with the given ID, creating it in the current
+// scope if needed.
+func (p *stateParser) state(id string, line int, implicit bool) *State {
+	if i, ok := p.index[id]; ok {
+		s := &p.sd.States[i]
+		if !implicit && s.Implicit {
+			s.Implicit = false
+			s.Line, s.Range = line, p.d.lineRange(line)
+		}
+		return s
+	}
+	scope := p.scope()
+	p.index[id] = len(p.sd.States)
+	p.sd.States = append(p.sd.States, State{
+		ID:       id,
+		Kind:     "state",
+		Parent:   scope.id,
+		Region:   scope.region,
+		Implicit: implicit,
+		Line:     line,
+		Range:    p.d.lineRange(line),
+	})
+	return &p.sd.States[len(p.sd.States)-1]
+}
+
+// pseudoState resolves [*] to the start or end state of the current scope.
+func (p *stateParser) pseudoState(id, kind string, line int) string {
+	if id != "[*]" {
+		p.state(id, line, true)
+		return id
+	}
+	scope := p.scope().id
+	if scope == "" {
+		scope = "root"
+	}
+	id = scope + "_" + kind
+	s := p.state(id, line, false)
+	s.Kind = kind
+	return id
+}
+
+func (p *stateParser) parseLine(i int, line string) {
+	lineNum := p.d.StartLine + i
+
+	if p.note != nil {
+		if line == "end note" {
+			p.note.Range.End = p.d.lineRange(lineNum).End
+			p.sd.Notes = append(p.sd.Notes, *p.note)
+			p.note = nil
+			return
+		}
+		if p.note.Text != "" {
+			p.note.Text += "\n"
+		}
+		p.note.Text += line
+		return
+	}
+
+	keyword, rest := splitKeyword(line)
+	switch {
+	case line == "}":
+		if len(p.open) == 0 {
+			p.d.addDiagnostic(lineNum, "\"}\" without an open composite state")
+			return
+		}
+		closed := &p.sd.States[p.index[p.open[len(p.open)-1].id]]
+		closed.EndLine, closed.Range.End = lineNum, p.d.lineRange(lineNum).End
+		p.open = p.open[:len(p.open)-1]
+	case line == "--" && len(p.open) > 0:
+		p.open[len(p.open)-1].region++
+	case keyword == "direction":
+		p.sd.Direction = rest
+	case keyword == "classDef" || keyword == "class" || keyword == "style":
+		// Styling is not part of the state model.
+	case keyword == "state":
+		p.parseStateDecl(line, lineNum)
+	case keyword == "note":
+		p.parseNote(line, lineNum)
+	default:
+		p.parseStatement(line, lineNum)
+	}
+}
+
+func (p *stateParser) parseStateDecl(line string, lineNum int) {
+	m := stateDeclPattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	s := p.state(m[2], lineNum, false)
+	if m[1] != "" {
+		s.Description = m[1]
+	}
+	if m[3] != "" {
+		s.Kind = strings.ToLower(m[3])
+	}
+	if m[4] != "" {
+		s.Description = m[4]
+	}
+	if m[5] != "" {
+		s.Composite = true
+		p.open = append(p.open, stateScope{id: s.ID})
+	}
+}
+
+func (p *stateParser) parseNote(line string, lineNum int) {
+	m := stateNotePattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	p.state(m[2], lineNum, true)
+	note := StateNote{Placement: m[1], State: m[2], Line: lineNum, Range: p.d.lineRange(lineNum)}
+	if !strings.Contains(line, ":") {
+		// The text follows on the next lines, up to "end note".
+		p.note = &note
+		return
+	}
+	note.Text = strings.TrimSpace(m[3])
+	p.sd.Notes = append(p.sd.Notes, note)
+}
+
+// parseStatement handles transitions, descriptions and bare state IDs.
+func (p *stateParser) parseStatement(line string, lineNum int) {
+	if m := transitionPattern.FindStringSubmatch(line); m != nil {
+		p.sd.Transitions = append(p.sd.Transitions, Transition{
+			From:  p.pseudoState(m[1], "start", lineNum),
+			To:    p.pseudoState(m[2], "end", lineNum),
+			Label: strings.TrimSpace(m[3]),
+			Line:  lineNum,
+			Range: p.d.lineRange(lineNum),
+		})
+		return
+	}
+	if m := stateDescPattern.FindStringSubmatch(line); m != nil {
+		s := p.state(m[1], lineNum, false)
+		if s.Description != "" {
+			s.Description += "\n"
+		}
+		s.Description += strings.TrimSpace(m[2])
+		return
+	}
+	if m := stateIDPattern.FindStringSubmatch(line); m != nil {
+		p.state(m[1], lineNum, false)
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_StateTransitions(t *testing.T) {
+	source := "stateDiagram-v2\n" +
+		"  [*] --> Still\n" +
+		"  Still --> Moving : push\n" +
+		"  Moving --> [*]"
+	d, _ := Parse(source, 1)
+
+	if d.State == nil {
+		t.Fatal("expected a state model")
+	}
+	want := []Transition{
+		{From: "root_start", To: "Still", Line: 2},
+		{From: "Still", To: "Moving", Label: "push", Line: 3},
+		{From: "Moving", To: "root_end", Line: 4},
+	}
+	if len(d.State.Transitions) != len(want) {
+		t.Fatalf("expected %d transitions, got %d", len(want), len(d.State.Transitions))
+	}
+	for i, w := range want {
+		got := d.State.Transitions[i]
+		got.Range = Range{}
+		if got != w {
+			t.Errorf("transition %d = %+v, want %+v", i, d.State.Transitions[i], w)
+		}
+	}
+
+	kinds := make(map[string]string)
+	for _, s := range d.State.States {
+		kinds[s.ID] = s.Kind
+	}
+	if kinds["root_start"] != "start" || kinds["root_end"] != "end" || kinds["Still"] != "state" {
+		t.Errorf("unexpected state kinds: %v", kinds)
+	}
+}
+
+func TestParse_StateCompositeAndConcurrency(t *testing.T) {
+	source := "stateDiagram-v2\n" +
+		"  state Active {\n" +
+		"    [*] --> NumLockOff\n" +
+		"    --\n" +
+		"    [*] --> CapsLockOff\n" +
+		"  }\n" +
+		"  state \"Waiting for input\" as Idle\n" +
+		"  Idle : press any key"
+	d, _ := Parse(source, 1)
+
+	byID := make(map[string]State)
+	for _, s := range d.State.States {
+		byID[s.ID] = s
+	}
+
+	active := byID["Active"]
+	if !active.Composite || active.Line != 2 || active.EndLine != 6 {
+		t.Errorf("Active = %+v", active)
+	}
+	if s := byID["NumLockOff"]; s.Parent != "Active" || s.Region != 0 {
+		t.Errorf("NumLockOff = %+v", s)
+	}
+	if s := byID["CapsLockOff"]; s.Parent != "Active" || s.Region != 1 {
+		t.Errorf("CapsLockOff = %+v", s)
+	}
+	if s, ok := byID["Active_start"]; !ok || s.Kind != "start" {
+		t.Errorf("expected a start state scoped to Active, got %+v", s)
+	}
+	if s := byID["Idle"]; s.Description != "Waiting for input\npress any key" {
+		t.Errorf("Idle description = %q", s.Description)
+	}
+}
+
+func TestParse_StateStereotypesAndNotes(t *testing.T) {
+	source := "stateDiagram\n" +
+		"  state fork_state <<fork>>\n" +
+		"  state if_state <<choice>>\n" +
+		"  note right of fork_state : splits here\n" +
+		"  note left of if_state\n" +
+		"    first line\n" +
+		"    second line\n" +
+		"  end note"
+	d, _ := Parse(source, 1)
+
+	if d.State.States[0].Kind != "fork" || d.State.States[1].Kind != "choice" {
+		t.Errorf("unexpected kinds: %+v", d.State.States)
+	}
+	if len(d.State.Notes) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(d.State.Notes))
+	}
+	if n := d.State.Notes[0]; n.Placement != "right of" || n.State != "fork_state" || n.Text != "splits here" {
+		t.Errorf("note 0 = %+v", n)
+	}
+	if n := d.State.Notes[1]; n.Text != "first line\nsecond line" || n.Line != 5 {
+		t.Errorf("note 1 = %+v", n)
+	}
+}
+
+func TestParse_StateUnclosed(t *testing.T) {
+	source := "stateDiagram-v2\n" +
+		"  }\n" +
+		"  state Busy {\n" +
+		"    [*] --> Working\n" +
+		"    note right of Working\n" +
+		"      still going"
+	d, diags := Parse(source, 1)
+
+	want := []Diagnostic{
+		{Message: `"}" without an open composite state`, Line: 2, Column: 3, EndLine: 2, EndColumn: 4},
+		{Message: `state "Busy" is not closed with "}"`, Line: 3, Column: 3, EndLine: 3, EndColumn: 15},
+		{Message: `note is not closed with "end note"`, Line: 5, Column: 5, EndLine: 5, EndColumn: 26},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("diagnostics = %+v, want %+v", diags, want)
+	}
+	for i := range want {
+		if diags[i] != want[i] {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, diags[i], want[i])
+		}
+	}
+	if len(d.State.Notes) != 1 || d.State.Notes[0].Text != "still going" {
+		t.Errorf("notes = %+v", d.State.Notes)
+	}
+}
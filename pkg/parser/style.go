@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StyleProperty is a single CSS declaration, such as "fill:#f9f".
+type StyleProperty struct {
+	Name  string
+	Value string
+}
+
+// ClassDef is a "classDef" statement of a flowchart. A statement defining
+// several classes at once, as in "classDef a,b fill:#f9f", yields one
+// ClassDef per name.
+type ClassDef struct {
+	Name   string
+	Styles []StyleProperty
+	Line   int
+	Range  Range
+}
+
+// ClassAssignment applies a class to a node, from a "class" statement or
+// the ":::" shorthand. Node may also be the ID of a subgraph.
+type ClassAssignment struct {
+	Node  string
+	Class string
+	Line  int
+	Range Range // The statement, or the node reference for ":::"
+}
+
+// LinkStyle is a "linkStyle" statement. Indexes refer to Diagram.Edges,
+// in the order the edges are declared.
+type LinkStyle struct {
+	Indexes     []int
+	Default     bool   // Set by "linkStyle default"
+	Interpolate string // Curve name from "interpolate", e.g. "basis"
+	Styles      []StyleProperty
+	Line        int
+	Range       Range
+}
+
+// collectStyle records a classDef, class, style or linkStyle statement.
+// Inline node styles are gathered into nodeStyles by node ID, to be
+// applied once every node is known.
+func (d *Diagram) collectStyle(stmt *KeywordStatement, nodeStyles map[string][]StyleProperty) {
+	target, rest := splitKeyword(stmt.Text)
+	if target == "" {
+		d.addSpanDiagnostic(stmt.Range.Start.Offset, stmt.Range.End.Offset,
+			"%s statement has no arguments", stmt.Keyword)
+		return
+	}
+
+	switch stmt.Keyword {
+	case "classdef":
+		styles := parseStyles(rest)
+		for _, name := range splitList(target) {
+			d.ClassDefs = append(d.ClassDefs, ClassDef{Name: name, Styles: styles, Line: stmt.Line, Range: stmt.Range})
+		}
+	case "class":
+		if rest == "" {
+			d.addSpanDiagnostic(stmt.Range.Start.Offset, stmt.Range.End.Offset,
+				"class statement for %q has no class name", target)
+			return
+		}
+		for _, node := range splitList(target) {
+			for _, class := range splitList(rest) {
+				d.ClassAssignments = append(d.ClassAssignments, ClassAssignment{
+					Node:  node,
+					Class: class,
+					Line:  stmt.Line,
+					Range: stmt.Range,
+				})
+			}
+		}
+	case "style":
+		nodeStyles[target] = append(nodeStyles[target], parseStyles(rest)...)
+	case "linkstyle":
+		ls := LinkStyle{Line: stmt.Line, Range: stmt.Range}
+		if target == "default" {
+			ls.Default = true
+		} else {
+			for _, s := range splitList(target) {
+				n, err := strconv.Atoi(s)
+				if err != nil || n < 0 {
+					d.addSpanDiagnostic(stmt.Range.Start.Offset, stmt.Range.End.Offset,
+						"linkStyle index %q is not a non-negative number", s)
+					return
+				}
+				ls.Indexes = append(ls.Indexes, n)
+			}
+		}
+		if word, after := splitKeyword(rest); word == "interpolate" {
+			ls.Interpolate, rest = splitKeyword(after)
+		}
+		ls.Styles = parseStyles(rest)
+		d.LinkStyles = append(d.LinkStyles, ls)
+	}
+}
+
+// applyStyles copies class assignments and inline styles onto the nodes
+// they name. Assignments to unknown IDs, such as subgraphs, are kept only
+// in Diagram.ClassAssignments.
+func (d *Diagram) applyStyles(nodeStyles map[string][]StyleProperty) {
+	index := make(map[string]int, len(d.Nodes))
+	for i, n := range d.Nodes {
+		index[n.ID] = i
+	}
+	for _, a := range d.ClassAssignments {
+		i, ok := index[a.Node]
+		if !ok {
+			continue
+		}
+		if !slices.Contains(d.Nodes[i].Classes, a.Class) {
+			d.Nodes[i].Classes = append(d.Nodes[i].Classes, a.Class)
+		}
+	}
+	for id, styles := range nodeStyles {
+		if i, ok := index[id]; ok {
+			d.Nodes[i].Styles = styles
+		}
+	}
+}
+
+// parseStyles splits a CSS declaration list such as
+// "fill:#f9f,stroke:#333,stroke-width:4px". Commas inside parentheses, as
+// in "rgb(0,0,0)", do not separate declarations.
+func parseStyles(s string) []StyleProperty {
+	var styles []StyleProperty
+	depth, start := 0, 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch s[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth > 0 {
+					depth--
+				}
+			}
+			if s[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		decl := strings.TrimSpace(s[start:i])
+		start = i + 1
+		if decl == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(decl, ":")
+		styles = append(styles, StyleProperty{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	return styles
+}
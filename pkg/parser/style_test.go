@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse_FlowchartClassDefs(t *testing.T) {
+	src := "flowchart LR\n" +
+		"  classDef hot,warm fill:#f96,stroke:rgb(0,0,0),stroke-width:2px\n" +
+		"  A --> B"
+	d, diags := Parse(src, 1)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(d.ClassDefs) != 2 || d.ClassDefs[0].Name != "hot" || d.ClassDefs[1].Name != "warm" {
+		t.Fatalf("ClassDefs = %+v", d.ClassDefs)
+	}
+	want := []StyleProperty{{"fill", "#f96"}, {"stroke", "rgb(0,0,0)"}, {"stroke-width", "2px"}}
+	if !reflect.DeepEqual(d.ClassDefs[0].Styles, want) {
+		t.Errorf("Styles = %+v, want %+v", d.ClassDefs[0].Styles, want)
+	}
+	if d.ClassDefs[0].Line != 2 || d.ClassDefs[0].Range.Start.Column != 3 {
+		t.Errorf("position = line %d, range %+v", d.ClassDefs[0].Line, d.ClassDefs[0].Range)
+	}
+}
+
+func TestParse_FlowchartClassAssignments(t *testing.T) {
+	src := "flowchart LR\n" +
+		"  class A,C hot\n" +
+		"  A:::cold --> B[Label]:::hot\n" +
+		"  C"
+	d, _ := Parse(src, 1)
+
+	if len(d.ClassAssignments) != 4 {
+		t.Fatalf("expected 4 assignments, got %+v", d.ClassAssignments)
+	}
+	short := d.ClassAssignments[2]
+	if short.Node != "A" || short.Class != "cold" || short.Line != 3 {
+		t.Errorf("shorthand assignment = %+v", short)
+	}
+
+	classes := map[string][]string{}
+	for _, n := range d.Nodes {
+		classes[n.ID] = n.Classes
+	}
+	want := map[string][]string{"A": {"hot", "cold"}, "B": {"hot"}, "C": {"hot"}}
+	if !reflect.DeepEqual(classes, want) {
+		t.Errorf("node classes = %v, want %v", classes, want)
+	}
+	if d.Nodes[1].Label != "Label" || d.Nodes[1].Range.End.Column != 30 {
+		t.Errorf("node B = %+v", d.Nodes[1])
+	}
+}
+
+func TestParse_FlowchartNodeStyles(t *testing.T) {
+	src := "flowchart LR\n" +
+		"  style A fill:#f9f,stroke:#333\n" +
+		"  A --> B\n" +
+		"  style A color:red\n" +
+		"  style Missing fill:#000"
+	d, _ := Parse(src, 1)
+
+	want := []StyleProperty{{"fill", "#f9f"}, {"stroke", "#333"}, {"color", "red"}}
+	if !reflect.DeepEqual(d.Nodes[0].Styles, want) {
+		t.Errorf("A styles = %+v, want %+v", d.Nodes[0].Styles, want)
+	}
+	if d.Nodes[1].Styles != nil {
+		t.Errorf("B styles = %+v, want none", d.Nodes[1].Styles)
+	}
+}
+
+func TestParse_FlowchartLinkStyles(t *testing.T) {
+	src := "flowchart LR\n" +
+		"  A --> B --> C\n" +
+		"  linkStyle 0,1 stroke:#f00,stroke-width:4px\n" +
+		"  linkStyle default interpolate basis stroke:#999\n" +
+		"  linkStyle x color:red"
+	d, diags := Parse(src, 1)
+
+	if len(d.LinkStyles) != 2 {
+		t.Fatalf("expected 2 link styles, got %+v", d.LinkStyles)
+	}
+	first := d.LinkStyles[0]
+	if !reflect.DeepEqual(first.Indexes, []int{0, 1}) || first.Default || len(first.Styles) != 2 {
+		t.Errorf("first linkStyle = %+v", first)
+	}
+	def := d.LinkStyles[1]
+	if !def.Default || def.Interpolate != "basis" || !reflect.DeepEqual(def.Styles, []StyleProperty{{"stroke", "#999"}}) {
+		t.Errorf("default linkStyle = %+v", def)
+	}
+	if len(diags) != 1 || diags[0].Line != 5 {
+		t.Errorf("expected one diagnostic on line 5, got %+v", diags)
+	}
+}
+
+func TestParseStyles(t *testing.T) {
+	got := parseStyles("fill:#f9f, stroke-dasharray: 5 5,,font-family:Arial")
+	want := []StyleProperty{{"fill", "#f9f"}, {"stroke-dasharray", "5 5"}, {"font-family", "Arial"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStyles = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Timeline is the model of a timeline.
+type Timeline struct {
+	Title    string
+	Sections []TimelineSection
+	Periods  []TimelinePeriod
+}
+
+// TimelineSection is a section heading grouping the periods after it.
+type TimelineSection struct {
+	Name  string
+	Line  int
+	Range Range
+}
+
+// TimelinePeriod is a time period and its events, such as
+// "2004 : Facebook : Google".
+type TimelinePeriod struct {
+	Label   string
+	Section string // Enclosing section, empty before the first section
+	Events  []TimelineEvent
+	Line    int
+	Range   Range // Up to the last line continuing its events
+}
+
+// TimelineEvent is an event of a period. Events may continue on later
+// lines that start with a colon.
+type TimelineEvent struct {
+	Text  string
+	Line  int
+	Range Range // Span of the event text
+}
+
+func (d *Diagram) parseTimeline() {
+	tl := &Timeline{}
+	section := ""
+	d.forEachBodyLine(func(i int, line string) {
+		lineNum := d.StartLine + i
+		r := d.lineRange(lineNum)
+		keyword, rest := splitKeyword(line)
+		switch {
+		case keyword == "title":
+			tl.Title = rest
+		case keyword == "section":
+			section = rest
+			tl.Sections = append(tl.Sections, TimelineSection{Name: rest, Line: lineNum, Range: r})
+		case keyword == "accTitle:" || keyword == "accDescr:":
+			// Not part of the timeline model.
+		case strings.HasPrefix(line, ":"):
+			if len(tl.Periods) == 0 {
+				d.addDiagnostic(lineNum, "timeline event %q has no time period", strings.TrimSpace(line[1:]))
+				return
+			}
+			period := &tl.Periods[len(tl.Periods)-1]
+			period.Events = append(period.Events, d.timelineEvents(line[1:], r.Start.Offset+1)...)
+			period.Range.End = r.End
+		default:
+			label, events, _ := strings.Cut(line, ":")
+			tl.Periods = append(tl.Periods, TimelinePeriod{
+				Label:   strings.TrimSpace(label),
+				Section: section,
+				Events:  d.timelineEvents(events, r.Start.Offset+len(label)+1),
+				Line:    lineNum,
+				Range:   r,
+			})
+		}
+	})
+	d.Timeline = tl
+}
+
+// timelineEvents splits "a : b" into events. start is the offset of text
+// in the diagram source.
+func (d *Diagram) timelineEvents(text string, start int) []TimelineEvent {
+	var events []TimelineEvent
+	for _, event := range strings.Split(text, ":") {
+		if trimmed := strings.TrimSpace(event); trimmed != "" {
+			offset := start + strings.Index(event, trimmed)
+			r := d.span(offset, offset+len(trimmed))
+			events = append(events, TimelineEvent{Text: trimmed, Line: r.Start.Line, Range: r})
+		}
+		start += len(event) + 1
+	}
+	return events
+}
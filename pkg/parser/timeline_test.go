@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_Timeline(t *testing.T) {
+	source := "timeline\n" +
+		"  title History of Social Media\n" +
+		"  2002 : LinkedIn\n" +
+		"  section Growth\n" +
+		"    2004 : Facebook : Google\n" +
+		"         : Flickr\n" +
+		"    2005 : YouTube"
+	d, _ := Parse(source, 1)
+
+	tl := d.Timeline
+	if tl == nil {
+		t.Fatal("expected a timeline model")
+	}
+	if tl.Title != "History of Social Media" {
+		t.Errorf("title = %q", tl.Title)
+	}
+	if len(tl.Sections) != 1 || tl.Sections[0].Name != "Growth" || tl.Sections[0].Line != 4 {
+		t.Errorf("sections = %+v", tl.Sections)
+	}
+	if len(tl.Periods) != 3 {
+		t.Fatalf("expected 3 periods, got %d", len(tl.Periods))
+	}
+	if p := tl.Periods[0]; p.Label != "2002" || p.Section != "" || len(p.Events) != 1 {
+		t.Errorf("period 0 = %+v", p)
+	}
+
+	p := tl.Periods[1]
+	if p.Label != "2004" || p.Section != "Growth" || len(p.Events) != 3 {
+		t.Fatalf("period 1 = %+v", p)
+	}
+	if p.Events[1].Text != "Google" || p.Events[2].Text != "Flickr" || p.Events[2].Line != 6 {
+		t.Errorf("events = %+v", p.Events)
+	}
+}
+
+func TestParse_TimelineEventWithoutPeriod(t *testing.T) {
+	d, _ := Parse("timeline\n  : Orphan event\n  2001 : First", 1)
+
+	if len(d.Diagnostics) != 1 || d.Diagnostics[0].Line != 2 {
+		t.Errorf("expected a diagnostic at line 2, got %+v", d.Diagnostics)
+	}
+	if len(d.Timeline.Periods) != 1 {
+		t.Errorf("expected 1 period, got %d", len(d.Timeline.Periods))
+	}
+}
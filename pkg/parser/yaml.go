@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a line of frontmatter holding content.
+type yamlLine struct {
+	indent int    // Leading spaces
+	text   string // The line without its indentation
+	offset int    // Byte offset of text in the diagram source
+}
+
+// yamlParser reads the subset of YAML used in diagram frontmatter: nested
+// block mappings, block sequences, comments, plain and quoted scalars,
+// and flow collections written as JSON.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+	err   *configError
+}
+
+// parseYAML parses frontmatter lines into nested map[string]any, []any
+// and scalar values.
+func parseYAML(lines []yamlLine) (any, *configError) {
+	p := &yamlParser{lines: lines}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	v := p.node(lines[0].indent)
+	if p.err == nil && p.pos < len(p.lines) {
+		p.fail(p.lines[p.pos].offset, "unexpected indentation")
+	}
+	return v, p.err
+}
+
+func (p *yamlParser) fail(offset int, msg string) {
+	if p.err == nil {
+		p.err = &configError{offset: offset, msg: msg}
+	}
+}
+
+// node parses the mapping or sequence whose entries are indented by
+// indent.
+func (p *yamlParser) node(indent int) any {
+	if isYAMLListItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) map[string]any {
+	m := make(map[string]any)
+	for p.err == nil && p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			p.fail(l.offset, "unexpected indentation")
+			break
+		}
+		key, value, valueAt, ok := cutYAMLKey(l.text)
+		if !ok {
+			p.fail(l.offset, `expected "key: value"`)
+			break
+		}
+		p.pos++
+		m[key] = p.value(value, l.offset+valueAt, indent)
+	}
+	return m
+}
+
+func (p *yamlParser) sequence(indent int) []any {
+	var items []any
+	for p.err == nil && p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent || !isYAMLListItem(l.text) {
+			p.fail(l.offset, "unexpected indentation")
+			break
+		}
+		p.pos++
+		item := strings.TrimPrefix(l.text, "-")
+		trimmed := strings.TrimLeft(item, " ")
+		items = append(items, p.value(trimmed, l.offset+1+len(item)-len(trimmed), indent))
+	}
+	return items
+}
+
+// value parses the value of a key or list item. An empty value introduces
+// a nested block on the following, more indented lines.
+func (p *yamlParser) value(text string, offset, indent int) any {
+	if text == "" {
+		if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+			return p.node(p.lines[p.pos].indent)
+		}
+		return nil
+	}
+	v, err := parseYAMLScalar(text, offset)
+	if err != nil {
+		p.fail(err.offset, err.msg)
+	}
+	return v
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// cutYAMLKey splits "key: value" at the first colon that is followed by a
+// space or the end of the line and is outside quotes. It returns the
+// unquoted key, the value and the byte index of the value in text.
+func cutYAMLKey(text string) (key, value string, valueAt int, ok bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			key = strings.TrimSpace(text[:i])
+			if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+				key = key[1 : len(key)-1]
+			}
+			rest := text[i+1:]
+			value = strings.TrimSpace(rest)
+			valueAt = i + 1 + len(rest) - len(strings.TrimLeft(rest, " "))
+			return key, value, valueAt, key != ""
+		}
+	}
+	return "", "", 0, false
+}
+
+// parseYAMLScalar parses a scalar or flow collection starting at offset of
+// the diagram source. Plain scalars become bool, nil, float64 or string,
+// like their JSON counterparts.
+func parseYAMLScalar(text string, offset int) (any, *configError) {
+	switch text[0] {
+	case '#':
+		return nil, nil // A comment in place of the value
+	case '{', '[':
+		return parseYAMLFlow(text, offset)
+	case '"':
+		end := closingQuote(text)
+		if end < 0 {
+			return nil, &configError{offset: offset, msg: "unterminated quoted string"}
+		}
+		s, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, &configError{offset: offset, msg: "invalid quoted string"}
+		}
+		return s, trailingYAML(text, end+1, offset)
+	case '\'':
+		for i := 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '\'' {
+				i++ // '' is an escaped quote
+				continue
+			}
+			return strings.ReplaceAll(text[1:i], "''", "'"), trailingYAML(text, i+1, offset)
+		}
+		return nil, &configError{offset: offset, msg: "unterminated quoted string"}
+	}
+
+	if i := strings.Index(text, " #"); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	switch text {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f, nil
+	}
+	return text, nil
+}
+
+// closingQuote returns the index of the double quote ending the string
+// that starts text, or -1.
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// trailingYAML reports anything but a comment after a quoted scalar.
+func trailingYAML(text string, end, offset int) *configError {
+	rest := strings.TrimLeft(text[end:], " ")
+	if rest == "" || strings.HasPrefix(rest, "#") {
+		return nil
+	}
+	return &configError{offset: offset + len(text) - len(rest), msg: "unexpected text after quoted string"}
+}
@@ -0,0 +1,92 @@
+package printer
+
+import (
+	"strconv"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// block lays out a block diagram, one block per line. Edges go into the
+// composite block holding their source line, after the blocks of that
+// line, so that the blocks they join are defined first.
+func (p *printer) block(bd *parser.BlockDiagram) []stmt {
+	edges := make(map[*parser.Block][]stmt)
+	for _, e := range bd.Edges {
+		link := e.Link
+		if link == "" {
+			link = "-->"
+		}
+		text := e.From + " " + link
+		if e.Label != "" {
+			text += "|" + e.Label + "|"
+		}
+		composite := blockAt(bd.Blocks, e.Line)
+		edges[composite] = append(edges[composite], at(e.Range, text+" "+e.To))
+	}
+	return blockBody(bd.Blocks, bd.Columns, edges[nil], edges)
+}
+
+// blockAt returns the innermost composite block holding line, or nil.
+func blockAt(blocks []*parser.Block, line int) *parser.Block {
+	for _, b := range blocks {
+		if b.Kind != "composite" || line == 0 || line <= b.Line || b.EndLine != 0 && line >= b.EndLine {
+			continue
+		}
+		if inner := blockAt(b.Children, line); inner != nil {
+			return inner
+		}
+		return b
+	}
+	return nil
+}
+
+// blockBody returns the statements of a diagram or composite block.
+func blockBody(blocks []*parser.Block, columns int, own []stmt, edges map[*parser.Block][]stmt) []stmt {
+	var stmts []stmt
+	if columns > 0 {
+		stmts = append(stmts, stmt{text: "columns " + strconv.Itoa(columns)})
+	}
+	for _, b := range blocks {
+		s := at(b.Range, blockSource(b))
+		if b.Kind == "composite" {
+			s.text = "block:" + b.ID + span(b)
+			s.body = blockBody(b.Children, b.Columns, edges[b], edges)
+			if s.body == nil {
+				s.body = []stmt{}
+			}
+			s.close, s.end = "end", b.EndLine
+		}
+		stmts = append(stmts, s)
+	}
+	return sortByLine(append(stmts, own...))
+}
+
+func blockSource(b *parser.Block) string {
+	switch b.Kind {
+	case "space":
+		return "space" + span(b)
+	case "arrow":
+		s := b.ID + `<["` + b.Label + `"]>`
+		if b.Direction != "" {
+			s += "(" + b.Direction + ")"
+		}
+		return s + span(b)
+	}
+	s := b.ID
+	if b.Shape != "" {
+		delims, ok := shapeDelimiters[b.Shape]
+		if !ok {
+			delims = shapeDelimiters["rect"]
+		}
+		s += delims[0] + labelSource(parser.LabelText{}, b.Label, delims[1]) + delims[1]
+	}
+	return s + span(b)
+}
+
+// span returns the ":n" suffix of a block spanning n columns.
+func span(b *parser.Block) string {
+	if b.Span > 1 {
+		return ":" + strconv.Itoa(b.Span)
+	}
+	return ""
+}
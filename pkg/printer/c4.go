@@ -0,0 +1,214 @@
+package printer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// c4 lays out a C4 diagram. Elements and boundaries go into the boundary
+// they name; relationships and style updates into the boundary holding
+// their source line.
+func (p *printer) c4(c *parser.C4Diagram) []stmt {
+	items := make(map[string][]stmt) // by boundary alias
+	for _, e := range c.Elements {
+		items[e.Boundary] = append(items[e.Boundary], at(e.Range, c4ElementSource(e)))
+	}
+	for _, rel := range c.Relationships {
+		scope := c4BoundaryAt(c, rel.Line)
+		items[scope] = append(items[scope], at(rel.Range, c4RelationshipSource(rel)))
+	}
+	for _, s := range c.Styles {
+		args := c4ArgsOf(s.Args)
+		for i, target := range s.Targets {
+			args.set(i, "", target)
+		}
+		scope := c4BoundaryAt(c, s.Line)
+		items[scope] = append(items[scope], at(s.Range, s.Macro+args.source(len(s.Targets))))
+	}
+
+	stmts := p.c4Body(c, "", items)
+	if c.Title != "" {
+		stmts = sortByLine(append([]stmt{p.keyed("title", "title "+c.Title)}, stmts...))
+	}
+	return stmts
+}
+
+// c4BoundaryAt returns the alias of the innermost boundary holding line,
+// or "" for lines outside every boundary and statements made in code.
+func c4BoundaryAt(c *parser.C4Diagram, line int) string {
+	alias, opened := "", 0
+	for _, b := range c.Boundaries {
+		if line > 0 && b.Line < line && (b.EndLine == 0 || line < b.EndLine) && b.Line > opened {
+			alias, opened = b.Alias, b.Line
+		}
+	}
+	return alias
+}
+
+// c4Body returns the statements inside the boundary alias, or at the top
+// level for "".
+func (p *printer) c4Body(c *parser.C4Diagram, alias string, items map[string][]stmt) []stmt {
+	stmts := items[alias]
+	for _, b := range c.Boundaries {
+		if b.Parent != alias {
+			continue
+		}
+		args := c4ArgsOf(b.Args)
+		args.set(0, "", b.Alias)
+		args.set(1, "", b.Label)
+		switch b.Macro {
+		case "System_Boundary", "Container_Boundary", "Enterprise_Boundary":
+		default:
+			args.set(2, "type", b.Type)
+			args.set(3, "descr", b.Description)
+		}
+		body := []stmt{}
+		if b.Alias != "" {
+			body = append(body, p.c4Body(c, b.Alias, items)...)
+		}
+		stmts = append(stmts, stmt{
+			line:  b.Line,
+			r:     b.Range,
+			text:  b.Macro + args.source(1) + " {",
+			body:  body,
+			close: "}",
+			end:   b.EndLine,
+		})
+	}
+	return sortByLine(stmts)
+}
+
+// c4ElementMacros gives the parts of an element macro: one for each kind,
+// followed by one for its storage, if any.
+var c4ElementMacros = map[string]string{
+	"person":    "Person",
+	"system":    "System",
+	"container": "Container",
+	"component": "Component",
+	"db":        "Db",
+	"queue":     "Queue",
+}
+
+func c4ElementSource(e parser.C4Element) string {
+	macro := e.Macro
+	if stem, ok := c4ElementMacros[e.Kind]; ok {
+		macro = stem + c4ElementMacros[e.Storage]
+		if e.External {
+			macro += "_Ext"
+		}
+	}
+	args := c4ArgsOf(e.Args)
+	args.set(0, "", e.Alias)
+	args.set(1, "", e.Label)
+	if e.Kind == "container" || e.Kind == "component" {
+		args.set(2, "techn", e.Technology)
+		args.set(3, "descr", e.Description)
+	} else {
+		args.set(2, "descr", e.Description)
+	}
+	return macro + args.source(1)
+}
+
+// c4RelMacros gives the relationship macro of each direction.
+var c4RelMacros = map[string]string{
+	"up": "Rel_U", "down": "Rel_D", "left": "Rel_L", "right": "Rel_R", "back": "Rel_Back",
+}
+
+func c4RelationshipSource(rel parser.C4Relationship) string {
+	macro := rel.Macro
+	switch {
+	case rel.Index != "":
+		macro = "RelIndex"
+	case rel.Bidirectional:
+		macro = "BiRel"
+	case macro == "" || macro == "BiRel" || macro == "RelIndex" || c4RelDirection(macro) != rel.Direction:
+		macro = "Rel"
+		if rel.Direction != "" {
+			macro = c4RelMacros[rel.Direction]
+		}
+	}
+
+	// The index of a RelIndex comes before the other arguments.
+	args := c4ArgsOf(rel.Args)
+	if rel.Macro == "RelIndex" && len(args.positional) > 0 {
+		args.positional = args.positional[1:]
+	}
+	offset, bare := 0, 2
+	if macro == "RelIndex" {
+		args.positional = append([]string{rel.Index}, args.positional...)
+		offset, bare = 1, 3
+	}
+	args.set(offset, "", rel.From)
+	args.set(offset+1, "", rel.To)
+	args.set(offset+2, "", rel.Label)
+	args.set(offset+3, "techn", rel.Technology)
+	args.set(offset+4, "descr", rel.Description)
+	return macro + args.source(bare)
+}
+
+// c4RelDirection returns the direction of a relationship macro, such as
+// "up" for "Rel_U" and "Rel_Up".
+func c4RelDirection(macro string) string {
+	for direction, short := range c4RelMacros {
+		if macro == short || direction != "back" && macro == "Rel_"+strings.ToUpper(direction[:1])+direction[1:] {
+			return direction
+		}
+	}
+	return ""
+}
+
+// c4Args is a copy of the arguments of a C4 macro being printed.
+type c4Args struct {
+	positional []string
+	named      map[string]string
+}
+
+func c4ArgsOf(args parser.C4Args) c4Args {
+	c := c4Args{positional: append([]string(nil), args.Positional...)}
+	if args.Named != nil {
+		c.named = make(map[string]string, len(args.Named))
+		for k, v := range args.Named {
+			c.named[k] = v
+		}
+	}
+	return c
+}
+
+// set writes the value of argument i, or of the named argument if it was
+// given by name.
+func (a *c4Args) set(i int, name, value string) {
+	if _, ok := a.named[name]; ok && name != "" {
+		a.named[name] = value
+		return
+	}
+	if i >= len(a.positional) {
+		if value == "" {
+			return
+		}
+		a.positional = append(a.positional, make([]string, i+1-len(a.positional))...)
+	}
+	a.positional[i] = value
+}
+
+// source returns the argument list in parentheses. The first bare
+// arguments, such as aliases, are written without quotes.
+func (a c4Args) source(bare int) string {
+	parts := make([]string, 0, len(a.positional)+len(a.named))
+	for i, arg := range a.positional {
+		if i >= bare {
+			arg = dquote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	names := make([]string, 0, len(a.named))
+	for name := range a.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, "$"+name+"="+dquote(a.named[name]))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
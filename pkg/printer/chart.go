@@ -0,0 +1,148 @@
+package printer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// pie lays out a pie chart. Its title stays in the type declaration if
+// the source has it there.
+func (p *printer) pie(header string, pie *parser.PieChart) (string, []stmt) {
+	if pie.ShowData {
+		header += " showData"
+	}
+	var stmts []stmt
+	if pie.Title != "" {
+		title := p.keyed("title", "title "+pie.Title)
+		if title.line == 0 && p.header >= 0 && strings.Contains(p.lines[p.header], "title") {
+			header += " title " + pie.Title
+		} else {
+			stmts = append(stmts, title)
+		}
+	}
+	for _, s := range pie.Slices {
+		value, ok := number(s.Value)
+		stmts = append(stmts, p.numbered(s.Range, dquote(s.Label)+" : "+value, ok))
+	}
+	return header, sortByLine(stmts)
+}
+
+// quadrant lays out a quadrant chart.
+func (p *printer) quadrant(q *parser.QuadrantChart) []stmt {
+	var stmts []stmt
+	if q.Title != "" {
+		stmts = append(stmts, p.keyed("title", "title "+q.Title))
+	}
+	for _, axis := range []struct {
+		key  string
+		axis parser.QuadrantAxis
+	}{{"x-axis", q.XAxis}, {"y-axis", q.YAxis}} {
+		if axis.axis.Line == 0 && axis.axis.Low == "" && axis.axis.High == "" {
+			continue
+		}
+		text := axis.key + " " + axis.axis.Low
+		if axis.axis.High != "" {
+			text += " --> " + axis.axis.High
+		}
+		stmts = append(stmts, at(axis.axis.Range, text))
+	}
+	for i, label := range q.Quadrants {
+		if label != "" {
+			key := "quadrant-" + string(rune('1'+i))
+			stmts = append(stmts, p.keyed(key, key+" "+label))
+		}
+	}
+	for _, pt := range q.Points {
+		stmts = append(stmts, p.quadrantPoint(pt))
+	}
+	return sortByLine(stmts)
+}
+
+func (p *printer) quadrantPoint(pt parser.QuadrantPoint) stmt {
+	text := pt.Label
+	if strings.ContainsAny(text, `:"[`) {
+		text = dquote(text)
+	}
+	if pt.Class != "" {
+		text += ":::" + pt.Class
+	}
+	x, okX := number(pt.X)
+	y, okY := number(pt.Y)
+	text += ": [" + x + ", " + y + "]"
+	if len(pt.Styles) > 0 {
+		keys := make([]string, 0, len(pt.Styles))
+		for key := range pt.Styles {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			keys[i] = key + ": " + pt.Styles[key]
+		}
+		text += " " + strings.Join(keys, ", ")
+	}
+	return p.numbered(pt.Range, text, okX && okY)
+}
+
+// xyChart lays out an xychart-beta chart.
+func (p *printer) xyChart(xy *parser.XYChart) []stmt {
+	var stmts []stmt
+	if xy.Title != "" {
+		stmts = append(stmts, p.keyed("title", "title "+dquote(xy.Title)))
+	}
+	for _, axis := range []struct {
+		key  string
+		axis parser.XYAxis
+	}{{"x-axis", xy.XAxis}, {"y-axis", xy.YAxis}} {
+		if axis.axis.Line == 0 && axis.axis.Title == "" && axis.axis.Categories == nil && !axis.axis.HasRange {
+			continue
+		}
+		stmts = append(stmts, p.xyAxis(axis.key, axis.axis))
+	}
+	for _, series := range xy.Series {
+		text := series.Kind
+		if series.Title != "" {
+			text += " " + dquote(series.Title)
+		}
+		values := make([]string, len(series.Values))
+		ok := true
+		for i, v := range series.Values {
+			var valid bool
+			values[i], valid = number(v)
+			ok = ok && valid
+		}
+		text += " [" + strings.Join(values, ", ") + "]"
+		stmts = append(stmts, p.numbered(series.Range, text, ok))
+	}
+	return sortByLine(stmts)
+}
+
+// xyCategory matches axis categories that need no quotes.
+var xyCategory = regexp.MustCompile(`^[^\s",\[\]]+$`)
+
+func (p *printer) xyAxis(key string, axis parser.XYAxis) stmt {
+	text := key
+	if axis.Title != "" {
+		text += " " + dquote(axis.Title)
+	}
+	ok := true
+	switch {
+	case axis.Categories != nil:
+		items := make([]string, len(axis.Categories))
+		for i, c := range axis.Categories {
+			items[i] = c
+			if !xyCategory.MatchString(c) {
+				items[i] = dquote(c)
+			}
+		}
+		text += " [" + strings.Join(items, ", ") + "]"
+	case axis.HasRange:
+		low, okLow := number(axis.Min)
+		high, okHigh := number(axis.Max)
+		text += " " + low + " --> " + high
+		ok = okLow && okHigh
+	}
+	return p.numbered(axis.Range, text, ok)
+}
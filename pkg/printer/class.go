@@ -0,0 +1,139 @@
+package printer
+
+import (
+	"regexp"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// class lays out a class diagram: classes in their namespaces, and the
+// relationships between them. Classes only used in relationships are
+// left to the relationships, unless they have details of their own.
+func (p *printer) class(cd *parser.ClassDiagram) []stmt {
+	var stmts []stmt
+	if cd.Direction != "" {
+		stmts = append(stmts, p.keyed("direction", "direction "+cd.Direction))
+	}
+
+	namespaces := make(map[string][]stmt)
+	for _, ns := range cd.Namespaces {
+		namespaces[ns.Name] = nil
+	}
+	for i := range cd.Classes {
+		c := &cd.Classes[i]
+		if c.Implicit && c.Label == "" && c.Generic == "" && len(c.Annotations) == 0 && len(c.Members) == 0 {
+			continue
+		}
+		s := p.classStmt(c)
+		if _, ok := namespaces[c.Namespace]; ok && c.Namespace != "" {
+			namespaces[c.Namespace] = append(namespaces[c.Namespace], s)
+		} else {
+			stmts = append(stmts, s)
+		}
+	}
+	for _, ns := range cd.Namespaces {
+		body := namespaces[ns.Name]
+		if body == nil {
+			body = []stmt{}
+		}
+		namespaces[ns.Name] = nil // A namespace written twice holds its classes once
+		stmts = append(stmts, stmt{
+			line:  ns.Line,
+			r:     ns.Range,
+			text:  "namespace " + ns.Name + " {",
+			body:  sortByLine(body),
+			close: "}",
+			end:   ns.EndLine,
+		})
+	}
+	for _, rel := range cd.Relationships {
+		stmts = append(stmts, at(rel.Range, classRelationshipSource(rel)))
+	}
+	return sortByLine(stmts)
+}
+
+// classStmt returns the declaration of a class, with a body holding its
+// annotations and members if it has any.
+func (p *printer) classStmt(c *parser.Class) stmt {
+	header := "class " + classID(c.ID)
+	if c.Generic != "" {
+		header += "~" + c.Generic + "~"
+	}
+	if c.Label != "" {
+		header += `["` + c.Label + `"]`
+	}
+	s := stmt{line: c.Line, r: c.Range, text: header}
+	if len(c.Annotations) == 0 && len(c.Members) == 0 {
+		return s
+	}
+
+	for _, a := range c.Annotations {
+		s.body = append(s.body, stmt{text: "<<" + a + ">>"})
+	}
+	for _, m := range c.Members {
+		member := stmt{line: m.Line, text: classMemberSource(m)}
+		if c.Line < m.Line && m.Line < c.Range.End.Line {
+			// Written in the body of the class rather than as "ID : member".
+			member.r = m.Range
+		}
+		s.body = append(s.body, member)
+	}
+	s.text += " {"
+	s.close = "}"
+	if c.Range.End.Line > c.Line {
+		s.end = c.Range.End.Line
+	}
+	return s
+}
+
+// classWord matches class names that need no backticks.
+var classWord = regexp.MustCompile(`^\w+$`)
+
+func classID(id string) string {
+	if classWord.MatchString(id) {
+		return id
+	}
+	return "`" + id + "`"
+}
+
+func classMemberSource(m parser.ClassMember) string {
+	s := m.Visibility
+	if m.Method {
+		s += m.Name + "(" + m.Parameters + ")"
+		if m.Type != "" {
+			s += " " + m.Type
+		}
+	} else {
+		if m.Type != "" {
+			s += m.Type + " "
+		}
+		s += m.Name
+	}
+	switch {
+	case m.Static:
+		s += "$"
+	case m.Abstract:
+		s += "*"
+	}
+	return s
+}
+
+func classRelationshipSource(rel parser.ClassRelationship) string {
+	s := classID(rel.From)
+	if rel.FromCardinality != "" {
+		s += ` "` + rel.FromCardinality + `"`
+	}
+	link := rel.Link
+	if link == "" {
+		link = "--"
+	}
+	s += " " + rel.FromHead + link + rel.ToHead
+	if rel.ToCardinality != "" {
+		s += ` "` + rel.ToCardinality + `"`
+	}
+	s += " " + classID(rel.To)
+	if rel.Label != "" {
+		s += " : " + rel.Label
+	}
+	return s
+}
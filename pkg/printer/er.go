@@ -0,0 +1,75 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// er lays out an entity relationship diagram. Entities only used in
+// relationships are left to the relationships, unless they have details
+// of their own.
+func (p *printer) er(ed *parser.ERDiagram) []stmt {
+	var stmts []stmt
+	for i := range ed.Entities {
+		e := &ed.Entities[i]
+		if e.Implicit && e.Alias == "" && len(e.Attributes) == 0 {
+			continue
+		}
+		s := stmt{line: e.Line, r: e.Range, text: name(e.Name)}
+		if e.Alias != "" {
+			s.text += `["` + e.Alias + `"]`
+		}
+		if len(e.Attributes) > 0 {
+			s.text += " {"
+			for _, a := range e.Attributes {
+				s.body = append(s.body, at(a.Range, attributeSource(a)))
+			}
+			s.close, s.end = "}", e.EndLine
+		}
+		stmts = append(stmts, s)
+	}
+	for _, rel := range ed.Relationships {
+		stmts = append(stmts, at(rel.Range, erRelationshipSource(rel)))
+	}
+	return sortByLine(stmts)
+}
+
+func attributeSource(a parser.Attribute) string {
+	s := a.Type + " " + a.Name
+	if len(a.Keys) > 0 {
+		s += " " + strings.Join(a.Keys, ", ")
+	}
+	if a.Comment != "" {
+		s += " " + dquote(a.Comment)
+	}
+	return s
+}
+
+// erMarkers gives the crow's foot markers of each cardinality, as written
+// on the left and on the right of the line.
+var erMarkers = map[string][2]string{
+	"zero-or-one":  {"|o", "o|"},
+	"exactly-one":  {"||", "||"},
+	"zero-or-more": {"}o", "o{"},
+	"one-or-more":  {"}|", "|{"},
+}
+
+func erRelationshipSource(rel parser.ERRelationship) string {
+	from, to := rel.FromMarker, rel.ToMarker
+	if from == "" {
+		from = erMarkers[rel.FromCardinality][0]
+	}
+	if to == "" {
+		to = erMarkers[rel.ToCardinality][1]
+	}
+	link := ".."
+	if rel.Identifying {
+		link = "--"
+	}
+	s := name(rel.From) + " " + from + link + to + " " + name(rel.To)
+	if rel.Label != "" {
+		s += " : " + name(rel.Label)
+	}
+	return s
+}
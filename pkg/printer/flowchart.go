@@ -0,0 +1,117 @@
+package printer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// isFlowchart reports whether d is a flowchart, or a diagram made in code
+// with only nodes and edges.
+func isFlowchart(d *parser.Diagram) bool {
+	switch d.Type {
+	case parser.DiagramFlowchart, parser.DiagramGraph:
+		return true
+	case "":
+		return len(d.Nodes) > 0 || len(d.Edges) > 0
+	}
+	return false
+}
+
+// flowchartOf builds the syntax tree of a flowchart that has none, such as
+// one made in code, from its nodes, edges, subgraphs and styles.
+func flowchartOf(d *parser.Diagram) *parser.Flowchart {
+	nodes := make(map[string][]parser.FlowchartStatement) // by subgraph ID
+	var styles []parser.FlowchartStatement
+	for _, n := range d.Nodes {
+		ref := parser.NodeRef{ID: n.ID, Label: n.Label, LabelText: n.LabelText, Shape: n.Shape, Attrs: n.Attrs, Classes: n.Classes}
+		if ref.Shape == "" && ref.Attrs == nil && n.Label != "" && n.Label != n.ID {
+			ref.Shape = "rect"
+		}
+		nodes[n.Subgraph] = append(nodes[n.Subgraph], &parser.ChainStatement{Groups: [][]parser.NodeRef{{ref}}})
+		if len(n.Styles) > 0 {
+			styles = append(styles, &parser.KeywordStatement{Keyword: "style", Text: n.ID + " " + styleList(n.Styles)})
+		}
+	}
+
+	var body func(parent string) []parser.FlowchartStatement
+	body = func(parent string) []parser.FlowchartStatement {
+		stmts := nodes[parent]
+		for _, sg := range d.Subgraphs {
+			if sg.Parent != parent {
+				continue
+			}
+			var inner []parser.FlowchartStatement
+			if sg.Direction != "" {
+				inner = append(inner, &parser.KeywordStatement{Keyword: "direction", Text: sg.Direction})
+			}
+			stmts = append(stmts, &parser.SubgraphStatement{
+				ID:        sg.ID,
+				Title:     sg.Title,
+				Direction: sg.Direction,
+				Body:      append(inner, body(sg.ID)...),
+			})
+		}
+		return stmts
+	}
+	stmts := body("")
+
+	known := make(map[string]bool, len(d.Nodes))
+	for _, n := range d.Nodes {
+		known[n.ID] = true
+	}
+	for _, e := range d.Edges {
+		stmts = append(stmts, &parser.ChainStatement{
+			Groups: [][]parser.NodeRef{{{ID: e.From}}, {{ID: e.To}}},
+			Links:  []parser.Link{{ID: e.ID, Style: e.Style, Label: e.Label, LabelText: e.LabelText}},
+		})
+		if e.ID != "" && e.Attrs != nil {
+			stmts = append(stmts, &parser.ChainStatement{Groups: [][]parser.NodeRef{{{ID: e.ID, Attrs: e.Attrs}}}})
+		}
+	}
+
+	for _, def := range d.ClassDefs {
+		stmts = append(stmts, &parser.KeywordStatement{Keyword: "classdef", Text: def.Name + " " + styleList(def.Styles)})
+	}
+	for _, a := range d.ClassAssignments {
+		// Classes of nodes are written with ":::".
+		if !known[a.Node] {
+			stmts = append(stmts, &parser.KeywordStatement{Keyword: "class", Text: a.Node + " " + a.Class})
+		}
+	}
+	stmts = append(stmts, styles...)
+	for _, ls := range d.LinkStyles {
+		stmts = append(stmts, &parser.KeywordStatement{Keyword: "linkstyle", Text: linkStyleText(ls)})
+	}
+	return &parser.Flowchart{Statements: stmts}
+}
+
+// styleList returns CSS declarations as written after "style" and
+// "classDef": "fill:#f9f,stroke:#333".
+func styleList(styles []parser.StyleProperty) string {
+	parts := make([]string, len(styles))
+	for i, s := range styles {
+		parts[i] = s.Name + ":" + s.Value
+	}
+	return strings.Join(parts, ",")
+}
+
+// linkStyleText returns the arguments of a "linkStyle" statement.
+func linkStyleText(ls parser.LinkStyle) string {
+	target := "default"
+	if !ls.Default {
+		indexes := make([]string, len(ls.Indexes))
+		for i, n := range ls.Indexes {
+			indexes[i] = strconv.Itoa(n)
+		}
+		target = strings.Join(indexes, ",")
+	}
+	if ls.Interpolate != "" {
+		target += " interpolate " + ls.Interpolate
+	}
+	if len(ls.Styles) > 0 {
+		target += " " + styleList(ls.Styles)
+	}
+	return target
+}
@@ -0,0 +1,93 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// gantt lays out a gantt chart: its settings, then its tasks, each in the
+// section named by its Section.
+func (p *printer) gantt(g *parser.GanttChart) []stmt {
+	var stmts []stmt
+	for _, setting := range []struct{ key, value string }{
+		{"title", g.Title},
+		{"dateFormat", g.DateFormat},
+		{"axisFormat", g.AxisFormat},
+		{"tickInterval", g.TickInterval},
+		{"todayMarker", g.TodayMarker},
+		{"weekday", g.Weekday},
+		{"excludes", strings.Join(g.Excludes, ", ")},
+		{"includes", strings.Join(g.Includes, ", ")},
+	} {
+		if setting.value != "" {
+			stmts = append(stmts, p.keyed(setting.key, setting.key+" "+setting.value))
+		}
+	}
+
+	sections := make([]sectionRef, len(g.Sections))
+	for i, s := range g.Sections {
+		sections[i] = sectionRef{s.Name, s.Line}
+	}
+	bodies := make([][]stmt, len(sections))
+	for _, t := range g.Tasks {
+		s := at(t.Range, ganttTaskSource(t))
+		if i := sectionOf(sections, t.Section, t.Line); i >= 0 {
+			bodies[i] = append(bodies[i], s)
+		} else {
+			stmts = append(stmts, s)
+		}
+	}
+	for i, s := range g.Sections {
+		stmts = append(stmts, sectionStmt(s.Range, s.Name, bodies[i]))
+	}
+	return sortByLine(stmts)
+}
+
+func ganttTaskSource(t parser.GanttTask) string {
+	fields := append([]string(nil), t.Tags...)
+	for _, f := range []string{t.ID, t.Start, t.End} {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return t.Name + " : " + strings.Join(fields, ", ")
+}
+
+// sectionRef is a section heading of a gantt chart or timeline.
+type sectionRef struct {
+	name string
+	line int
+}
+
+// sectionOf returns the index of the section holding an item of the named
+// section read from line: the last section of that name before the line,
+// or else the first of that name. It returns -1 for items outside every
+// section.
+func sectionOf(sections []sectionRef, name string, line int) int {
+	first, last := -1, -1
+	for i, s := range sections {
+		if s.name != name {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if s.line < line {
+			last = i
+		}
+	}
+	if last >= 0 {
+		return last
+	}
+	return first
+}
+
+// sectionStmt returns a section heading with the items in it.
+func sectionStmt(r parser.Range, name string, body []stmt) stmt {
+	s := at(r, "section "+name)
+	if len(body) > 0 {
+		s.body = sortByLine(body)
+	}
+	return s
+}
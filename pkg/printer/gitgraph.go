@@ -0,0 +1,41 @@
+package printer
+
+import (
+	"strconv"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// gitGraph lays out a gitGraph from its commands; its branches and
+// commits are what the commands produce.
+func (p *printer) gitGraph(g *parser.GitGraph) []stmt {
+	stmts := make([]stmt, len(g.Commands))
+	for i, cmd := range g.Commands {
+		stmts[i] = at(cmd.Range, gitCommandSource(cmd))
+	}
+	return stmts
+}
+
+func gitCommandSource(cmd parser.GitCommand) string {
+	s := cmd.Kind
+	switch cmd.Kind {
+	case "branch", "checkout", "merge":
+		s += " " + name(cmd.Branch)
+	}
+	for _, attr := range []struct{ key, value string }{
+		{"id", cmd.ID},
+		{"parent", cmd.Parent},
+		{"tag", cmd.Tag},
+	} {
+		if attr.value != "" {
+			s += " " + attr.key + ": " + dquote(attr.value)
+		}
+	}
+	if cmd.Type != "" {
+		s += " type: " + cmd.Type
+	}
+	if cmd.Kind == "branch" && cmd.Order >= 0 {
+		s += " order: " + strconv.Itoa(cmd.Order)
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// mindmapShapes gives the delimiters of each mindmap node shape.
+var mindmapShapes = map[string][2]string{
+	"square":  {"[", "]"},
+	"rounded": {"(", ")"},
+	"circle":  {"((", "))"},
+	"bang":    {"))", "(("},
+	"cloud":   {")", "("},
+	"hexagon": {"{{", "}}"},
+}
+
+// mindmap lays out a mindmap, nesting each node below its parent. Nodes
+// are always printed in canonical form, since their indentation places
+// them in the tree.
+func (p *printer) mindmap(mm *parser.Mindmap) []stmt {
+	var stmts []stmt
+	for _, root := range mm.Roots {
+		stmts = append(stmts, mindmapNode(root))
+	}
+	return stmts
+}
+
+func mindmapNode(n *parser.MindmapNode) stmt {
+	s := stmt{line: n.Line, text: mindmapNodeSource(n)}
+	if n.Icon != "" {
+		s.body = append(s.body, stmt{text: "::icon(" + n.Icon + ")"})
+	}
+	if len(n.Classes) > 0 {
+		s.body = append(s.body, stmt{text: ":::" + strings.Join(n.Classes, " ")})
+	}
+	for _, child := range n.Children {
+		s.body = append(s.body, mindmapNode(child))
+	}
+	return s
+}
+
+func mindmapNodeSource(n *parser.MindmapNode) string {
+	delims, ok := mindmapShapes[n.Shape]
+	if !ok {
+		if n.Label != "" {
+			return n.Label
+		}
+		return n.ID
+	}
+	label := n.Label
+	if strings.ContainsAny(label, "()[]{}") {
+		label = dquote(label)
+	}
+	id := n.ID
+	if id == n.Label {
+		id = ""
+	}
+	return id + delims[0] + label + delims[1]
+}
@@ -0,0 +1,415 @@
+package printer
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// stmt is a statement of a diagram model, laid out for printing. A
+// statement with a body or a closing line is a block, whose text is its
+// opening line.
+type stmt struct {
+	line  int          // File line the statement was read from, 0 if made in code
+	r     parser.Range // Source the statement can be printed from, if any
+	text  string       // Canonical form
+	body  []stmt
+	close string // Line closing a block, such as "end" or "}"
+	end   int    // File line of close, 0 if none
+}
+
+// at returns a statement read from the source span r.
+func at(r parser.Range, text string) stmt {
+	return stmt{line: r.Start.Line, r: r, text: text}
+}
+
+func (s stmt) isBlock() bool {
+	return s.body != nil || s.close != ""
+}
+
+// modelLines matches, for each diagram type, the body lines its model is
+// read from that are not covered by the source ranges of its statements,
+// such as "title" lines, closing braces and the class lines of mindmap
+// nodes. Other lines the parser does not model are printed as they are.
+var modelLines = map[parser.DiagramType]*regexp.Regexp{
+	parser.DiagramSequence:     regexp.MustCompile(`^(?i:end)$`),
+	parser.DiagramClass:        regexp.MustCompile(`^(<<.*|class\s.*|direction(\s.*)?|\})$`),
+	parser.DiagramState:        stateLines,
+	parser.DiagramStateV2:      stateLines,
+	parser.DiagramER:           regexp.MustCompile(`^(\}|([\w-]+|"[^"]+")(\[[^\]]*\])?\s*(\{\s*\}?)?)$`),
+	parser.DiagramGantt:        regexp.MustCompile(`^(title|dateFormat|axisFormat|tickInterval|todayMarker|weekday|excludes|includes)(\s.*)?$`),
+	parser.DiagramPie:          regexp.MustCompile(`^(title|showData)(\s.*)?$`),
+	parser.DiagramQuadrant:     regexp.MustCompile(`^(title|x-axis|y-axis|quadrant-[1-4])(\s.*)?$`),
+	parser.DiagramXYChart:      regexp.MustCompile(`^(title|x-axis|y-axis)(\s.*)?$`),
+	parser.DiagramMindmap:      regexp.MustCompile(`^(::icon\(|:::)`),
+	parser.DiagramTimeline:     regexp.MustCompile(`^(title(\s.*)?|:.*)$`),
+	parser.DiagramC4Context:    c4Lines,
+	parser.DiagramC4Container:  c4Lines,
+	parser.DiagramC4Component:  c4Lines,
+	parser.DiagramC4Dynamic:    c4Lines,
+	parser.DiagramC4Deployment: c4Lines,
+	parser.DiagramRequirement:  regexp.MustCompile(`^(\}|(?i:id|text|risk|verifymethod|type|docref)\s*:.*)$`),
+	parser.DiagramBlock:        regexp.MustCompile(`^(columns(\s.*)?|end)$`),
+}
+
+var (
+	stateLines = regexp.MustCompile(`^(--|\}|end note|state\s.*|direction(\s.*)?|[\w.]+(-[\w.]+)*\s*:.*)$`)
+	c4Lines    = regexp.MustCompile(`^(title(\s.*)?|\})$`)
+)
+
+// model prints a diagram from its model and reports whether it has one.
+// Source lines the model was not read from, such as "click" and
+// "accTitle" statements, are kept where they are.
+func (p *printer) model() bool {
+	header, stmts, ok := p.modelOf(p.d)
+	if !ok {
+		return false
+	}
+
+	if p.header >= 0 {
+		p.covered = make(map[int]bool)
+		p.orig = make(map[[2]int]string)
+		orig, _ := parser.Parse(p.source, p.d.StartLine)
+		origHeader, origStmts, ok := p.modelOf(orig)
+		if !ok || orig.Type != p.d.Type {
+			// The source no longer describes the diagram; keep only its
+			// comments and blank lines.
+			for i := range p.lines {
+				p.covered[i] = true
+			}
+		} else {
+			p.record(origStmts)
+			pattern := modelLines[p.d.Type]
+			for i := p.header + 1; i < len(p.lines); i++ {
+				if pattern != nil && pattern.MatchString(strings.TrimSpace(p.lines[i])) {
+					p.covered[i] = true
+				}
+			}
+		}
+
+		if p.opts.Preserve && header == origHeader {
+			p.buf.WriteString(strings.TrimRight(p.lines[p.header], " \t\r") + "\n")
+		} else {
+			p.line(0, header)
+		}
+		p.next = p.header + 1
+	} else {
+		p.line(0, header)
+	}
+
+	p.stmts(stmts, 0)
+	p.skipTo(len(p.lines), 0)
+	return true
+}
+
+// modelOf returns the type declaration and the statements of a diagram
+// printed from its model; ok is false if d has no model.
+func (p *printer) modelOf(d *parser.Diagram) (header string, stmts []stmt, ok bool) {
+	switch {
+	case d.Sequence != nil:
+		return typeName(d, parser.DiagramSequence), p.sequence(d.Sequence), true
+	case d.Class != nil:
+		return typeName(d, parser.DiagramClass), p.class(d.Class), true
+	case d.State != nil:
+		return typeName(d, parser.DiagramStateV2), p.state(d.State), true
+	case d.ER != nil:
+		return typeName(d, parser.DiagramER), p.er(d.ER), true
+	case d.Gantt != nil:
+		return typeName(d, parser.DiagramGantt), p.gantt(d.Gantt), true
+	case d.Pie != nil:
+		header, stmts := p.pie(typeName(d, parser.DiagramPie), d.Pie)
+		return header, stmts, true
+	case d.Quadrant != nil:
+		return typeName(d, parser.DiagramQuadrant), p.quadrant(d.Quadrant), true
+	case d.XYChart != nil:
+		header = typeName(d, parser.DiagramXYChart)
+		if d.XYChart.Horizontal {
+			header += " horizontal"
+		}
+		return header, p.xyChart(d.XYChart), true
+	case d.GitGraph != nil:
+		header = typeName(d, parser.DiagramGitGraph)
+		if d.GitGraph.Direction != "" {
+			header += " " + d.GitGraph.Direction + ":"
+		}
+		return header, p.gitGraph(d.GitGraph), true
+	case d.Mindmap != nil:
+		return typeName(d, parser.DiagramMindmap), p.mindmap(d.Mindmap), true
+	case d.Timeline != nil:
+		return typeName(d, parser.DiagramTimeline), p.timeline(d.Timeline), true
+	case d.C4 != nil:
+		return typeName(d, parser.DiagramC4Context), p.c4(d.C4), true
+	case d.Requirement != nil:
+		return typeName(d, parser.DiagramRequirement), p.requirement(d.Requirement), true
+	case d.Sankey != nil:
+		return typeName(d, parser.DiagramSankey), p.sankey(d.Sankey), true
+	case d.Block != nil:
+		return typeName(d, parser.DiagramBlock), p.block(d.Block), true
+	}
+	return "", nil, false
+}
+
+// typeName returns the type keyword of d as written, or else its Type,
+// falling back to def for diagrams made in code without one.
+func typeName(d *parser.Diagram, def parser.DiagramType) string {
+	switch {
+	case d.TypeRaw != "":
+		return d.TypeRaw
+	case d.Type != "" && d.Type != parser.DiagramUnknown:
+		return string(d.Type)
+	}
+	return string(def)
+}
+
+// record notes the canonical text of the statements read from the source,
+// so that statements left unchanged can be printed from it, and marks the
+// source lines they cover.
+func (p *printer) record(stmts []stmt) {
+	for _, s := range stmts {
+		if s.r.Start.Line > 0 {
+			p.orig[spanOf(s.r)] = s.text
+			last := s.r.End.Line
+			if s.isBlock() {
+				last = s.r.Start.Line
+			}
+			for line := s.r.Start.Line; line <= last; line++ {
+				p.cover(line)
+			}
+		}
+		p.cover(s.line)
+		p.cover(s.end)
+		p.record(s.body)
+	}
+}
+
+func (p *printer) cover(line int) {
+	if i := p.index(line); i >= 0 {
+		p.covered[i] = true
+	}
+}
+
+func spanOf(r parser.Range) [2]int {
+	return [2]int{r.Start.Offset, r.End.Offset}
+}
+
+// reuse returns the source text of s when preserving formatting and s is
+// unchanged.
+func (p *printer) reuse(s stmt) (string, bool) {
+	if !p.opts.Preserve || s.r.Start.Line == 0 || p.orig[spanOf(s.r)] != s.text {
+		return "", false
+	}
+	return p.sourceText(s.r)
+}
+
+// sourceText returns the source text of r.
+func (p *printer) sourceText(r parser.Range) (string, bool) {
+	if p.index(r.Start.Line) < 0 || r.Start.Offset > r.End.Offset || r.End.Offset > len(p.source) {
+		return "", false
+	}
+	return p.source[r.Start.Offset:r.End.Offset], true
+}
+
+// holds reports whether the source text of r contains text, so that a
+// statement with a value gathered from several lines is only printed from
+// r when r holds it.
+func (p *printer) holds(r parser.Range, text string) bool {
+	src, ok := p.sourceText(r)
+	return ok && strings.Contains(src, text)
+}
+
+// stmts prints the statements of a diagram model.
+func (p *printer) stmts(stmts []stmt, level int) {
+	for _, s := range stmts {
+		if !s.isBlock() {
+			p.leaf(s, level)
+			continue
+		}
+		p.open(s, level)
+		p.stmts(s.body, level+1)
+		if s.close != "" {
+			p.closeBlock(s, level)
+		}
+	}
+}
+
+// leaf prints a statement without a body.
+func (p *printer) leaf(s stmt, level int) {
+	i := p.index(s.line)
+	if i < 0 {
+		p.line(level, s.text)
+		return
+	}
+	p.skipTo(i, level)
+	if src, ok := p.reuse(s); ok {
+		prefix := lineBefore(p.source, s.r.Start.Offset)
+		if strings.TrimSpace(prefix) != "" {
+			prefix = strings.Repeat(p.opts.Indent, level)
+		}
+		p.buf.WriteString(prefix + src + "\n")
+	} else {
+		p.line(level, s.text)
+	}
+	if end := p.index(s.r.End.Line); end > i {
+		i = end
+	}
+	if i+1 > p.next {
+		p.next = i + 1
+	}
+}
+
+// open prints the opening line of a block.
+func (p *printer) open(s stmt, level int) {
+	i := p.index(s.line)
+	if i < 0 {
+		p.line(level, s.text)
+		return
+	}
+	p.skipTo(i, level)
+	source := strings.TrimRight(p.lines[i], " \t\r")
+	_, ok := p.reuse(s)
+	if ok && (!strings.HasSuffix(s.text, "{") || strings.HasSuffix(source, "{")) {
+		p.buf.WriteString(source + "\n")
+	} else {
+		p.line(level, s.text)
+	}
+	if i+1 > p.next {
+		p.next = i + 1
+	}
+}
+
+// closeBlock prints the closing line of a block.
+func (p *printer) closeBlock(s stmt, level int) {
+	i := p.index(s.end)
+	if i < 0 {
+		p.line(level, s.close)
+		return
+	}
+	p.skipTo(i, level+1)
+	source := strings.TrimRight(p.lines[i], " \t\r")
+	if p.opts.Preserve && strings.TrimSpace(source) == s.close {
+		p.buf.WriteString(source + "\n")
+	} else {
+		p.line(level, s.close)
+	}
+	if i+1 > p.next {
+		p.next = i + 1
+	}
+}
+
+// keyed returns the statement setting a chart property, such as "title",
+// placed at the body line starting with key. A property set on several
+// lines is printed in canonical form.
+func (p *printer) keyed(key, text string) stmt {
+	s := stmt{text: text}
+	if p.header < 0 {
+		return s
+	}
+	for i := p.header + 1; i < len(p.lines); i++ {
+		if fields := strings.Fields(p.lines[i]); len(fields) == 0 || fields[0] != key {
+			continue
+		}
+		if s.line != 0 {
+			s.r = parser.Range{}
+			break
+		}
+		s.line, s.r = p.d.StartLine+i, p.lineRange(i)
+	}
+	return s
+}
+
+// lineRange returns the span of the text of line index i, without its
+// indentation.
+func (p *printer) lineRange(i int) parser.Range {
+	offset := 0
+	for _, line := range p.lines[:i] {
+		offset += len(line) + 1
+	}
+	line := p.lines[i]
+	start := offset + len(line) - len(strings.TrimLeft(line, " \t"))
+	end := offset + len(strings.TrimRight(line, " \t\r"))
+	if end < start {
+		end = start
+	}
+	n := p.d.StartLine + i
+	return parser.Range{
+		Start: parser.Position{Line: n, Offset: start},
+		End:   parser.Position{Line: n, Offset: end},
+	}
+}
+
+// lineAt returns the span of the text of a file line, or the zero Range
+// for lines outside the source.
+func (p *printer) lineAt(line int) parser.Range {
+	i := p.index(line)
+	if i < 0 {
+		return parser.Range{}
+	}
+	return p.lineRange(i)
+}
+
+// sortByLine orders statements gathered from several lists by the line
+// they were read from, keeping the order of statements on the same line.
+// A statement made in code stays behind the one before it.
+func sortByLine(stmts []stmt) []stmt {
+	keys := make([]int, len(stmts))
+	for i, s := range stmts {
+		switch {
+		case s.line > 0:
+			keys[i] = s.line
+		case i > 0:
+			keys[i] = keys[i-1]
+		}
+	}
+	order := make([]int, len(stmts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] < keys[order[b]] })
+	sorted := make([]stmt, len(stmts))
+	for i, j := range order {
+		sorted[i] = stmts[j]
+	}
+	return sorted
+}
+
+// number formats a chart value. ok is false for NaN, which stands for a
+// value that is not a number.
+func number(v float64) (string, bool) {
+	if math.IsNaN(v) {
+		return "", false
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64), true
+}
+
+// numbered returns the statement for a chart row read from r. A row with
+// a value that is not a number is kept as written, since its model has
+// lost the value.
+func (p *printer) numbered(r parser.Range, text string, ok bool) stmt {
+	if !ok {
+		if src, found := p.sourceText(r); found {
+			text = src
+		}
+	}
+	return at(r, text)
+}
+
+// dquote returns s in double quotes. Mermaid has no escapes inside them.
+func dquote(s string) string {
+	return `"` + s + `"`
+}
+
+// plainWord matches names that need no quotes.
+var plainWord = regexp.MustCompile(`^[\w-]+$`)
+
+// name returns s as written in most diagram types: bare if it is a single
+// word, in double quotes otherwise.
+func name(s string) string {
+	if plainWord.MatchString(s) {
+		return s
+	}
+	return dquote(s)
+}
@@ -0,0 +1,439 @@
+// Package printer writes parsed Mermaid diagrams back out as source.
+//
+// Flowcharts are printed from their syntax tree, or from their nodes and
+// edges when they have none, and every other diagram type from its model,
+// such as Diagram.Sequence, so that a diagram changed or built in code can
+// be serialized. Source lines a model is not read from, such as "click"
+// statements, are printed as they are.
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// Options controls how a diagram is printed. The zero value prints the
+// canonical form: frontmatter regenerated from the diagram's config, one
+// statement per line, four-space indentation and no comments.
+type Options struct {
+	Indent   string // Indentation of each nesting level, four spaces if empty
+	Comments bool   // Keep the source's "%%" comment lines
+	// Preserve reuses the source text and indentation of every statement
+	// that has a source range, and keeps the source's frontmatter,
+	// directives, comments and blank lines. Statements without a range,
+	// such as ones added in code, are printed in canonical form, as are
+	// the statements of diagram models that were changed since parsing.
+	Preserve bool
+}
+
+// Fprint writes d to w as Mermaid source.
+func Fprint(w io.Writer, d *parser.Diagram, opts Options) error {
+	_, err := io.WriteString(w, Sprint(d, opts))
+	return err
+}
+
+// Sprint returns d as Mermaid source.
+func Sprint(d *parser.Diagram, opts Options) string {
+	if opts.Indent == "" {
+		opts.Indent = "    "
+	}
+	p := &printer{d: d, opts: opts, source: strings.Join(d.Lines, "\n"), lines: d.Lines, header: -1}
+	if n := len(p.lines); n > 0 && p.lines[n-1] == "" {
+		// The newline ending the source is printed after the last line.
+		p.lines = p.lines[:n-1]
+	}
+	if d.TypeRaw != "" {
+		p.header = d.TypeRange.Start.Line - d.StartLine
+	}
+
+	p.preamble()
+	switch {
+	case d.Flowchart != nil:
+		p.flowchart(d.Flowchart)
+	case isFlowchart(d):
+		p.flowchart(flowchartOf(d))
+	case !p.model():
+		p.body()
+	}
+	return p.buf.String()
+}
+
+// printer holds the state of a single Sprint call.
+type printer struct {
+	d      *parser.Diagram
+	opts   Options
+	source string
+	lines  []string // d.Lines without the empty line after a final newline
+	header int      // Index in p.lines of the type declaration, -1 if unknown
+	next   int      // Index in p.lines of the first line not yet printed or skipped
+	buf    strings.Builder
+
+	// For diagrams printed from their model: the source lines the model
+	// was read from, by index in p.lines, and the canonical text of each
+	// statement read from the source, by its start and end offsets.
+	covered map[int]bool
+	orig    map[[2]int]string
+}
+
+// line writes text on a line of its own, indented to level. Each line of
+// a text spanning several lines is indented.
+func (p *printer) line(level int, text string) {
+	indent := strings.Repeat(p.opts.Indent, level)
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			p.buf.WriteString(indent + line)
+		}
+		p.buf.WriteByte('\n')
+	}
+}
+
+// index returns the index in p.lines of a file line number, or -1 for
+// positions that do not come from the source.
+func (p *printer) index(line int) int {
+	i := line - p.d.StartLine
+	if line == 0 || i < 0 || i >= len(p.lines) {
+		return -1
+	}
+	return i
+}
+
+// skipTo prints the comment and, when preserving, blank lines before line
+// index end that have not been printed yet, and the lines a diagram model
+// was not read from.
+func (p *printer) skipTo(end, level int) {
+	for ; p.next < end; p.next++ {
+		text := strings.TrimRight(p.lines[p.next], " \t\r")
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case strings.HasPrefix(trimmed, "%%{"):
+			// Directives are merged into the canonical frontmatter.
+			if p.opts.Preserve {
+				p.buf.WriteString(text + "\n")
+			}
+		case strings.HasPrefix(trimmed, "%%"):
+			if p.opts.Preserve {
+				p.buf.WriteString(text + "\n")
+			} else if p.opts.Comments {
+				p.line(level, trimmed)
+			}
+		case trimmed == "":
+			if p.opts.Preserve {
+				p.buf.WriteByte('\n')
+			}
+		case p.covered != nil && !p.covered[p.next]:
+			if p.opts.Preserve {
+				p.buf.WriteString(text + "\n")
+			} else {
+				p.line(level, trimmed)
+			}
+		}
+	}
+}
+
+// preamble prints the frontmatter and directives in front of the type
+// declaration.
+func (p *printer) preamble() {
+	if p.opts.Preserve && p.header > 0 {
+		for _, line := range p.lines[:p.header] {
+			p.buf.WriteString(strings.TrimRight(line, " \t\r") + "\n")
+		}
+		p.next = p.header
+		return
+	}
+
+	if cfg := p.d.Config; cfg != nil && (cfg.Title != "" || len(cfg.Values) > 0) {
+		p.line(0, "---")
+		if cfg.Title != "" {
+			p.line(0, "title: "+quote(cfg.Title))
+		}
+		if len(cfg.Values) > 0 {
+			p.line(0, "config: "+jsonValue(cfg.Values))
+		}
+		p.line(0, "---")
+	}
+	if cfg := p.d.Config; cfg != nil && cfg.Wrap && cfg.Values["wrap"] != true {
+		p.line(0, "%%{wrap}%%")
+	}
+	if p.header > 0 {
+		// Keep comments, but not the frontmatter lines.
+		if cfg := p.d.Config; cfg != nil && cfg.Frontmatter.End.Line > 0 {
+			p.next = p.index(cfg.Frontmatter.End.Line) + 1
+		}
+		p.skipTo(p.header, 0)
+	}
+}
+
+// body prints a diagram of an unknown type from its source lines.
+func (p *printer) body() {
+	if p.header < 0 {
+		if p.d.TypeRaw != "" {
+			p.line(0, p.d.TypeRaw)
+		}
+		return
+	}
+	for i := p.header; i < len(p.lines); i++ {
+		trimmed := strings.TrimSpace(p.lines[i])
+		if i > p.header && (trimmed == "" || strings.HasPrefix(trimmed, "%%")) {
+			continue
+		}
+		p.skipTo(i, 0)
+		p.buf.WriteString(strings.TrimRight(p.lines[i], " \t\r") + "\n")
+		p.next = i + 1
+	}
+	p.skipTo(len(p.lines), 0)
+}
+
+// flowchart prints a flowchart from its syntax tree.
+func (p *printer) flowchart(f *parser.Flowchart) {
+	stmts := f.Statements
+	if !isHeader(stmts) {
+		header := typeName(p.d, parser.DiagramFlowchart)
+		if p.d.Direction != "" {
+			header += " " + p.d.Direction
+		}
+		p.line(0, header)
+	}
+	p.statements(stmts, 0)
+	p.skipTo(len(p.lines), 0)
+}
+
+// isHeader reports whether the first statement is the type declaration.
+func isHeader(stmts []parser.FlowchartStatement) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	kw, ok := stmts[0].(*parser.KeywordStatement)
+	return ok && (kw.Keyword == "flowchart" || kw.Keyword == "graph")
+}
+
+func (p *printer) statements(stmts []parser.FlowchartStatement, level int) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *parser.KeywordStatement:
+			p.statement(stmt.Range, level, keywordSource(stmt))
+		case *parser.ChainStatement:
+			p.statement(stmt.Range, level, chainSource(stmt))
+		case *parser.SubgraphStatement:
+			p.subgraph(stmt, level)
+		}
+	}
+}
+
+// statement prints a single-line statement, from its source text when
+// preserving formatting and the statement has one.
+func (p *printer) statement(r parser.Range, level int, canonical string) {
+	start, end := p.index(r.Start.Line), p.index(r.End.Line)
+	if start < 0 || end < 0 {
+		p.line(level, canonical)
+		return
+	}
+	p.skipTo(start, level)
+	if p.opts.Preserve {
+		prefix := lineBefore(p.source, r.Start.Offset)
+		if strings.TrimSpace(prefix) != "" {
+			prefix = strings.Repeat(p.opts.Indent, level)
+		}
+		p.buf.WriteString(prefix + p.source[r.Start.Offset:r.End.Offset] + "\n")
+	} else {
+		p.line(level, canonical)
+	}
+	if end+1 > p.next {
+		p.next = end + 1
+	}
+}
+
+// lineBefore returns the text between the start of the line holding offset
+// and offset.
+func lineBefore(s string, offset int) string {
+	return s[strings.LastIndexByte(s[:offset], '\n')+1 : offset]
+}
+
+func (p *printer) subgraph(sg *parser.SubgraphStatement, level int) {
+	p.sourceLine(sg.Line, level, level, subgraphHeader(sg))
+	p.statements(sg.Body, level+1)
+	p.sourceLine(sg.EndLine, level+1, level, "end")
+}
+
+// sourceLine prints the source line with the given file line number when
+// preserving formatting, and canonical at level otherwise. Comments before
+// it are printed at commentLevel.
+func (p *printer) sourceLine(line, commentLevel, level int, canonical string) {
+	i := p.index(line)
+	if i < 0 {
+		p.line(level, canonical)
+		return
+	}
+	p.skipTo(i, commentLevel)
+	if p.opts.Preserve {
+		p.buf.WriteString(strings.TrimRight(p.lines[i], " \t\r") + "\n")
+	} else {
+		p.line(level, canonical)
+	}
+	p.next = i + 1
+}
+
+// subgraphHeader returns the canonical "subgraph" line of sg.
+func subgraphHeader(sg *parser.SubgraphStatement) string {
+	switch {
+	case sg.Title == sg.ID:
+		return "subgraph " + sg.ID
+	case strings.HasPrefix(sg.ID, "subGraph") && strings.ContainsAny(sg.Title, " \t"):
+		return "subgraph " + quote(sg.Title)
+	case strings.HasPrefix(sg.ID, "subGraph") && sg.Title == "":
+		return "subgraph"
+	}
+	return "subgraph " + sg.ID + " [" + sg.Title + "]"
+}
+
+// keywordNames gives the canonical spelling of keywords that are not
+// all lowercase.
+var keywordNames = map[string]string{
+	"classdef":  "classDef",
+	"linkstyle": "linkStyle",
+	"acctitle":  "accTitle",
+	"accdescr":  "accDescr",
+}
+
+func keywordSource(kw *parser.KeywordStatement) string {
+	name := kw.Keyword
+	if canonical, ok := keywordNames[name]; ok {
+		name = canonical
+	}
+	if kw.Text == "" {
+		return name
+	}
+	if kw.Keyword == "acctitle" || kw.Keyword == "accdescr" {
+		if strings.Contains(kw.Text, "\n") {
+			return name + " {\n" + kw.Text + "\n}"
+		}
+		return name + ": " + kw.Text
+	}
+	return name + " " + kw.Text
+}
+
+func chainSource(chain *parser.ChainStatement) string {
+	var b strings.Builder
+	for i, group := range chain.Groups {
+		if i > 0 {
+			b.WriteString(" " + linkSource(chain.Links[i-1]) + " ")
+		}
+		for j, ref := range group {
+			if j > 0 {
+				b.WriteString(" & ")
+			}
+			b.WriteString(nodeSource(ref))
+		}
+	}
+	return b.String()
+}
+
+func linkSource(link parser.Link) string {
+	s := link.Style
+	if s == "" {
+		s = "-->"
+	}
+	if link.ID != "" {
+		s = link.ID + "@" + s
+	}
+	if link.Label != "" {
+		s += "|" + labelSource(link.LabelText, link.Label, "|") + "|"
+	}
+	return s
+}
+
+// shapeDelimiters gives the brackets of each shape with a bracket syntax.
+var shapeDelimiters = map[string][2]string{
+	"rect":              {"[", "]"},
+	"round":             {"(", ")"},
+	"stadium":           {"([", "])"},
+	"subroutine":        {"[[", "]]"},
+	"cylinder":          {"[(", ")]"},
+	"circle":            {"((", "))"},
+	"double-circle":     {"(((", ")))"},
+	"asymmetric":        {">", "]"},
+	"rhombus":           {"{", "}"},
+	"hexagon":           {"{{", "}}"},
+	"parallelogram":     {"[/", "/]"},
+	"parallelogram-alt": {`[\`, `\]`},
+	"trapezoid":         {"[/", `\]`},
+	"trapezoid-alt":     {`[\`, "/]"},
+}
+
+func nodeSource(ref parser.NodeRef) string {
+	s := ref.ID
+	if ref.Attrs != nil {
+		s += "@" + attrsSource(ref.Attrs)
+	} else if ref.Shape != "" {
+		delims, ok := shapeDelimiters[ref.Shape]
+		if !ok {
+			delims = shapeDelimiters["rect"]
+		}
+		s += delims[0] + labelSource(ref.LabelText, ref.Label, delims[1]) + delims[1]
+	}
+	for _, class := range ref.Classes {
+		s += ":::" + class
+	}
+	return s
+}
+
+// labelSource returns a label as it is written in source. The label's raw
+// text is reused while it still decodes to label; otherwise label is
+// quoted if it contains closer or other syntax.
+func labelSource(text parser.LabelText, label, closer string) string {
+	if text.Raw != "" && text.Display == label && (text.Quoted || !strings.Contains(text.Raw, closer)) {
+		return text.Raw
+	}
+	if label == "" || !strings.ContainsAny(label, `[](){}|<>"#;`) && !strings.Contains(label, closer) {
+		return label
+	}
+	return `"` + strings.ReplaceAll(label, `"`, "#quot;") + `"`
+}
+
+// attrsSource returns an "@{ ... }" attribute block with sorted keys.
+func attrsSource(attrs map[string]any) string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if !isBareKey(key) {
+			key = quote(key)
+		}
+		parts[i] = key + ": " + jsonValue(attrs[key])
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+func isBareKey(s string) bool {
+	for _, r := range s {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// jsonValue encodes a config or attribute value as JSON, which both the
+// YAML frontmatter and attribute blocks accept.
+func jsonValue(v any) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return quote(fmt.Sprint(v))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// quote returns s as a double-quoted string.
+func quote(s string) string {
+	return jsonValue(s)
+}
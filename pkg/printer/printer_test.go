@@ -0,0 +1,506 @@
+package printer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+const roundTripSource = `---
+title: "Order flow"
+config:
+  theme: forest
+---
+%%{init: {"flowchart": {"curve": "basis"}}}%%
+flowchart LR
+  accTitle: Order flow
+  accDescr {
+    How an order is checked
+    and stored
+  }
+  %% Entry points
+  start([Start]) --> check{Valid?}
+  check -->|yes| db[(Orders)]:::store
+  check -. "no, retry" .-> start
+  db e1@==> done((Done))
+  e1@{ animate: true }
+  A & B --- C@{ shape: hex, label: "Prep #amp; cook" }
+  subgraph backend [Back end]
+    direction TB
+    api[["API"]] <--> queue>Queue]
+    subgraph inner
+      worker[/Worker\]
+    end
+  end
+  subgraph "Two words"
+    x
+  end
+  classDef store fill:#f96,stroke:#333
+  class start,done store
+  style db stroke-width:2px
+  linkStyle 0,1 stroke:red
+`
+
+// model is the part of a diagram that printing must not change.
+type model struct {
+	Type             parser.DiagramType
+	Direction        string
+	Title            string
+	Values           map[string]any
+	Flowchart        *parser.Flowchart
+	Nodes            []parser.Node
+	Edges            []parser.Edge
+	Subgraphs        []parser.Subgraph
+	ClassDefs        []parser.ClassDef
+	ClassAssignments []parser.ClassAssignment
+	LinkStyles       []parser.LinkStyle
+	Sequence         *parser.SequenceDiagram
+	Class            *parser.ClassDiagram
+	State            *parser.StateDiagram
+	ER               *parser.ERDiagram
+	Gantt            *parser.GanttChart
+	Pie              *parser.PieChart
+	Quadrant         *parser.QuadrantChart
+	XYChart          *parser.XYChart
+	GitGraph         *parser.GitGraph
+	Mindmap          *parser.Mindmap
+	Timeline         *parser.Timeline
+	C4               *parser.C4Diagram
+	Requirement      *parser.RequirementDiagram
+	Sankey           *parser.SankeyDiagram
+	Block            *parser.BlockDiagram
+}
+
+func modelOf(t *testing.T, source string) model {
+	t.Helper()
+	d, diags := parser.Parse(source, 1)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v\nsource:\n%s", diags, source)
+	}
+	m := model{
+		Type:             d.Type,
+		Direction:        d.Direction,
+		Flowchart:        d.Flowchart,
+		Nodes:            d.Nodes,
+		Edges:            d.Edges,
+		Subgraphs:        d.Subgraphs,
+		ClassDefs:        d.ClassDefs,
+		ClassAssignments: d.ClassAssignments,
+		LinkStyles:       d.LinkStyles,
+		Sequence:         d.Sequence,
+		Class:            d.Class,
+		State:            d.State,
+		ER:               d.ER,
+		Gantt:            d.Gantt,
+		Pie:              d.Pie,
+		Quadrant:         d.Quadrant,
+		XYChart:          d.XYChart,
+		GitGraph:         d.GitGraph,
+		Mindmap:          d.Mindmap,
+		Timeline:         d.Timeline,
+		C4:               d.C4,
+		Requirement:      d.Requirement,
+		Sankey:           d.Sankey,
+		Block:            d.Block,
+	}
+	if d.Config != nil {
+		m.Title, m.Values = d.Config.Title, d.Config.Values
+	}
+	clearPositions(reflect.ValueOf(&m).Elem())
+	return m
+}
+
+// clearPositions zeroes the Line, EndLine and Range fields found anywhere
+// in v, since printing moves statements to other lines, and the Indent of
+// mindmap nodes, which printing changes to its own.
+func clearPositions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			clearPositions(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			clearPositions(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			switch v.Type().Field(i).Name {
+			case "Line", "EndLine", "Range", "Indent":
+				v.Field(i).SetZero()
+			default:
+				if v.Field(i).CanSet() {
+					clearPositions(v.Field(i))
+				}
+			}
+		}
+	}
+}
+
+func TestSprint_RoundTrip(t *testing.T) {
+	want := modelOf(t, roundTripSource)
+	for name, opts := range map[string]Options{
+		"canonical": {},
+		"comments":  {Comments: true},
+		"preserve":  {Preserve: true},
+		"indent":    {Indent: "\t"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d, _ := parser.Parse(roundTripSource, 1)
+			out := Sprint(d, opts)
+			if got := modelOf(t, out); !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the diagram\noutput:\n%s\ngot:  %+v\nwant: %+v", out, got, want)
+			}
+		})
+	}
+}
+
+// modelSources has a diagram of every type printed from its model.
+var modelSources = map[string]string{
+	"sequence": "sequenceDiagram\n" +
+		"  %% greeting\n" +
+		"  participant A as Alice\n" +
+		"  actor B\n" +
+		"\n" +
+		"  A->>+B: Hello\n" +
+		"  loop Every minute\n" +
+		"    B-->>-A: Hi\n" +
+		"  end\n" +
+		"  Note right of B: Thinks\n",
+	"class": "classDiagram\n" +
+		"  %% shapes\n" +
+		"  class Shape~T~ {\n" +
+		"    <<interface>>\n" +
+		"    +area() double$\n" +
+		"  }\n" +
+		"\n" +
+		"  Shape <|-- Square : extends\n" +
+		"  Square : -int side\n",
+	"state": "stateDiagram-v2\n" +
+		"  %% lifecycle\n" +
+		"  [*] --> Idle\n" +
+		"  state Busy {\n" +
+		"    [*] --> Working\n" +
+		"  }\n" +
+		"\n" +
+		"  Idle --> Busy : start\n" +
+		"  note right of Idle : waiting\n",
+	"er": "erDiagram\n" +
+		"  %% orders\n" +
+		"  CUSTOMER ||--o{ ORDER : places\n" +
+		"\n" +
+		"  ORDER {\n" +
+		"    int id PK\n" +
+		"    string status\n" +
+		"  }\n",
+	"gantt": "gantt\n" +
+		"  title Plan\n" +
+		"  dateFormat YYYY-MM-DD\n" +
+		"  %% first phase\n" +
+		"  section Build\n" +
+		"  Design :a1, 2024-01-01, 3d\n" +
+		"\n" +
+		"  Code :after a1, 5d\n",
+	"pie": "pie showData\n" +
+		"  title Pets\n" +
+		"  %% counted\n" +
+		"  \"Dogs\" : 386\n" +
+		"\n" +
+		"  \"Cats\" : 85.5\n",
+	"quadrant": "quadrantChart\n" +
+		"  title Reach\n" +
+		"  x-axis Low --> High\n" +
+		"  %% axes done\n" +
+		"  y-axis Low --> High\n" +
+		"\n" +
+		"  Campaign A: [0.3, 0.6]\n",
+	"xychart": "xychart-beta\n" +
+		"  title Sales\n" +
+		"  x-axis [jan, feb]\n" +
+		"  %% revenue\n" +
+		"  y-axis \"Revenue\" 0 --> 100\n" +
+		"\n" +
+		"  bar [40, 60]\n" +
+		"  line [40, 60]\n",
+	"gitgraph": "gitGraph\n" +
+		"  commit id: \"init\"\n" +
+		"  %% feature work\n" +
+		"  branch dev\n" +
+		"  commit tag: \"v1\"\n" +
+		"\n" +
+		"  checkout main\n" +
+		"  merge dev\n",
+	"mindmap": "mindmap\n" +
+		"  root((Plan))\n" +
+		"    %% ideas\n" +
+		"    Ideas\n" +
+		"\n" +
+		"      More\n" +
+		"    Tasks\n",
+	"timeline": "timeline\n" +
+		"  title History\n" +
+		"  %% eras\n" +
+		"  section Early\n" +
+		"  2002 : LinkedIn\n" +
+		"\n" +
+		"       : Friendster\n" +
+		"  2004 : Facebook : Google\n",
+	"c4": "C4Context\n" +
+		"  title System\n" +
+		"  %% people\n" +
+		"  Person(user, \"User\")\n" +
+		"  System_Boundary(b, \"Shop\") {\n" +
+		"    System(shop, \"Shop\")\n" +
+		"  }\n" +
+		"\n" +
+		"  Rel(user, shop, \"Uses\")\n",
+	"requirement": "requirementDiagram\n" +
+		"  %% needs\n" +
+		"  requirement test_req {\n" +
+		"    id: 1\n" +
+		"    text: the test text.\n" +
+		"    risk: high\n" +
+		"    verifymethod: test\n" +
+		"  }\n" +
+		"\n" +
+		"  element test_entity {\n" +
+		"    type: simulation\n" +
+		"  }\n" +
+		"  test_entity - satisfies -> test_req\n",
+	"sankey": "sankey-beta\n" +
+		"  %% flows\n" +
+		"  Solar,Grid,10\n" +
+		"\n" +
+		"  Grid,Homes,7.5\n",
+	"block": "block-beta\n" +
+		"  columns 3\n" +
+		"  %% layout\n" +
+		"  a[\"A\"] b:2\n" +
+		"\n" +
+		"  block:group\n" +
+		"    c\n" +
+		"  end\n" +
+		"  a --> b\n",
+}
+
+// TestSprint_RoundTripModels checks that printing keeps the model of every
+// diagram type other than flowcharts.
+func TestSprint_RoundTripModels(t *testing.T) {
+	for name, src := range modelSources {
+		want := modelOf(t, src)
+		for optName, opts := range map[string]Options{
+			"canonical": {},
+			"comments":  {Comments: true},
+			"preserve":  {Preserve: true},
+		} {
+			t.Run(name+"/"+optName, func(t *testing.T) {
+				d, _ := parser.Parse(src, 1)
+				out := Sprint(d, opts)
+				if got := modelOf(t, out); !reflect.DeepEqual(got, want) {
+					t.Errorf("round trip changed the diagram\noutput:\n%s\ngot:  %+v\nwant: %+v", out, got, want)
+				}
+			})
+		}
+	}
+}
+
+// TestSprint_PrintsModelEdits checks that a change to the model of each
+// diagram type shows in the output, whether or not the source is kept.
+func TestSprint_PrintsModelEdits(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(d *parser.Diagram)
+		want string
+	}{
+		{"sequence", func(d *parser.Diagram) { d.Sequence.Messages[0].Text = "Howdy" }, "A->>+B: Howdy\n"},
+		{"class", func(d *parser.Diagram) { d.Class.Relationships[0].Label = "is a" }, "Shape <|-- Square : is a\n"},
+		{"state", func(d *parser.Diagram) { d.State.Transitions[2].Label = "go" }, "Idle --> Busy : go\n"},
+		{"er", func(d *parser.Diagram) { d.ER.Relationships[0].Label = "buys" }, "CUSTOMER ||--o{ ORDER : buys\n"},
+		{"gantt", func(d *parser.Diagram) { d.Gantt.Title = "Roadmap" }, "title Roadmap\n"},
+		{"pie", func(d *parser.Diagram) { d.Pie.Slices[1].Value = 90 }, "\"Cats\" : 90\n"},
+		{"quadrant", func(d *parser.Diagram) { d.Quadrant.Points[0].Label = "Campaign B" }, "Campaign B: [0.3, 0.6]\n"},
+		{"xychart", func(d *parser.Diagram) { d.XYChart.Title = "Income" }, "title \"Income\"\n"},
+		{"gitgraph", func(d *parser.Diagram) { d.GitGraph.Commands[0].ID = "root" }, "commit id: \"root\"\n"},
+		{"mindmap", func(d *parser.Diagram) { d.Mindmap.Roots[0].Children[1].Label = "Jobs" }, "Jobs\n"},
+		{"timeline", func(d *parser.Diagram) { d.Timeline.Periods[0].Label = "2003" }, "2003 : LinkedIn : Friendster\n"},
+		{"c4", func(d *parser.Diagram) { d.C4.Relationships[0].Label = "Buys from" }, "Rel(user, shop, \"Buys from\")\n"},
+		{"requirement", func(d *parser.Diagram) { d.Requirement.Requirements[0].Risk = "low" }, "risk: low\n"},
+		{"sankey", func(d *parser.Diagram) { d.Sankey.Links[0].Value = 12 }, "Solar,Grid,12\n"},
+		{"block", func(d *parser.Diagram) { d.Block.Edges[0].To = "c" }, "a --> c\n"},
+	}
+	for _, tt := range tests {
+		for optName, opts := range map[string]Options{
+			"canonical": {},
+			"preserve":  {Preserve: true},
+		} {
+			t.Run(tt.name+"/"+optName, func(t *testing.T) {
+				d, _ := parser.Parse(modelSources[tt.name], 1)
+				tt.edit(d)
+				if got := Sprint(d, opts); !strings.Contains(got, tt.want) {
+					t.Errorf("Sprint =\n%s\nwant it to contain %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSprint_ModelWithoutSource(t *testing.T) {
+	tests := []struct {
+		name string
+		d    *parser.Diagram
+		want string
+	}{
+		{
+			name: "sequence",
+			d: &parser.Diagram{
+				Type: parser.DiagramSequence,
+				Sequence: &parser.SequenceDiagram{
+					Messages: []parser.Message{{From: "A", To: "B", Arrow: "->>", Text: "hi"}},
+				},
+			},
+			want: "sequenceDiagram\nA->>B: hi\n",
+		},
+		{
+			name: "flowchart nodes",
+			d: &parser.Diagram{
+				Type:      "graph",
+				Direction: "TD",
+				Nodes:     []parser.Node{{ID: "A", Label: "Start"}, {ID: "B", Label: "B"}},
+				Edges:     []parser.Edge{{From: "A", To: "B", Style: "-->"}},
+			},
+			want: "graph TD\nA[Start]\nB\nA --> B\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sprint(tt.d, Options{}); got != tt.want {
+				t.Errorf("Sprint =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSprint_Canonical(t *testing.T) {
+	src := "graph TD\n" +
+		"%% a comment\n" +
+		"A[Start]-->B(\"Step (1)\")\n" +
+		"\n" +
+		"  subgraph S\n" +
+		"B---C\n" +
+		"    end\n" +
+		"classdef hot fill:red;"
+	d, _ := parser.Parse(src, 1)
+
+	want := "graph TD\n" +
+		"A[Start] --> B(\"Step (1)\")\n" +
+		"subgraph S\n" +
+		"    B --- C\n" +
+		"end\n" +
+		"classDef hot fill:red\n"
+	if got := Sprint(d, Options{}); got != want {
+		t.Errorf("Sprint =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSprint_Comments(t *testing.T) {
+	src := "flowchart LR\n" +
+		"    %% top\n" +
+		"subgraph S\n" +
+		"  %% inside\n" +
+		"  A\n" +
+		"end\n" +
+		"%% trailing"
+	d, _ := parser.Parse(src, 1)
+
+	want := "flowchart LR\n" +
+		"%% top\n" +
+		"subgraph S\n" +
+		"    %% inside\n" +
+		"    A\n" +
+		"end\n" +
+		"%% trailing\n"
+	if got := Sprint(d, Options{Comments: true}); got != want {
+		t.Errorf("Sprint =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSprint_PreserveKeepsSource(t *testing.T) {
+	d, _ := parser.Parse(roundTripSource, 1)
+	if got := Sprint(d, Options{Preserve: true}); got != roundTripSource {
+		t.Errorf("Sprint changed the source:\n%s", got)
+	}
+}
+
+func TestSprint_PreservePrintsEditsCanonically(t *testing.T) {
+	src := "flowchart LR\n" +
+		"   A  -->   B\n" +
+		"\n" +
+		"   %% note\n" +
+		"   B-->C"
+	d, _ := parser.Parse(src, 1)
+	chain := d.Flowchart.Statements[1].(*parser.ChainStatement)
+	chain.Groups[1][0].Label = "Bee"
+	chain.Groups[1][0].Shape = "round"
+	chain.Range = parser.Range{}
+	d.Flowchart.Statements = append(d.Flowchart.Statements, &parser.ChainStatement{
+		Groups: [][]parser.NodeRef{{{ID: "C"}}, {{ID: "D", Shape: "rect", Label: "Say \"hi\""}}},
+		Links:  []parser.Link{{Style: "-.->"}},
+	})
+
+	want := "flowchart LR\n" +
+		"A --> B(Bee)\n" +
+		"\n" +
+		"   %% note\n" +
+		"   B-->C\n" +
+		"C -.-> D[\"Say #quot;hi#quot;\"]\n"
+	if got := Sprint(d, Options{Preserve: true}); got != want {
+		t.Errorf("Sprint =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSprint_Frontmatter(t *testing.T) {
+	src := "%%{init: {\"theme\": \"dark\"}}%%\n" +
+		"%%{wrap}%%\n" +
+		"sequenceDiagram\n" +
+		"  %% greet\n" +
+		"  Alice->>Bob: Hi\n" +
+		"\n" +
+		"  Bob-->>Alice: Hello"
+	d, _ := parser.Parse(src, 1)
+
+	want := "---\n" +
+		"config: {\"theme\":\"dark\"}\n" +
+		"---\n" +
+		"%%{wrap}%%\n" +
+		"sequenceDiagram\n" +
+		"Alice->>Bob: Hi\n" +
+		"Bob-->>Alice: Hello\n"
+	got := Sprint(d, Options{})
+	if got != want {
+		t.Errorf("Sprint =\n%s\nwant:\n%s", got, want)
+	}
+
+	again, diags := parser.Parse(got, 1)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if !reflect.DeepEqual(again.Config.Values, d.Config.Values) || !again.Config.Wrap {
+		t.Errorf("Config = %+v, want %+v", again.Config, d.Config)
+	}
+	if len(again.Sequence.Messages) != 2 {
+		t.Errorf("Messages = %+v", again.Sequence.Messages)
+	}
+}
+
+func TestFprint(t *testing.T) {
+	d, _ := parser.Parse("pie\n  \"A\" : 1", 1)
+	var b strings.Builder
+	if err := Fprint(&b, d, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "pie\n\"A\" : 1\n" {
+		t.Errorf("Fprint wrote %q", b.String())
+	}
+}
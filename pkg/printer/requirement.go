@@ -0,0 +1,51 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// requirement lays out a requirement diagram.
+func (p *printer) requirement(rd *parser.RequirementDiagram) []stmt {
+	var stmts []stmt
+	for _, req := range rd.Requirements {
+		kind := req.Kind
+		if kind == "" {
+			kind = "requirement"
+		}
+		stmts = append(stmts, p.requirementBlock(req.Line, req.EndLine, req.Range, kind+" "+name(req.Name), []string{
+			"id", req.ID,
+			"text", req.Text,
+			"risk", req.Risk,
+			"verifymethod", req.VerifyMethod,
+		}))
+	}
+	for _, e := range rd.Elements {
+		stmts = append(stmts, p.requirementBlock(e.Line, e.EndLine, e.Range, "element "+name(e.Name), []string{
+			"type", e.Type,
+			"docRef", e.DocRef,
+		}))
+	}
+	for _, rel := range rd.Relationships {
+		stmts = append(stmts, at(rel.Range, name(rel.From)+" - "+rel.Kind+" -> "+name(rel.To)))
+	}
+	return sortByLine(stmts)
+}
+
+// requirementBlock returns a requirement or element with the fields that
+// are set, given as pairs of name and value. It is printed from its source
+// only if the source holds all of it, up to the closing brace.
+func (p *printer) requirementBlock(line, end int, r parser.Range, header string, fields []string) stmt {
+	lines := []string{header + " {"}
+	for i := 0; i < len(fields); i += 2 {
+		if fields[i+1] != "" {
+			lines = append(lines, p.opts.Indent+fields[i]+": "+fields[i+1])
+		}
+	}
+	lines = append(lines, "}")
+	if end == 0 {
+		r = parser.Range{}
+	}
+	return stmt{line: line, r: r, text: strings.Join(lines, "\n")}
+}
@@ -0,0 +1,25 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// sankey lays out a sankey diagram as rows of CSV.
+func (p *printer) sankey(sk *parser.SankeyDiagram) []stmt {
+	stmts := make([]stmt, len(sk.Links))
+	for i, link := range sk.Links {
+		value, ok := number(link.Value)
+		stmts[i] = p.numbered(link.Range, csvField(link.Source)+","+csvField(link.Target)+","+value, ok)
+	}
+	return stmts
+}
+
+// csvField quotes a CSV field if it needs quotes.
+func csvField(s string) string {
+	if !strings.ContainsAny(s, ",\"\n") && strings.TrimSpace(s) == s {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
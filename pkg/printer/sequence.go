@@ -0,0 +1,113 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// sequence lays out a sequence diagram from its statements. Participants
+// declared and messages sent only in Participants and Messages, such as
+// ones added in code, are printed first and last.
+func (p *printer) sequence(sd *parser.SequenceDiagram) []stmt {
+	declared := make(map[string]bool)
+	sent := make(map[*parser.Message]bool)
+	body := p.sequenceStatements(sd.Statements, declared, sent)
+
+	var stmts []stmt
+	for i := range sd.Participants {
+		part := &sd.Participants[i]
+		if !part.Implicit && !declared[part.ID] {
+			stmts = append(stmts, at(part.Range, participantSource(part)))
+		}
+	}
+	stmts = append(stmts, body...)
+	for i := range sd.Messages {
+		if msg := &sd.Messages[i]; !sent[msg] {
+			stmts = append(stmts, at(msg.Range, messageSource(msg)))
+		}
+	}
+	return stmts
+}
+
+func (p *printer) sequenceStatements(stmts []parser.SequenceStatement, declared map[string]bool, sent map[*parser.Message]bool) []stmt {
+	var out []stmt
+	for _, s := range stmts {
+		switch s := s.(type) {
+		case *parser.Participant:
+			declared[s.ID] = true
+			out = append(out, at(s.Range, participantSource(s)))
+		case *parser.Message:
+			sent[s] = true
+			out = append(out, at(s.Range, messageSource(s)))
+		case *parser.Activation:
+			keyword := "deactivate "
+			if s.Active {
+				keyword = "activate "
+			}
+			out = append(out, at(s.Range, keyword+s.Participant))
+		case *parser.Note:
+			target := strings.Join(s.Participants, ",")
+			if s.Placement != "" {
+				target = s.Placement + " " + target
+			}
+			out = append(out, at(s.Range, "Note "+target+": "+s.Text))
+		case *parser.SequenceBlock:
+			for _, section := range s.Sections {
+				out = append(out, stmt{
+					line: section.Line,
+					r:    section.Range,
+					text: strings.TrimSpace(section.Keyword + " " + section.Label),
+					body: p.sequenceStatements(section.Statements, declared, sent),
+				})
+			}
+			out = append(out, stmt{line: s.EndLine, r: p.lineAt(s.EndLine), text: "end"})
+		case *parser.SequenceDirective:
+			out = append(out, at(s.Range, directiveSource(s)))
+		}
+	}
+	return out
+}
+
+func participantSource(part *parser.Participant) string {
+	s := part.Kind
+	if s == "" {
+		s = "participant"
+	}
+	if part.Created {
+		s = "create " + s
+	}
+	s += " " + part.ID
+	if part.Alias != "" {
+		s += " as " + part.Alias
+	}
+	return s
+}
+
+func messageSource(msg *parser.Message) string {
+	s := msg.From + msg.Arrow
+	if msg.Arrow == "" {
+		s += "->>"
+	}
+	switch {
+	case msg.Activate:
+		s += "+"
+	case msg.Deactivate:
+		s += "-"
+	}
+	s += msg.To
+	if msg.Text != "" {
+		s += ": " + msg.Text
+	}
+	return s
+}
+
+func directiveSource(dir *parser.SequenceDirective) string {
+	switch {
+	case dir.Text == "":
+		return dir.Keyword
+	case dir.Keyword == "accTitle" || dir.Keyword == "accDescr":
+		return dir.Keyword + ": " + dir.Text
+	}
+	return dir.Keyword + " " + dir.Text
+}
@@ -0,0 +1,169 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// stateScope is a concurrent region of a composite state, or of the
+// diagram itself when id is empty.
+type stateScope struct {
+	id     string
+	region int
+}
+
+// state lays out a state diagram. Transitions and notes go into the
+// composite state holding their source line or, for ones made in code,
+// the one holding the state they start from. States only used in
+// transitions and the "[*]" pseudo-states are left to the transitions.
+func (p *printer) state(sd *parser.StateDiagram) []stmt {
+	states := make(map[string]*parser.State, len(sd.States))
+	for i := range sd.States {
+		states[sd.States[i].ID] = &sd.States[i]
+	}
+
+	items := make(map[stateScope][]stmt)
+	for _, t := range sd.Transitions {
+		text := stateRef(states, t.From) + " --> " + stateRef(states, t.To)
+		if t.Label != "" {
+			text += " : " + t.Label
+		}
+		scope := p.stateScopeOf(sd, states, t.Line, t.From)
+		items[scope] = append(items[scope], at(t.Range, text))
+	}
+	for _, n := range sd.Notes {
+		placement := n.Placement
+		if placement == "" {
+			placement = "right of"
+		}
+		text := "note " + placement + " " + n.State
+		if strings.Contains(n.Text, "\n") {
+			text += "\n" + p.opts.Indent + strings.ReplaceAll(n.Text, "\n", "\n"+p.opts.Indent) + "\nend note"
+		} else {
+			text += " : " + n.Text
+		}
+		scope := p.stateScopeOf(sd, states, n.Line, n.State)
+		items[scope] = append(items[scope], at(n.Range, text))
+	}
+
+	stmts := p.stateBody(sd, "", items)
+	if sd.Direction != "" {
+		stmts = sortByLine(append([]stmt{p.keyed("direction", "direction "+sd.Direction)}, stmts...))
+	}
+	return stmts
+}
+
+// stateScopeOf returns the region holding a transition or note read from
+// line, or made in code and starting from the state id.
+func (p *printer) stateScopeOf(sd *parser.StateDiagram, states map[string]*parser.State, line int, id string) stateScope {
+	if line == 0 {
+		if s, ok := states[id]; ok {
+			return stateScope{s.Parent, s.Region}
+		}
+		return stateScope{}
+	}
+	var scope stateScope
+	opened := 0
+	for _, s := range sd.States {
+		if s.Composite && s.Line < line && (s.EndLine == 0 || line < s.EndLine) && s.Line > opened {
+			scope, opened = stateScope{id: s.ID}, s.Line
+		}
+	}
+	for _, s := range sd.States {
+		if s.Parent == scope.id && s.Line <= line && s.Region > scope.region {
+			scope.region = s.Region
+		}
+	}
+	return scope
+}
+
+// stateBody returns the states of the composite state id, or of the
+// diagram, with the transitions and notes of its regions.
+func (p *printer) stateBody(sd *parser.StateDiagram, id string, items map[stateScope][]stmt) []stmt {
+	regions := 0
+	for scope := range items {
+		if scope.id == id && scope.region > regions {
+			regions = scope.region
+		}
+	}
+	for _, s := range sd.States {
+		if s.Parent == id && s.Region > regions {
+			regions = s.Region
+		}
+	}
+
+	lists := make([][]stmt, regions+1)
+	for i := range sd.States {
+		s := &sd.States[i]
+		if s.Parent != id || s.Kind == "start" || s.Kind == "end" || s.Implicit && s.Description == "" && !s.Composite {
+			continue
+		}
+		lists[s.Region] = append(lists[s.Region], p.stateStmts(sd, s, items)...)
+	}
+	var stmts []stmt
+	for region, list := range lists {
+		if region > 0 {
+			stmts = append(stmts, stmt{text: "--"})
+		}
+		stmts = append(stmts, sortByLine(append(list, items[stateScope{id, region}]...))...)
+	}
+	return stmts
+}
+
+// stateStmts returns the declaration of a state, followed by the lines
+// of its description that the declaration does not hold.
+func (p *printer) stateStmts(sd *parser.StateDiagram, s *parser.State, items map[stateScope][]stmt) []stmt {
+	var desc []string
+	if s.Description != "" {
+		desc = strings.Split(s.Description, "\n")
+	}
+	r := s.Range
+	if len(desc) > 1 || len(desc) == 1 && !p.holds(r, desc[0]) {
+		r = parser.Range{} // The description was gathered from other lines
+	}
+
+	kind := s.Kind != "" && s.Kind != "state"
+	if !kind && !s.Composite {
+		lines := make([]string, len(desc))
+		for i, d := range desc {
+			lines[i] = s.ID + " : " + d
+		}
+		if len(lines) == 0 {
+			lines = []string{s.ID}
+		}
+		return []stmt{{line: s.Line, r: r, text: strings.Join(lines, "\n")}}
+	}
+
+	header := "state " + s.ID
+	if s.Composite && len(desc) > 0 && !strings.Contains(desc[0], `"`) {
+		header = "state " + dquote(desc[0]) + " as " + s.ID
+		desc = desc[1:]
+	}
+	if kind {
+		header += " <<" + s.Kind + ">>"
+	}
+	decl := stmt{line: s.Line, r: r, text: header}
+	if s.Composite {
+		decl.text += " {"
+		decl.body = p.stateBody(sd, s.ID, items)
+		if decl.body == nil {
+			decl.body = []stmt{}
+		}
+		decl.close, decl.end = "}", s.EndLine
+	}
+	stmts := []stmt{decl}
+	for _, d := range desc {
+		stmts = append(stmts, stmt{text: s.ID + " : " + d})
+	}
+	return stmts
+}
+
+// stateRef returns how a transition refers to a state, "[*]" for the
+// start and end pseudo-states.
+func stateRef(states map[string]*parser.State, id string) string {
+	if s, ok := states[id]; ok && (s.Kind == "start" || s.Kind == "end") && strings.HasSuffix(id, "_"+s.Kind) {
+		return "[*]"
+	}
+	return id
+}
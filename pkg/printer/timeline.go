@@ -0,0 +1,36 @@
+package printer
+
+import (
+	"github.com/skjutare/mermaid-lint/pkg/parser"
+)
+
+// timeline lays out a timeline: its title, then its periods, each in the
+// section named by its Section.
+func (p *printer) timeline(tl *parser.Timeline) []stmt {
+	var stmts []stmt
+	if tl.Title != "" {
+		stmts = append(stmts, p.keyed("title", "title "+tl.Title))
+	}
+
+	sections := make([]sectionRef, len(tl.Sections))
+	for i, s := range tl.Sections {
+		sections[i] = sectionRef{s.Name, s.Line}
+	}
+	bodies := make([][]stmt, len(sections))
+	for _, period := range tl.Periods {
+		text := period.Label
+		for _, e := range period.Events {
+			text += " : " + e.Text
+		}
+		s := at(period.Range, text)
+		if i := sectionOf(sections, period.Section, period.Line); i >= 0 {
+			bodies[i] = append(bodies[i], s)
+		} else {
+			stmts = append(stmts, s)
+		}
+	}
+	for i, s := range tl.Sections {
+		stmts = append(stmts, sectionStmt(s.Range, s.Name, bodies[i]))
+	}
+	return sortByLine(stmts)
+}